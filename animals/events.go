@@ -0,0 +1,70 @@
+package animals
+
+import (
+	"go-test/models"
+	"log"
+	"sync"
+)
+
+// EventType - kind of change of a record.
+type EventType int
+
+const (
+	Created EventType = iota + 1
+	Replaced
+	Deleted
+	DescriptionUpdated
+)
+
+// Event - change of one record, published after it is stored.
+type Event struct {
+	Type EventType
+	// tenant of the record, subscribers only see their own
+	Tenant string
+	Animal models.AnimalWithID
+}
+
+// Broadcaster - in-process fan-out of events to all subscribers.
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: map[chan Event]struct{}{}}
+}
+
+// Subscribe returns a channel of the events published from now on and the
+// function ending the subscription, which closes the channel.
+func (b *Broadcaster) Subscribe(buffer int) (<-chan Event, func()) {
+	events := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subscribers[events] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, events)
+			b.mu.Unlock()
+			close(events)
+		})
+	}
+}
+
+// Publish hands the event to every subscriber without blocking,
+// subscribers with a full buffer miss it.
+func (b *Broadcaster) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			log.Printf("animals: slow subscriber missed event of record %d", event.Animal.ID)
+		}
+	}
+}
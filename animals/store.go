@@ -0,0 +1,387 @@
+// Package animals holds the record logic shared by the REST, GraphQL and gRPC APIs:
+// reads through the Redis cache, invalidation on change and change events.
+package animals
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"go-test/auth"
+	dbModels "go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/models"
+	"go-test/problems"
+	"go-test/requestid"
+	"go-test/tenants"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheTTL - lifetime of cached records.
+const cacheTTL = 1 * time.Hour
+
+// Store - animal records of the repository behind the cache. Redis and Events
+// are optional, records are not cached and changes not published without them.
+// Every call sees only the records of the tenant of its context, and changes
+// only those its principal owns or shares unless the policy grants manage.
+type Store struct {
+	Mu         *sync.Mutex
+	Repository *repository.AnimalRepository
+	Redis      *redis.Client
+	Events     *Broadcaster
+	// ownership is not checked when nil
+	Policy *auth.Policy
+}
+
+// List returns all active records.
+func (s *Store) List(ctx context.Context) ([]models.AnimalWithID, error) {
+	return s.ListColumns(ctx, nil)
+}
+
+// ListColumns returns all active records with only the columns read, all of them when nil.
+func (s *Store) ListColumns(ctx context.Context, columns []string) ([]models.AnimalWithID, error) {
+	defer s.lock(ctx)()
+
+	var records []dbModels.Animal
+	var err error
+	if columns == nil {
+		records, err = s.repository(ctx).FindAll()
+	} else {
+		records, err = s.repository(ctx).FindAllColumns(columns)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var animals []models.AnimalWithID
+	for _, record := range records {
+		animals = append(animals, WithID(record))
+	}
+	return animals, nil
+}
+
+// ListPage returns limit active records in id order after skipping offset,
+// with only the columns read, all of them when nil, and the number of all
+// active records for the links to the last page.
+func (s *Store) ListPage(ctx context.Context, columns []string, offset, limit int) ([]models.AnimalWithID, int64, error) {
+	defer s.lock(ctx)()
+
+	records, err := s.repository(ctx).FindPage(columns, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repository(ctx).GetCount()
+	if err != nil {
+		return nil, 0, err
+	}
+	animals := []models.AnimalWithID{}
+	for _, record := range records {
+		animals = append(animals, WithID(record))
+	}
+	return animals, total, nil
+}
+
+// Count returns the number of active records.
+func (s *Store) Count(ctx context.Context) (int64, error) {
+	defer s.lock(ctx)()
+
+	return s.repository(ctx).GetCount()
+}
+
+// Get returns an active record, from the cache when possible.
+func (s *Store) Get(ctx context.Context, id uint) (models.AnimalWithID, error) {
+	return s.GetColumns(ctx, id, nil)
+}
+
+// GetColumns returns an active record with only the columns read, all of them when nil.
+// Every projection is cached separately.
+func (s *Store) GetColumns(ctx context.Context, id uint, columns []string) (models.AnimalWithID, error) {
+	defer s.lock(ctx)()
+
+	// try to find in cache
+	projection := projectionKey(columns)
+	if s.cached(ctx) {
+		val, err := s.Redis.HGet(ctx, cacheKey(ctx, id), projection).Result()
+		if err == nil {
+			var animal models.AnimalWithID
+			if err := json.Unmarshal([]byte(val), &animal); err != nil {
+				// cannot unmarshal struct
+				return animal, problems.Internal(err)
+			}
+			return animal, nil
+		}
+	}
+
+	var record dbModels.Animal
+	var err error
+	if columns == nil {
+		record, err = s.repository(ctx).FindByID(id)
+	} else {
+		record, err = s.repository(ctx).FindByIDColumns(id, columns)
+	}
+	if err != nil {
+		return models.AnimalWithID{}, err
+	}
+	animal := WithID(record)
+	s.cache(ctx, animal, projection)
+	return animal, nil
+}
+
+// GetMany returns the active records of ids with one cache and one database
+// round trip, missing records are left out.
+func (s *Store) GetMany(ctx context.Context, ids []uint) (map[uint]models.AnimalWithID, error) {
+	defer s.lock(ctx)()
+
+	animals := map[uint]models.AnimalWithID{}
+	misses := ids
+	if s.cached(ctx) {
+		// full records of all ids in one round trip, errors are misses
+		gets := make([]*redis.StringCmd, len(ids))
+		s.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, id := range ids {
+				gets[i] = pipe.HGet(ctx, cacheKey(ctx, id), fullProjection)
+			}
+			return nil
+		})
+		misses = nil
+		for i, get := range gets {
+			var animal models.AnimalWithID
+			if val, err := get.Result(); err == nil && json.Unmarshal([]byte(val), &animal) == nil {
+				animals[ids[i]] = animal
+				continue
+			}
+			misses = append(misses, ids[i])
+		}
+	}
+	if len(misses) == 0 {
+		return animals, nil
+	}
+
+	records, err := s.repository(ctx).FindByIDs(misses)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		animal := WithID(record)
+		animals[record.ID] = animal
+		s.cache(ctx, animal, fullProjection)
+	}
+	return animals, nil
+}
+
+// Each calls fn for every active record in id order, fetched one by one. The
+// cursor only reads, so it runs without the store lock and slow consumers
+// never block writers.
+func (s *Store) Each(ctx context.Context, fn func(models.AnimalWithID) error) error {
+	rows, err := s.repository(ctx).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record, err := rows.Scan()
+		if err != nil {
+			return err
+		}
+		if err := fn(WithID(record)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) Create(ctx context.Context, input models.Animal) (models.AnimalWithID, error) {
+	defer s.lock(ctx)()
+
+	record, err := s.repository(ctx).Create(input)
+	if err != nil {
+		return models.AnimalWithID{}, err
+	}
+	return s.publish(ctx, Created, record), nil
+}
+
+func (s *Store) Replace(ctx context.Context, id uint, input models.Animal) (models.AnimalWithID, error) {
+	defer s.lock(ctx)()
+
+	if err := s.invalidate(ctx, id); err != nil {
+		return models.AnimalWithID{}, err
+	}
+	record, err := s.repository(ctx).Replace(id, input)
+	if err != nil {
+		return models.AnimalWithID{}, err
+	}
+	return s.publish(ctx, Replaced, record), nil
+}
+
+func (s *Store) Delete(ctx context.Context, id uint) (models.AnimalWithID, error) {
+	defer s.lock(ctx)()
+
+	if err := s.invalidate(ctx, id); err != nil {
+		return models.AnimalWithID{}, err
+	}
+	record, err := s.repository(ctx).Delete(id)
+	if err != nil {
+		return models.AnimalWithID{}, err
+	}
+	return s.publish(ctx, Deleted, record), nil
+}
+
+func (s *Store) UpdateDescription(ctx context.Context, id uint, description string) (models.AnimalWithID, error) {
+	defer s.lock(ctx)()
+
+	if err := s.invalidate(ctx, id); err != nil {
+		return models.AnimalWithID{}, err
+	}
+	record, err := s.repository(ctx).UpdateDescription(id, description)
+	if err != nil {
+		return models.AnimalWithID{}, err
+	}
+	return s.publish(ctx, DescriptionUpdated, record), nil
+}
+
+// Import creates the record or, with an import key, updates the one matching
+// it, like Create and Replace do.
+func (s *Store) Import(ctx context.Context, input models.ImportRecord, key string) (models.AnimalWithID, bool, error) {
+	defer s.lock(ctx)()
+
+	if key == repository.ImportKeyID {
+		if err := s.invalidate(ctx, input.ID); err != nil {
+			return models.AnimalWithID{}, false, err
+		}
+	}
+	record, created, err := s.repository(ctx).Import(input, key)
+	if err != nil {
+		return models.AnimalWithID{}, false, err
+	}
+	if created {
+		return s.publish(ctx, Created, record), true, nil
+	}
+	if key != repository.ImportKeyID {
+		// the id of records matched by external id is only known now, the
+		// record is changed already, so a failure is only logged
+		if err := s.invalidate(ctx, record.ID); err != nil {
+			requestid.Printf(ctx, "animals: cache: %v", err)
+		}
+	}
+	return s.publish(ctx, Replaced, record), false, nil
+}
+
+// Shares returns the users and groups the record is shared with.
+func (s *Store) Shares(ctx context.Context, id uint) ([]dbModels.AnimalShare, error) {
+	defer s.lock(ctx)()
+
+	return s.repository(ctx).FindShares(id)
+}
+
+// Share lets the user or the group change the record, only its owner may share it.
+func (s *Store) Share(ctx context.Context, id uint, granteeType, grantee string) (dbModels.AnimalShare, error) {
+	defer s.lock(ctx)()
+
+	return s.repository(ctx).Share(id, granteeType, grantee)
+}
+
+// Unshare removes a share of the record, only its owner may remove it.
+func (s *Store) Unshare(ctx context.Context, id, shareID uint) (dbModels.AnimalShare, error) {
+	defer s.lock(ctx)()
+
+	return s.repository(ctx).Unshare(id, shareID)
+}
+
+// key of the actor set by WithActor
+type actorKey struct{}
+
+// WithActor returns a copy of ctx whose changes are made by the actor, as
+// background jobs do for the principal which enqueued them.
+func WithActor(ctx context.Context, actor repository.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorOf returns who changes records in ctx. Anonymous requests, when
+// authentication is off, and principals granted manage by the policy may
+// change every record.
+func ActorOf(ctx context.Context, policy *auth.Policy) repository.Actor {
+	if actor, ok := ctx.Value(actorKey{}).(repository.Actor); ok {
+		return actor
+	}
+	principal, ok := auth.FromContext(ctx)
+	actor := repository.Actor{Subject: principal.Subject, Groups: principal.Groups}
+	actor.Unrestricted = !ok || policy == nil || policy.Allows(principal, auth.PermManage)
+	return actor
+}
+
+// cache stores the projection of the record in the hash of its id, so that
+// invalidation drops all projections at once. A failure only costs the next
+// read a query.
+func (s *Store) cache(ctx context.Context, animal models.AnimalWithID, projection string) {
+	if !s.cached(ctx) {
+		return
+	}
+	jsonValue, err := json.Marshal(animal)
+	if err == nil {
+		key := cacheKey(ctx, uint(animal.ID))
+		_, err = s.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, projection, jsonValue)
+			pipe.Expire(ctx, key, cacheTTL)
+			return nil
+		})
+	}
+	if err != nil {
+		requestid.Printf(ctx, "animals: cache: %v", err)
+	}
+}
+
+// invalidate drops the cached record before it changes
+func (s *Store) invalidate(ctx context.Context, id uint) error {
+	if s.Redis == nil {
+		return nil
+	}
+	if err := s.Redis.Del(ctx, cacheKey(ctx, id)).Err(); err != nil {
+		return problems.Cache(err)
+	}
+	return nil
+}
+
+// publish reports the change, changes of a transaction once it is committed
+func (s *Store) publish(ctx context.Context, eventType EventType, record dbModels.Animal) models.AnimalWithID {
+	animal := WithID(record)
+	event := Event{Type: eventType, Tenant: tenants.FromContext(ctx), Animal: animal}
+	if tx, ok := ctx.Value(txKey{}).(*transaction); ok {
+		tx.events = append(tx.events, event)
+		return animal
+	}
+	s.Events.Publish(event)
+	return animal
+}
+
+// cache key of a record, a hash with one field per projection, in the
+// namespace of the tenant of ctx
+func cacheKey(ctx context.Context, id uint) string {
+	return tenants.FromContext(ctx) + ":" + strconv.Itoa(int(id))
+}
+
+// hash field of records with all columns
+const fullProjection = "*"
+
+// projectionKey is the hash field of the columns, which callers list in a fixed order
+func projectionKey(columns []string) string {
+	if columns == nil {
+		return fullProjection
+	}
+	return strings.Join(columns, ",")
+}
+
+// WithID converts a stored record into its API representation.
+func WithID(record dbModels.Animal) models.AnimalWithID {
+	return models.AnimalWithID{
+		ID: int(record.ID),
+		Animal: models.Animal{
+			Name:        record.Name,
+			Type:        record.Type,
+			Description: record.Description,
+		},
+	}
+}
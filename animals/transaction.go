@@ -0,0 +1,71 @@
+package animals
+
+import (
+	"context"
+	"go-test/db-utils/repository"
+	"go-test/tenants"
+)
+
+// key of the running transaction in the context
+type txKey struct{}
+
+// transaction - repository of a database transaction and the events it holds back.
+type transaction struct {
+	repository repository.AnimalRepository
+	events     []Event
+}
+
+// Transaction runs fn in a database transaction, which is committed when fn
+// returns nil. Store calls with the context passed to fn take part in it:
+// they skip the cache fill, and their events are published after the commit.
+// The store is locked for the whole transaction.
+func (s *Store) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	var events []Event
+	err := s.repository(ctx).Transaction(func(rp repository.AnimalRepository) error {
+		tx := &transaction{repository: rp}
+		if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+			return err
+		}
+		events = tx.events
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		s.Events.Publish(event)
+	}
+	return nil
+}
+
+// InTransaction reports whether ctx belongs to a running transaction.
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*transaction)
+	return ok
+}
+
+// repository returns the repository of the running transaction, if any,
+// otherwise the one of the tenant and the actor of ctx
+func (s *Store) repository(ctx context.Context) repository.AnimalRepository {
+	if tx, ok := ctx.Value(txKey{}).(*transaction); ok {
+		return tx.repository
+	}
+	return (*s.Repository).ForTenant(tenants.FromContext(ctx)).WithContext(ctx).As(ActorOf(ctx, s.Policy))
+}
+
+// lock locks the store unless the transaction of ctx holds it already, and returns the unlock
+func (s *Store) lock(ctx context.Context) func() {
+	if InTransaction(ctx) {
+		return func() {}
+	}
+	s.Mu.Lock()
+	return s.Mu.Unlock
+}
+
+// cached reports whether reads of ctx may use the cache, uncommitted records are never cached
+func (s *Store) cached(ctx context.Context) bool {
+	return s.Redis != nil && !InTransaction(ctx)
+}
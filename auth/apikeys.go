@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	dbModels "go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/requestid"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scopes of API keys
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+	// CONNECT tunnels only
+	ScopeTunnel = "tunnel"
+)
+
+// APIKeyScopes - every scope an API key can be granted.
+var APIKeyScopes = []string{ScopeRead, ScopeWrite, ScopeAdmin, ScopeTunnel}
+
+// ErrInvalidAPIKey - the key is unknown, revoked or expired.
+var ErrInvalidAPIKey = errors.New("api key is invalid, revoked or expired")
+
+// API keys look like ak_<prefix>_<secret>, the prefix is stored in clear
+const apiKeyTag = "ak"
+
+// NewAPIKey generates a random key and returns it with its prefix and hash,
+// the key itself is shown once and never stored.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	random := make([]byte, 6+32)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(random[:6])
+	key = apiKeyTag + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(random[6:])
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey - hash of the key as stored. Keys are random, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeys - validates API keys against the repository.
+type APIKeys struct {
+	Repository *repository.APIKeyRepository
+	// last use is recorded at most once per interval and key
+	TouchInterval time.Duration
+}
+
+// Verify checks the key and returns the principal of its scopes.
+func (k *APIKeys) Verify(ctx context.Context, key string) (Principal, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyTag {
+		return Principal{}, ErrInvalidAPIKey
+	}
+	record, err := (*k.Repository).WithContext(ctx).FindByPrefix(parts[1])
+	if errors.Is(err, repository.ErrUnknownAPIKey) {
+		return Principal{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(record.Hash)) != 1 || !Usable(record, time.Now()) {
+		return Principal{}, ErrInvalidAPIKey
+	}
+	k.touch(ctx, record)
+	return Principal{
+		Subject: "api-key:" + strconv.Itoa(int(record.ID)),
+		Method:  MethodAPIKey,
+		Scopes:  strings.Fields(record.Scopes),
+		Tenant:  record.TenantID,
+	}, nil
+}
+
+// Usable reports whether the key is neither revoked nor expired at the time.
+func Usable(key dbModels.APIKey, at time.Time) bool {
+	return key.RevokedAt == nil && (key.ExpiresAt == nil || at.Before(*key.ExpiresAt))
+}
+
+// touch records the use of the key, a failure is only logged
+func (k *APIKeys) touch(ctx context.Context, key dbModels.APIKey) {
+	now := time.Now()
+	if key.LastUsedAt != nil && now.Sub(*key.LastUsedAt) < k.TouchInterval {
+		return
+	}
+	if err := (*k.Repository).WithContext(ctx).Touch(key.ID, now); err != nil {
+		requestid.Printf(ctx, "auth: recording use of api key %d: %v", key.ID, err)
+	}
+}
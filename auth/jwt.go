@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+	"time"
+)
+
+// signing algorithms accepted in tokens, "none" and others are rejected
+var algorithms = []string{"HS256", "RS256", "ES256"}
+
+// Verifier - validates bearer tokens against the keys of a source.
+type Verifier struct {
+	Keys KeySource
+	// expected iss and aud claims, not checked when empty
+	Issuer   string
+	Audience string
+	// allowed clock skew for exp, nbf and iat
+	Leeway time.Duration
+}
+
+// claims - registered claims and the authorization claims read from tokens
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+	// space separated, as in OAuth 2.0
+	Scope  string   `json:"scope"`
+	Groups []string `json:"groups"`
+	// tenant the token is issued for
+	Tenant string `json:"tenant"`
+}
+
+// Verify validates the signature and the claims of the token and returns its principal.
+func (v *Verifier) Verify(ctx context.Context, token string) (Principal, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(v.Leeway),
+	}
+	if v.Issuer != "" {
+		options = append(options, jwt.WithIssuer(v.Issuer))
+	}
+	if v.Audience != "" {
+		options = append(options, jwt.WithAudience(v.Audience))
+	}
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.Keys.Key(ctx, kid, t.Method.Alg())
+	}, options...)
+	if err != nil {
+		return Principal{}, err
+	}
+	if c.Subject == "" {
+		return Principal{}, errors.New("token has no subject")
+	}
+	return Principal{
+		Subject: c.Subject,
+		Method:  MethodJWT,
+		Roles:   c.Roles,
+		Scopes:  strings.Fields(c.Scope),
+		Groups:  c.Groups,
+		Tenant:  c.Tenant,
+	}, nil
+}
@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey - the source has no key of the requested id and algorithm.
+var ErrUnknownKey = errors.New("no key for the token")
+
+// KeySource - verification keys of signed tokens, looked up by key id and algorithm.
+type KeySource interface {
+	Key(ctx context.Context, kid, alg string) (interface{}, error)
+}
+
+// HMACSecret - shared secret of HS256 tokens, regardless of their key id.
+type HMACSecret []byte
+
+func (s HMACSecret) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	if alg != "HS256" || len(s) == 0 {
+		return nil, ErrUnknownKey
+	}
+	return []byte(s), nil
+}
+
+// Sources asks each source in turn, until one of them knows the key.
+func Sources(sources ...KeySource) KeySource {
+	return sourceList(sources)
+}
+
+type sourceList []KeySource
+
+func (l sourceList) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	for _, source := range l {
+		key, err := source.Key(ctx, kid, alg)
+		if errors.Is(err, ErrUnknownKey) {
+			continue
+		}
+		return key, err
+	}
+	return nil, ErrUnknownKey
+}
+
+// KeySet - public keys of a JWKS document by key id.
+type KeySet map[string]interface{}
+
+// jwk - the members of a JSON Web Key used for RSA and P-256 keys
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS reads the RSA and P-256 signing keys of a JWKS document, other keys are skipped.
+func ParseJWKS(data []byte) (KeySet, error) {
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	set := KeySet{}
+	for _, k := range document.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsa()
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			key, err = k.ecdsa()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+		set[k.Kid] = key
+	}
+	return set, nil
+}
+
+func (k jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := decodeInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() {
+		return nil, errors.New("exponent is too large")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecdsa() (*ecdsa.PublicKey, error) {
+	x, err := decodeInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	if !key.Curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return key, nil
+}
+
+func decodeInt(raw string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid base64url number")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// Key returns the key of the id if it suits the algorithm, so that an RSA key
+// never verifies an ES256 token and the other way round.
+func (s KeySet) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if alg == "RS256" {
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if alg == "ES256" {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// FileKeys - keys of a local JWKS file, read again when the file changes.
+type FileKeys struct {
+	Path    string
+	mu      sync.Mutex
+	modTime time.Time
+	keys    KeySet
+}
+
+func NewFileKeys(path string) (*FileKeys, error) {
+	f := &FileKeys{Path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FileKeys) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// rotated keys are picked up without restart, the old set is kept on errors
+	if err := f.reload(); err != nil {
+		if f.keys == nil {
+			return nil, err
+		}
+		log.Printf("auth: %v", err)
+	}
+	return f.keys.Key(ctx, kid, alg)
+}
+
+// reload reads the file when it was modified since the last read
+func (f *FileKeys) reload() error {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return err
+	}
+	if f.keys != nil && info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	f.keys, f.modTime = keys, info.ModTime()
+	return nil
+}
+
+// RemoteKeys - keys of a JWKS URL, cached for TTL. An unknown key id triggers
+// a refresh, so that rotated keys are found before the cache expires. The URL
+// is fetched at most once per MinRefresh and the last keys are kept while it fails.
+type RemoteKeys struct {
+	URL        string
+	TTL        time.Duration
+	MinRefresh time.Duration
+	Client     *http.Client
+
+	mu          sync.Mutex
+	keys        KeySet
+	err         error
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+func NewRemoteKeys(url string, ttl time.Duration) *RemoteKeys {
+	return &RemoteKeys{
+		URL:        url,
+		TTL:        ttl,
+		MinRefresh: 30 * time.Second,
+		Client:     &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (r *RemoteKeys) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.keys == nil || now.Sub(r.fetchedAt) >= r.TTL {
+		r.refresh(ctx, now)
+	}
+	if r.keys == nil {
+		return nil, r.err
+	}
+	key, err := r.keys.Key(ctx, kid, alg)
+	if errors.Is(err, ErrUnknownKey) && r.refresh(ctx, now) {
+		return r.keys.Key(ctx, kid, alg)
+	}
+	return key, err
+}
+
+// refresh fetches the keys unless that was attempted recently, true when they were replaced
+func (r *RemoteKeys) refresh(ctx context.Context, now time.Time) bool {
+	if !r.attemptedAt.IsZero() && now.Sub(r.attemptedAt) < r.MinRefresh {
+		return false
+	}
+	r.attemptedAt = now
+	if r.err = r.fetch(ctx, now); r.err != nil {
+		log.Printf("auth: %v", r.err)
+		return false
+	}
+	return true
+}
+
+func (r *RemoteKeys) fetch(ctx context.Context, now time.Time) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return err
+	}
+	res, err := r.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching JWKS: %s", res.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	r.keys, r.fetchedAt = keys, now
+	return nil
+}
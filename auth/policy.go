@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// permissions required by routes
+const (
+	PermRead    = "read"
+	PermCreate  = "create"
+	PermReplace = "replace"
+	PermDelete  = "delete"
+	PermPurge   = "purge"
+	PermAdmin   = "admin"
+	// change records owned by others
+	PermManage = "manage"
+	// open CONNECT tunnels
+	PermTunnel = "tunnel"
+	// change the quotas of every tenant, which tenant admins may not
+	PermQuotas = "quotas"
+)
+
+// Permissions - every permission a policy can grant.
+var Permissions = []string{PermRead, PermCreate, PermReplace, PermDelete, PermPurge, PermAdmin, PermManage, PermTunnel, PermQuotas}
+
+// Policy - permissions granted to the roles of users and the scopes of API keys.
+type Policy struct {
+	Roles  map[string][]string `json:"roles"`
+	Scopes map[string][]string `json:"scopes"`
+}
+
+// DefaultPolicy - policy used when the configuration names no policy file.
+var DefaultPolicy = Policy{
+	Roles: map[string][]string{
+		"viewer":   {PermRead},
+		"editor":   {PermRead, PermCreate, PermReplace, PermDelete},
+		"admin":    {PermRead, PermCreate, PermReplace, PermDelete, PermPurge, PermAdmin, PermManage, PermTunnel},
+		"operator": {PermQuotas},
+	},
+	Scopes: map[string][]string{
+		ScopeRead:   {PermRead},
+		ScopeWrite:  {PermCreate, PermReplace, PermDelete},
+		ScopeAdmin:  {PermAdmin},
+		ScopeTunnel: {PermTunnel},
+	},
+}
+
+// LoadPolicy reads a policy file, unknown permissions are an error.
+func LoadPolicy(path string) (Policy, error) {
+	var policy Policy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	for _, grants := range []map[string][]string{policy.Roles, policy.Scopes} {
+		for name, permissions := range grants {
+			for _, permission := range permissions {
+				if !slices.Contains(Permissions, permission) {
+					return policy, fmt.Errorf("invalid policy %s: %s grants unknown permission %q", path, name, permission)
+				}
+			}
+		}
+	}
+	return policy, nil
+}
+
+// Allows reports whether the principal holds the permission, through one of
+// its roles or, for API keys, one of its scopes.
+func (p *Policy) Allows(principal Principal, permission string) bool {
+	grants, names := p.Roles, principal.Roles
+	if principal.Method == MethodAPIKey {
+		grants, names = p.Scopes, principal.Scopes
+	}
+	for _, name := range names {
+		if slices.Contains(grants[name], permission) {
+			return true
+		}
+	}
+	return false
+}
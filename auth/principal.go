@@ -0,0 +1,62 @@
+// Package auth authenticates the clients of the API and carries who they are
+// through the request.
+package auth
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+)
+
+// PrincipalKey - key of the authenticated principal in the gin context.
+const PrincipalKey = "principal"
+
+// authentication methods of a principal
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api-key"
+)
+
+// Principal - authenticated client of a request.
+type Principal struct {
+	// sub claim of the token, api-key:<id> for API keys
+	Subject string
+	Method  string
+	// roles claim of users, mapped to permissions by the policy
+	Roles []string
+	// scopes of API keys, also mapped by the policy
+	Scopes []string
+	// groups claim of users, records may be shared with them
+	Groups []string
+	// tenant claim of tokens, tenant of API keys, empty for the default tenant
+	Tenant string
+}
+
+// key of the principal in request contexts
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal of the request context, false for anonymous requests.
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Current returns the principal of the gin request, false for anonymous requests.
+func Current(c *gin.Context) (Principal, bool) {
+	principal, ok := c.Get(PrincipalKey)
+	if !ok {
+		return Principal{}, false
+	}
+	return principal.(Principal), true
+}
+
+// Set stores the principal in the gin context and in the request context,
+// which is passed on to the store and background work.
+func Set(c *gin.Context, principal Principal) {
+	c.Set(PrincipalKey, principal)
+	c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), principal))
+}
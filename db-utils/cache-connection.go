@@ -8,7 +8,7 @@ import (
 	"time"
 )
 
-func ConnectRedis(DBAddr, DBPassword string, DBName int) *redis.Client {
+func ConnectRedis(DBAddr, DBPassword string, DBName int, logCommands bool) *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:        DBAddr,
 		Password:    DBPassword,
@@ -22,5 +22,7 @@ func ConnectRedis(DBAddr, DBPassword string, DBName int) *redis.Client {
 		log.Fatalf("Could not connect to Redis: %v\n", err)
 	}
 	fmt.Println("Connected to Redis")
+	// calls of requests are logged with their request id, failed ones only unless logCommands
+	rdb.AddHook(RequestLogger{Commands: logCommands})
 	return rdb
 }
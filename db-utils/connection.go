@@ -32,6 +32,10 @@ func Connect(DBUser, DBPassword, DBHost, DBName, DBSSLMode, DBPort string) *gorm
 	if err != nil {
 		log.Fatal("Could not connect to the database after several attempts: ", err)
 	}
+	// statements of requests name their request id
+	if err := db.Use(RequestComments{}); err != nil {
+		log.Fatal(err)
+	}
 
 	// creating table if not exists
 	err = migrations.MigrateAllTables(db)
@@ -0,0 +1,10 @@
+package migrations
+
+import (
+	"go-test/db-utils/models"
+	"gorm.io/gorm"
+)
+
+func MigrateAnimalShares(db *gorm.DB) error {
+	return migrateTable(db, &models.AnimalShare{})
+}
@@ -9,28 +9,52 @@ import (
 )
 
 func MigrateAllTables(db *gorm.DB) error {
-	return MigrateAnimals(db)
+	if err := MigrateAnimals(db); err != nil {
+		return err
+	}
+	if err := MigrateAnimalShares(db); err != nil {
+		return err
+	}
+	if err := MigrateJobs(db); err != nil {
+		return err
+	}
+	if err := MigrateAPIKeys(db); err != nil {
+		return err
+	}
+	return MigrateQuotas(db)
 }
 
 func MigrateAnimals(db *gorm.DB) error {
+	if err := migrateTable(db, &models.Animal{}); err != nil {
+		return err
+	}
+	// external ids used to be unique across all tenants
+	if db.Migrator().HasIndex(&models.Animal{}, "idx_animals_external_id") {
+		return db.Migrator().DropIndex(&models.Animal{}, "idx_animals_external_id")
+	}
+	return nil
+}
+
+// migrateTable brings the table of a gorm model in line with its schema
+func migrateTable(db *gorm.DB, model interface{}) error {
 	// define as a transaction block
 	return db.Transaction(func(tx *gorm.DB) error {
 		// apply manual changes for every condition
 		// no table
-		if !tx.Migrator().HasTable(&models.Animal{}) {
-			if err := tx.Migrator().CreateTable(&models.Animal{}); err != nil {
+		if !tx.Migrator().HasTable(model) {
+			if err := tx.Migrator().CreateTable(model); err != nil {
 				return err
 			}
 			// no additional checks required
 			return nil
 		}
 		// get column names in existing table
-		columns, err := db.Migrator().ColumnTypes(&models.Animal{})
+		columns, err := db.Migrator().ColumnTypes(model)
 		if err != nil {
 			return err
 		}
 		// get columns names in the gorm schema
-		s, err := schema.Parse(&models.Animal{}, &sync.Map{}, schema.NamingStrategy{})
+		s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
 		if err != nil {
 			panic("failed to parse schema")
 		}
@@ -40,14 +64,22 @@ func MigrateAnimals(db *gorm.DB) error {
 		}
 		// add missing columns
 		for _, column := range schemaColumns {
-			if !tx.Migrator().HasColumn(&models.Animal{}, column) {
-				tx.Migrator().AddColumn(&models.Animal{}, column)
+			if !tx.Migrator().HasColumn(model, column) {
+				tx.Migrator().AddColumn(model, column)
 			}
 		}
 		// remove redundant columns
 		for _, column := range columns {
 			if !slices.Contains(schemaColumns, column.Name()) {
-				tx.Migrator().DropColumn(&models.Animal{}, column.Name())
+				tx.Migrator().DropColumn(model, column.Name())
+			}
+		}
+		// add missing indexes
+		for _, index := range s.ParseIndexes() {
+			if !tx.Migrator().HasIndex(model, index.Name) {
+				if err := tx.Migrator().CreateIndex(model, index.Name); err != nil {
+					return err
+				}
 			}
 		}
 
@@ -0,0 +1,13 @@
+package migrations
+
+import (
+	"go-test/db-utils/models"
+	"gorm.io/gorm"
+)
+
+func MigrateQuotas(db *gorm.DB) error {
+	if err := migrateTable(db, &models.Quota{}); err != nil {
+		return err
+	}
+	return migrateTable(db, &models.QuotaUsage{})
+}
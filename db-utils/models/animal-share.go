@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// grantee types of shares
+const (
+	ShareUser  = "user"
+	ShareGroup = "group"
+)
+
+// AnimalShare - permission of a user or a group to change a record of someone else.
+type AnimalShare struct {
+	ID          uint   `gorm:"primaryKey"`
+	TenantID    string `gorm:"not null;default:'default';index"`
+	CreatedAt   time.Time
+	AnimalID    uint   `gorm:"uniqueIndex:idx_animal_shares_grantee"`
+	GranteeType string `gorm:"uniqueIndex:idx_animal_shares_grantee"`
+	Grantee     string `gorm:"uniqueIndex:idx_animal_shares_grantee"` // subject of the user or name of the group
+	CreatedBy   string
+}
@@ -5,11 +5,15 @@ import (
 )
 
 type Animal struct {
-	ID          uint `gorm:"primaryKey"`
+	ID          uint   `gorm:"primaryKey"`
+	TenantID    string `gorm:"not null;default:'default';uniqueIndex:idx_animals_tenant_external_id"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	Name        string
 	Type        int
 	Description string
-	IsActive    bool `gorm:"default:true"`
+	ExternalID  *string `gorm:"uniqueIndex:idx_animals_tenant_external_id"` // key of the record in an imported dataset, unique per tenant
+	IsActive    bool    `gorm:"default:true"`
+	CreatedBy   string  // subject of the principal, who owns the record
+	UpdatedBy   string
 }
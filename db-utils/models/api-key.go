@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKey - long-lived credential of a machine client. Only the hash of the
+// secret is stored, the prefix identifies the key in requests and lists.
+type APIKey struct {
+	ID         uint   `gorm:"primaryKey"`
+	TenantID   string `gorm:"not null;default:'default';index"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Name       string
+	Prefix     string `gorm:"uniqueIndex"`
+	Hash       string
+	Scopes     string // space separated
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
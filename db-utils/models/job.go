@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// job states
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+type Job struct {
+	ID              uint   `gorm:"primaryKey"`
+	TenantID        string `gorm:"not null;default:'default';index"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Kind            string `gorm:"index"`
+	Status          string `gorm:"index"`
+	Payload         string `gorm:"type:text"`
+	Result          string `gorm:"type:text"`
+	Error           string
+	Progress        int    // percent of done work
+	Checkpoint      string `gorm:"type:text"` // state saved by the handler, attempts resume from it
+	Attempts        int
+	MaxAttempts     int
+	RunAt           time.Time `gorm:"index"` // earliest time of the next attempt
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+	CancelRequested bool
+	RequestID       string // of the request which enqueued the job, its log lines carry it
+}
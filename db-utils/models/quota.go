@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// quota periods, counted in UTC
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+)
+
+// Quota - plan of an API key or tenant, and limits overriding those of the plan.
+type Quota struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// api-key:<id> or tenant:<id>
+	Subject string `gorm:"uniqueIndex;not null"`
+	// default plan when empty
+	Plan         string
+	DailyLimit   *int64
+	MonthlyLimit *int64
+	UpdatedBy    string
+}
+
+// QuotaUsage - requests of a subject in one day or month.
+type QuotaUsage struct {
+	Subject string    `gorm:"primaryKey"`
+	Period  string    `gorm:"primaryKey"`
+	Start   time.Time `gorm:"primaryKey"`
+	Count   int64     `gorm:"not null;default:0"`
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+	"go-test/db-utils/models"
+	"slices"
+)
+
+// ErrNotPermitted - record belongs to someone else and is not shared with the actor.
+var ErrNotPermitted = errors.New("record is owned by another user")
+
+// Actor - principal changing records. Records may be changed by their owner
+// and by those they are shared with, by unrestricted actors all of them.
+type Actor struct {
+	Subject      string   `json:"subject"`
+	Groups       []string `json:"groups,omitempty"`
+	Unrestricted bool     `json:"unrestricted"`
+}
+
+// Owns reports whether the actor may change the record and manage its shares.
+func (a Actor) Owns(animal models.Animal) bool {
+	return a.Unrestricted || (a.Subject != "" && animal.CreatedBy == a.Subject)
+}
+
+// SharedWith reports whether one of the shares grants the actor changes.
+func (a Actor) SharedWith(shares []models.AnimalShare) bool {
+	for _, share := range shares {
+		switch share.GranteeType {
+		case models.ShareUser:
+			if a.Subject != "" && share.Grantee == a.Subject {
+				return true
+			}
+		case models.ShareGroup:
+			if slices.Contains(a.Groups, share.Grantee) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-test/db-utils/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type APIKeyRepository interface {
+	Create(key models.APIKey) (models.APIKey, error)
+	FindAll() ([]models.APIKey, error)
+	FindByID(id uint) (models.APIKey, error)
+	FindByPrefix(prefix string) (models.APIKey, error)
+	Rotate(id uint, prefix, hash string) (models.APIKey, error)
+	Revoke(id uint) (models.APIKey, error)
+	Touch(id uint, usedAt time.Time) error
+	ForTenant(tenant string) APIKeyRepository
+	WithContext(ctx context.Context) APIKeyRepository
+}
+
+// ErrUnknownAPIKey - no key has the prefix.
+var ErrUnknownAPIKey = errors.New("unknown api key")
+
+// ErrAPIKeyRevoked - key has been revoked and cannot change anymore.
+var ErrAPIKeyRevoked = errors.New("api key is revoked")
+
+// APIKeyRepositoryImpl - keys of all tenants, as authentication needs them,
+// or of one tenant once bound by ForTenant.
+type APIKeyRepositoryImpl struct {
+	db     *gorm.DB
+	tenant string
+	bound  bool
+}
+
+func NewAPIKeysRepositoryImpl(DB *gorm.DB) APIKeyRepository {
+	return &APIKeyRepositoryImpl{db: DB}
+}
+
+func (a *APIKeyRepositoryImpl) ForTenant(tenant string) APIKeyRepository {
+	return &APIKeyRepositoryImpl{db: a.db, tenant: tenant, bound: true}
+}
+
+// WithContext runs the statements in the context of a request, whose id they carry.
+func (a *APIKeyRepositoryImpl) WithContext(ctx context.Context) APIKeyRepository {
+	return &APIKeyRepositoryImpl{db: a.db.WithContext(ctx), tenant: a.tenant, bound: a.bound}
+}
+
+// scoped limits the query to the keys of the bound tenant
+func (a *APIKeyRepositoryImpl) scoped(db *gorm.DB) *gorm.DB {
+	if !a.bound {
+		return db
+	}
+	return db.Scopes(TenantScope(a.tenant))
+}
+
+func (a *APIKeyRepositoryImpl) Create(key models.APIKey) (models.APIKey, error) {
+	if a.bound {
+		key.TenantID = a.tenant
+	}
+	result := a.scoped(a.db).Create(&key)
+	if result.Error != nil {
+		return key, result.Error
+	}
+	return key, nil
+}
+
+func (a *APIKeyRepositoryImpl) FindAll() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	result := a.scoped(a.db).Order("id").Find(&keys)
+	if result.Error != nil {
+		return keys, result.Error
+	}
+	return keys, nil
+}
+
+func (a *APIKeyRepositoryImpl) FindByID(id uint) (models.APIKey, error) {
+	var key models.APIKey
+	result := a.scoped(a.db).First(&key, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return key, &NotFoundError{Id: id, When: time.Now()}
+		} else {
+			return key, result.Error
+		}
+	}
+	return key, nil
+}
+
+func (a *APIKeyRepositoryImpl) FindByPrefix(prefix string) (models.APIKey, error) {
+	var key models.APIKey
+	result := a.db.Where("prefix = ?", prefix).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return key, ErrUnknownAPIKey
+		}
+		return key, result.Error
+	}
+	return key, nil
+}
+
+func (a *APIKeyRepositoryImpl) Rotate(id uint, prefix, hash string) (models.APIKey, error) {
+	return a.update(id, func(key *models.APIKey) error {
+		if key.RevokedAt != nil {
+			return ErrAPIKeyRevoked
+		}
+		// the old secret stops working right away
+		key.Prefix = prefix
+		key.Hash = hash
+		return nil
+	})
+}
+
+func (a *APIKeyRepositoryImpl) Revoke(id uint) (models.APIKey, error) {
+	return a.update(id, func(key *models.APIKey) error {
+		// revoking twice keeps the first time
+		if key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+		}
+		return nil
+	})
+}
+
+func (a *APIKeyRepositoryImpl) Touch(id uint, usedAt time.Time) error {
+	// no updated_at change, the key itself did not change
+	return a.db.Model(&models.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
+
+// update changes the locked key with fn and saves it
+func (a *APIKeyRepositoryImpl) update(id uint, fn func(key *models.APIKey) error) (models.APIKey, error) {
+	var key models.APIKey
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		result := a.scoped(tx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&key, id)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return &NotFoundError{Id: id, When: time.Now()}
+			}
+			return result.Error
+		}
+		if err := fn(&key); err != nil {
+			return err
+		}
+		return tx.Save(&key).Error
+	})
+	return key, err
+}
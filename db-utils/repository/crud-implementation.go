@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"go-test/db-utils/models"
@@ -11,12 +13,43 @@ import (
 
 type AnimalRepository interface {
 	FindAll() ([]models.Animal, error)
+	Rows() (AnimalRows, error)
 	GetCount() (int64, error)
 	FindByID(id uint) (models.Animal, error)
+	FindByIDs(ids []uint) ([]models.Animal, error)
+	FindAllColumns(columns []string) ([]models.Animal, error)
+	FindPage(columns []string, offset, limit int) ([]models.Animal, error)
+	FindByIDColumns(id uint, columns []string) (models.Animal, error)
 	Create(animal inputModels.Animal) (models.Animal, error)
 	Replace(id uint, animal inputModels.Animal) (models.Animal, error)
 	Delete(id uint) (models.Animal, error)
 	UpdateDescription(id uint, description string) (models.Animal, error)
+	Import(record inputModels.ImportRecord, key string) (models.Animal, bool, error)
+	Transaction(fn func(rp AnimalRepository) error) error
+	ForTenant(tenant string) AnimalRepository
+	As(actor Actor) AnimalRepository
+	WithContext(ctx context.Context) AnimalRepository
+	FindShares(id uint) ([]models.AnimalShare, error)
+	Share(id uint, granteeType, grantee string) (models.AnimalShare, error)
+	Unshare(id, shareID uint) (models.AnimalShare, error)
+}
+
+// AnimalColumns - columns which reads may select, fields left out stay zero.
+var AnimalColumns = []string{"id", "name", "type", "description"}
+
+// keys to match imported records with existing ones
+const (
+	ImportKeyNone       = ""
+	ImportKeyID         = "id"
+	ImportKeyExternalID = "external_id"
+)
+
+// AnimalRows - cursor over active animal records, must be closed by the caller.
+type AnimalRows interface {
+	Next() bool
+	Scan() (models.Animal, error)
+	Err() error
+	Close() error
 }
 
 type NotFoundError struct {
@@ -29,17 +62,41 @@ func (e *NotFoundError) Error() string {
 		e.Id, e.When)
 }
 
+// AnimalRepositoryImpl - animal records of one tenant, changed by one actor.
+// Repositories created by NewAnimalsRepositoryImpl belong to no tenant and
+// fail every query with ErrNoTenant, ForTenant binds them. Changes need the
+// actor, bound by As, to own the record or to be one it is shared with.
 type AnimalRepositoryImpl struct {
-	db *gorm.DB
+	db     *gorm.DB
+	tenant string
+	actor  Actor
 }
 
 func NewAnimalsRepositoryImpl(DB *gorm.DB) AnimalRepository {
 	return &AnimalRepositoryImpl{db: DB}
 }
 
+func (a *AnimalRepositoryImpl) ForTenant(tenant string) AnimalRepository {
+	return &AnimalRepositoryImpl{db: a.db, tenant: tenant, actor: a.actor}
+}
+
+func (a *AnimalRepositoryImpl) As(actor Actor) AnimalRepository {
+	return &AnimalRepositoryImpl{db: a.db, tenant: a.tenant, actor: actor}
+}
+
+// WithContext runs the statements in the context of a request, whose id they carry.
+func (a *AnimalRepositoryImpl) WithContext(ctx context.Context) AnimalRepository {
+	return &AnimalRepositoryImpl{db: a.db.WithContext(ctx), tenant: a.tenant, actor: a.actor}
+}
+
+// scoped starts a query limited to the records of the tenant
+func (a *AnimalRepositoryImpl) scoped() *gorm.DB {
+	return a.db.Scopes(TenantScope(a.tenant))
+}
+
 func (a *AnimalRepositoryImpl) FindAll() ([]models.Animal, error) {
 	var animals []models.Animal
-	result := a.db.Find(&animals)
+	result := a.scoped().Find(&animals)
 	if result.Error != nil {
 		return animals, result.Error
 	}
@@ -56,9 +113,41 @@ func (a *AnimalRepositoryImpl) FindAll() ([]models.Animal, error) {
 	return filteredAnimals, nil
 }
 
+func (a *AnimalRepositoryImpl) Rows() (AnimalRows, error) {
+	// same active records as FindAll, but fetched one by one
+	rows, err := a.scoped().Model(&models.Animal{}).Where("is_active = ?", true).Order("id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	return &animalRowsImpl{db: a.db, rows: rows}, nil
+}
+
+type animalRowsImpl struct {
+	db   *gorm.DB
+	rows *sql.Rows
+}
+
+func (r *animalRowsImpl) Next() bool {
+	return r.rows.Next()
+}
+
+func (r *animalRowsImpl) Scan() (models.Animal, error) {
+	var animal models.Animal
+	err := r.db.ScanRows(r.rows, &animal)
+	return animal, err
+}
+
+func (r *animalRowsImpl) Err() error {
+	return r.rows.Err()
+}
+
+func (r *animalRowsImpl) Close() error {
+	return r.rows.Close()
+}
+
 func (a *AnimalRepositoryImpl) GetCount() (int64, error) {
 	var count int64
-	result := a.db.Model(&models.Animal{}).Where("is_active = ?", true).Count(&count)
+	result := a.scoped().Model(&models.Animal{}).Where("is_active = ?", true).Count(&count)
 	if result.Error != nil {
 		return -1, result.Error
 	}
@@ -68,7 +157,7 @@ func (a *AnimalRepositoryImpl) GetCount() (int64, error) {
 func (a *AnimalRepositoryImpl) FindByID(id uint) (models.Animal, error) {
 	var animal models.Animal
 	// find first record with id
-	result := a.db.First(&animal, id)
+	result := a.scoped().First(&animal, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return animal, &NotFoundError{Id: id, When: time.Now()}
@@ -83,14 +172,65 @@ func (a *AnimalRepositoryImpl) FindByID(id uint) (models.Animal, error) {
 	return animal, nil
 }
 
+func (a *AnimalRepositoryImpl) FindByIDs(ids []uint) ([]models.Animal, error) {
+	// active records of all ids in one query, missing ones are left out
+	animals := []models.Animal{}
+	result := a.scoped().Where("id IN ? AND is_active = ?", ids, true).Order("id").Find(&animals)
+	if result.Error != nil {
+		return animals, result.Error
+	}
+	return animals, nil
+}
+
+func (a *AnimalRepositoryImpl) FindAllColumns(columns []string) ([]models.Animal, error) {
+	// only the selected columns of active records are read
+	animals := []models.Animal{}
+	result := a.scoped().Select(columns).Where("is_active = ?", true).Order("id").Find(&animals)
+	if result.Error != nil {
+		return animals, result.Error
+	}
+	return animals, nil
+}
+
+func (a *AnimalRepositoryImpl) FindPage(columns []string, offset, limit int) ([]models.Animal, error) {
+	// one page of active records in id order, all columns when nil
+	animals := []models.Animal{}
+	query := a.scoped()
+	if columns != nil {
+		query = query.Select(columns)
+	}
+	result := query.Where("is_active = ?", true).Order("id").Offset(offset).Limit(limit).Find(&animals)
+	if result.Error != nil {
+		return animals, result.Error
+	}
+	return animals, nil
+}
+
+func (a *AnimalRepositoryImpl) FindByIDColumns(id uint, columns []string) (models.Animal, error) {
+	var animal models.Animal
+	// deleted records are filtered by the query, is_active is not selected
+	result := a.scoped().Select(columns).Where("is_active = ?", true).First(&animal, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return animal, &NotFoundError{Id: id, When: time.Now()}
+		} else {
+			return animal, result.Error
+		}
+	}
+	return animal, nil
+}
+
 func (a *AnimalRepositoryImpl) Create(animalInput inputModels.Animal) (models.Animal, error) {
 	// set exactly those fields which are needed
 	var animal models.Animal
 	animal.Name = animalInput.Name
 	animal.Description = animalInput.Description
 	animal.Type = animalInput.Type
+	animal.TenantID = a.tenant
+	animal.CreatedBy = a.actor.Subject
+	animal.UpdatedBy = a.actor.Subject
 	// create in the DB
-	result := a.db.Create(&animal)
+	result := a.scoped().Create(&animal)
 	if result.Error != nil {
 		return animal, result.Error
 	}
@@ -99,7 +239,7 @@ func (a *AnimalRepositoryImpl) Create(animalInput inputModels.Animal) (models.An
 
 func (a *AnimalRepositoryImpl) Replace(id uint, animalInput inputModels.Animal) (models.Animal, error) {
 	var animal models.Animal
-	result := a.db.First(&animal, id)
+	result := a.scoped().First(&animal, id)
 	// find animal by id
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -111,12 +251,16 @@ func (a *AnimalRepositoryImpl) Replace(id uint, animalInput inputModels.Animal)
 	if !animal.IsActive {
 		return animal, &NotFoundError{Id: id, When: time.Now()}
 	}
+	if err := a.mayChange(animal); err != nil {
+		return animal, err
+	}
 	// replace needed field values
+	animal.UpdatedBy = a.actor.Subject
 	animal.Name = animalInput.Name
 	animal.Description = animalInput.Description
 	animal.Type = animalInput.Type
 	// save replaced animal
-	result = a.db.Save(&animal)
+	result = a.scoped().Save(&animal)
 	if result.Error != nil {
 		return animal, result.Error
 	}
@@ -125,7 +269,7 @@ func (a *AnimalRepositoryImpl) Replace(id uint, animalInput inputModels.Animal)
 
 func (a *AnimalRepositoryImpl) Delete(id uint) (models.Animal, error) {
 	var animal models.Animal
-	result := a.db.First(&animal, id)
+	result := a.scoped().First(&animal, id)
 	// find animal by id
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -137,10 +281,14 @@ func (a *AnimalRepositoryImpl) Delete(id uint) (models.Animal, error) {
 	if !animal.IsActive {
 		return animal, &NotFoundError{Id: id, When: time.Now()}
 	}
+	if err := a.mayChange(animal); err != nil {
+		return animal, err
+	}
 	// set him to deleted state
+	animal.UpdatedBy = a.actor.Subject
 	animal.IsActive = false
 	// apply changes
-	result = a.db.Save(&animal)
+	result = a.scoped().Save(&animal)
 	if result.Error != nil {
 		return animal, result.Error
 	}
@@ -149,7 +297,7 @@ func (a *AnimalRepositoryImpl) Delete(id uint) (models.Animal, error) {
 
 func (a *AnimalRepositoryImpl) UpdateDescription(id uint, description string) (models.Animal, error) {
 	var animal models.Animal
-	result := a.db.First(&animal, id)
+	result := a.scoped().First(&animal, id)
 	// find animal by id
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -161,12 +309,142 @@ func (a *AnimalRepositoryImpl) UpdateDescription(id uint, description string) (m
 	if !animal.IsActive {
 		return animal, &NotFoundError{Id: id, When: time.Now()}
 	}
+	if err := a.mayChange(animal); err != nil {
+		return animal, err
+	}
 	// update his description
+	animal.UpdatedBy = a.actor.Subject
 	animal.Description = description
 	// apply changes
-	result = a.db.Save(&animal)
+	result = a.scoped().Save(&animal)
 	if result.Error != nil {
 		return animal, result.Error
 	}
 	return animal, nil
 }
+
+func (a *AnimalRepositoryImpl) Import(record inputModels.ImportRecord, key string) (models.Animal, bool, error) {
+	var animal models.Animal
+	// find the record to overwrite
+	switch key {
+	case ImportKeyID:
+		result := a.scoped().First(&animal, record.ID)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return animal, false, &NotFoundError{Id: record.ID, When: time.Now()}
+			} else {
+				return animal, false, result.Error
+			}
+		}
+		if !animal.IsActive {
+			return animal, false, &NotFoundError{Id: record.ID, When: time.Now()}
+		}
+	case ImportKeyExternalID:
+		// deleted records are brought back by a repeated import
+		result := a.scoped().Where("external_id = ?", record.ExternalID).First(&animal)
+		if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return animal, false, result.Error
+		}
+	}
+	// set exactly those fields which are needed
+	created := animal.ID == 0
+	if created {
+		animal.CreatedBy = a.actor.Subject
+	} else if err := a.mayChange(animal); err != nil {
+		return animal, false, err
+	}
+	animal.TenantID = a.tenant
+	animal.UpdatedBy = a.actor.Subject
+	animal.Name = record.Animal.Name
+	animal.Description = record.Animal.Description
+	animal.Type = record.Animal.Type
+	animal.IsActive = true
+	if record.ExternalID != "" {
+		animal.ExternalID = &record.ExternalID
+	}
+	// create or update in the DB
+	result := a.scoped().Save(&animal)
+	if result.Error != nil {
+		return animal, false, result.Error
+	}
+	return animal, created, nil
+}
+
+func (a *AnimalRepositoryImpl) Transaction(fn func(rp AnimalRepository) error) error {
+	// rolled back when fn fails or panics
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&AnimalRepositoryImpl{db: tx, tenant: a.tenant, actor: a.actor})
+	})
+}
+
+func (a *AnimalRepositoryImpl) FindShares(id uint) ([]models.AnimalShare, error) {
+	shares := []models.AnimalShare{}
+	if _, err := a.FindByID(id); err != nil {
+		return shares, err
+	}
+	result := a.scoped().Where("animal_id = ?", id).Order("id").Find(&shares)
+	if result.Error != nil {
+		return shares, result.Error
+	}
+	return shares, nil
+}
+
+func (a *AnimalRepositoryImpl) Share(id uint, granteeType, grantee string) (models.AnimalShare, error) {
+	share := models.AnimalShare{TenantID: a.tenant, AnimalID: id, GranteeType: granteeType, Grantee: grantee}
+	animal, err := a.FindByID(id)
+	if err != nil {
+		return share, err
+	}
+	// shared records are not shared on by others
+	if !a.actor.Owns(animal) {
+		return share, ErrNotPermitted
+	}
+	// sharing twice keeps the first share
+	share.CreatedBy = a.actor.Subject
+	result := a.scoped().
+		Where("animal_id = ? AND grantee_type = ? AND grantee = ?", id, granteeType, grantee).
+		FirstOrCreate(&share)
+	if result.Error != nil {
+		return share, result.Error
+	}
+	return share, nil
+}
+
+func (a *AnimalRepositoryImpl) Unshare(id, shareID uint) (models.AnimalShare, error) {
+	var share models.AnimalShare
+	animal, err := a.FindByID(id)
+	if err != nil {
+		return share, err
+	}
+	if !a.actor.Owns(animal) {
+		return share, ErrNotPermitted
+	}
+	result := a.scoped().Where("animal_id = ?", id).First(&share, shareID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return share, &NotFoundError{Id: shareID, When: time.Now()}
+		}
+		return share, result.Error
+	}
+	result = a.scoped().Delete(&share)
+	if result.Error != nil {
+		return share, result.Error
+	}
+	return share, nil
+}
+
+// mayChange checks that the actor owns the record or that it is shared with the actor
+func (a *AnimalRepositoryImpl) mayChange(animal models.Animal) error {
+	if a.actor.Owns(animal) {
+		return nil
+	}
+	var shares []models.AnimalShare
+	result := a.scoped().Where("animal_id = ?", animal.ID).Find(&shares)
+	if result.Error != nil {
+		return result.Error
+	}
+	if !a.actor.SharedWith(shares) {
+		return ErrNotPermitted
+	}
+	return nil
+}
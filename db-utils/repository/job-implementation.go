@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-test/db-utils/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type JobRepository interface {
+	Create(job models.Job) (models.Job, error)
+	FindByID(id uint) (models.Job, error)
+	FindAll(filter JobFilter) ([]models.Job, error)
+	ClaimNext() (models.Job, error)
+	UpdateProgress(id uint, attempt, progress int) (bool, error)
+	SaveCheckpoint(id uint, attempt int, checkpoint string) error
+	Finish(id uint, attempt int, status, result, message string) error
+	Retry(id uint, attempt int, runAt time.Time, message string) error
+	Cancel(id uint) (models.Job, error)
+	RequeueStale(before time.Time) (int64, error)
+	ForTenant(tenant string) JobRepository
+	WithContext(ctx context.Context) JobRepository
+}
+
+// JobFilter - optional conditions of the job list, zero values match everything.
+type JobFilter struct {
+	Kind   string
+	Status string
+	Limit  int
+}
+
+// ErrNoJob - there is no queued job ready to run.
+var ErrNoJob = errors.New("no job ready to run")
+
+// ErrJobFinished - job has already reached a final state.
+var ErrJobFinished = errors.New("job is already finished")
+
+// ErrJobLost - job is no longer running the attempt of the worker, it was
+// declared stale and requeued, claimed again or finished meanwhile.
+var ErrJobLost = errors.New("job is no longer held by this attempt")
+
+// JobRepositoryImpl - jobs of all tenants, as the workers need them, or of
+// one tenant once bound by ForTenant.
+type JobRepositoryImpl struct {
+	db     *gorm.DB
+	tenant string
+	bound  bool
+}
+
+func NewJobsRepositoryImpl(DB *gorm.DB) JobRepository {
+	return &JobRepositoryImpl{db: DB}
+}
+
+func (j *JobRepositoryImpl) ForTenant(tenant string) JobRepository {
+	return &JobRepositoryImpl{db: j.db, tenant: tenant, bound: true}
+}
+
+// WithContext runs the statements in the context of a request, whose id they carry.
+func (j *JobRepositoryImpl) WithContext(ctx context.Context) JobRepository {
+	return &JobRepositoryImpl{db: j.db.WithContext(ctx), tenant: j.tenant, bound: j.bound}
+}
+
+// scoped limits the query to the jobs of the bound tenant
+func (j *JobRepositoryImpl) scoped(db *gorm.DB) *gorm.DB {
+	if !j.bound {
+		return db
+	}
+	return db.Scopes(TenantScope(j.tenant))
+}
+
+func (j *JobRepositoryImpl) Create(job models.Job) (models.Job, error) {
+	job.Status = models.JobQueued
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if j.bound {
+		job.TenantID = j.tenant
+	}
+	result := j.scoped(j.db).Create(&job)
+	if result.Error != nil {
+		return job, result.Error
+	}
+	return job, nil
+}
+
+func (j *JobRepositoryImpl) FindByID(id uint) (models.Job, error) {
+	var job models.Job
+	result := j.scoped(j.db).First(&job, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return job, &NotFoundError{Id: id, When: time.Now()}
+		} else {
+			return job, result.Error
+		}
+	}
+	return job, nil
+}
+
+func (j *JobRepositoryImpl) FindAll(filter JobFilter) ([]models.Job, error) {
+	var jobs []models.Job
+	query := j.scoped(j.db).Order("id desc")
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	result := query.Find(&jobs)
+	if result.Error != nil {
+		return jobs, result.Error
+	}
+	return jobs, nil
+}
+
+func (j *JobRepositoryImpl) ClaimNext() (models.Job, error) {
+	var job models.Job
+	err := j.db.Transaction(func(tx *gorm.DB) error {
+		// skip jobs locked by workers of other replicas
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", models.JobQueued, time.Now()).
+			Order("run_at").
+			First(&job)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return ErrNoJob
+			}
+			return result.Error
+		}
+		// mark as taken by this worker
+		now := time.Now()
+		job.Status = models.JobRunning
+		job.Attempts++
+		job.StartedAt = &now
+		job.Error = ""
+		return tx.Save(&job).Error
+	})
+	return job, err
+}
+
+// leased limits the update to the running attempt of the job, its claim
+// number serves as the lease of the worker
+func (j *JobRepositoryImpl) leased(id uint, attempt int) *gorm.DB {
+	return j.db.Model(&models.Job{}).Where("id = ? AND status = ? AND attempts = ?", id, models.JobRunning, attempt)
+}
+
+// checkLease turns updates which matched no row into ErrJobLost
+func checkLease(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobLost
+	}
+	return nil
+}
+
+func (j *JobRepositoryImpl) UpdateProgress(id uint, attempt, progress int) (bool, error) {
+	// also serves as a heartbeat of the running job
+	if err := checkLease(j.leased(id, attempt).Update("progress", progress)); err != nil {
+		return false, err
+	}
+	// report whether the job was cancelled meanwhile
+	var job models.Job
+	result := j.db.Select("cancel_requested").First(&job, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return job.CancelRequested, nil
+}
+
+func (j *JobRepositoryImpl) SaveCheckpoint(id uint, attempt int, checkpoint string) error {
+	// also serves as a heartbeat of the running job
+	return checkLease(j.leased(id, attempt).Update("checkpoint", checkpoint))
+}
+
+func (j *JobRepositoryImpl) Finish(id uint, attempt int, status, result, message string) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      status,
+		"result":      result,
+		"error":       message,
+		"finished_at": &now,
+	}
+	if status == models.JobSucceeded {
+		updates["progress"] = 100
+	}
+	return checkLease(j.leased(id, attempt).Updates(updates))
+}
+
+func (j *JobRepositoryImpl) Retry(id uint, attempt int, runAt time.Time, message string) error {
+	// put back into the queue for a later attempt
+	return checkLease(j.leased(id, attempt).Updates(map[string]interface{}{
+		"status": models.JobQueued,
+		"error":  message,
+		"run_at": runAt,
+	}))
+}
+
+func (j *JobRepositoryImpl) Cancel(id uint) (models.Job, error) {
+	var job models.Job
+	err := j.db.Transaction(func(tx *gorm.DB) error {
+		result := j.scoped(tx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, id)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return &NotFoundError{Id: id, When: time.Now()}
+			}
+			return result.Error
+		}
+		switch job.Status {
+		case models.JobQueued:
+			// never started, so cancelled right away
+			now := time.Now()
+			job.Status = models.JobCancelled
+			job.FinishedAt = &now
+		case models.JobRunning:
+			// the worker notices the flag on its next progress report
+			job.CancelRequested = true
+		default:
+			return ErrJobFinished
+		}
+		return tx.Save(&job).Error
+	})
+	return job, err
+}
+
+func (j *JobRepositoryImpl) RequeueStale(before time.Time) (int64, error) {
+	// running jobs without heartbeat belong to a crashed worker
+	stale := j.db.Model(&models.Job{}).Where("status = ? AND updated_at < ?", models.JobRunning, before).Session(&gorm.Session{})
+	// nobody is left to notice the cancellation
+	result := stale.Where("cancel_requested = ?", true).
+		Updates(map[string]interface{}{"status": models.JobCancelled, "finished_at": time.Now()})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	// the attempt of the crashed worker counts, used up jobs fail
+	result = stale.Where("cancel_requested = ? AND attempts >= max_attempts", false).
+		Updates(map[string]interface{}{"status": models.JobFailed, "error": "worker stopped responding", "finished_at": time.Now()})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	result = stale.Where("cancel_requested = ? AND attempts < max_attempts", false).
+		Updates(map[string]interface{}{"status": models.JobQueued, "run_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
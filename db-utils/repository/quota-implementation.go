@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-test/db-utils/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type QuotaRepository interface {
+	Find(subject string) (models.Quota, error)
+	Save(quota models.Quota) (models.Quota, error)
+	Delete(subject string) error
+	Consume(subject string, cost int64, day, month time.Time, dailyLimit, monthlyLimit int64) (int64, int64, bool, error)
+	Usage(subject string, day, month time.Time) (int64, int64, error)
+	WithContext(ctx context.Context) QuotaRepository
+}
+
+// QuotaRepositoryImpl - quotas and usage of all tenants, subjects name their tenant.
+type QuotaRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewQuotasRepositoryImpl(DB *gorm.DB) QuotaRepository {
+	return &QuotaRepositoryImpl{db: DB}
+}
+
+// WithContext runs the statements in the context of a request, whose id they carry.
+func (q *QuotaRepositoryImpl) WithContext(ctx context.Context) QuotaRepository {
+	return &QuotaRepositoryImpl{db: q.db.WithContext(ctx)}
+}
+
+// Find returns the quota of the subject, one without plan and overrides when none is stored.
+func (q *QuotaRepositoryImpl) Find(subject string) (models.Quota, error) {
+	quota := models.Quota{Subject: subject}
+	result := q.db.Where("subject = ?", subject).First(&quota)
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return quota, result.Error
+	}
+	return quota, nil
+}
+
+// Save stores the plan and overrides of the subject, replacing earlier ones.
+func (q *QuotaRepositoryImpl) Save(quota models.Quota) (models.Quota, error) {
+	result := q.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subject"}},
+		DoUpdates: clause.AssignmentColumns([]string{"plan", "daily_limit", "monthly_limit", "updated_by", "updated_at"}),
+	}).Create(&quota)
+	if result.Error != nil {
+		return quota, result.Error
+	}
+	return quota, nil
+}
+
+// Delete removes the plan and overrides of the subject, its usage is kept.
+func (q *QuotaRepositoryImpl) Delete(subject string) error {
+	return q.db.Where("subject = ?", subject).Delete(&models.Quota{}).Error
+}
+
+// consumeSQL locks the counters of both windows, checks that cost fits into
+// their limits and adds it to both, or to none. A limit of 0 is unlimited.
+// Counted rows are returned, or the current ones when cost does not fit.
+const consumeSQL = `WITH current AS (
+	SELECT period, count FROM quota_usages
+	WHERE subject = @subject AND ((period = 'day' AND start = @day) OR (period = 'month' AND start = @month))
+	FOR UPDATE
+), allowed AS (
+	SELECT (@daily = 0 OR COALESCE((SELECT count FROM current WHERE period = 'day'), 0) + @cost <= @daily)
+		AND (@monthly = 0 OR COALESCE((SELECT count FROM current WHERE period = 'month'), 0) + @cost <= @monthly) AS ok
+), counted AS (
+	INSERT INTO quota_usages (subject, period, start, count)
+	SELECT @subject, windows.period, windows.start, @cost
+	FROM (VALUES ('day', @day::timestamptz), ('month', @month::timestamptz)) AS windows (period, start), allowed
+	WHERE allowed.ok
+	ON CONFLICT (subject, period, start) DO UPDATE SET count = quota_usages.count + EXCLUDED.count
+	RETURNING period, count
+)
+SELECT period, count, true AS counted FROM counted
+UNION ALL
+SELECT period, count, false AS counted FROM current WHERE NOT EXISTS (SELECT 1 FROM counted)`
+
+// Consume counts cost requests in the day and month starting at the given times
+// unless they go beyond one of the limits, and returns the counts of both and
+// whether the requests were counted. Both rows are checked and upserted by one
+// statement holding their locks, so replicas add to the same counters without
+// losing requests, and refused requests are not counted.
+func (q *QuotaRepositoryImpl) Consume(subject string, cost int64, day, month time.Time, dailyLimit, monthlyLimit int64) (int64, int64, bool, error) {
+	var rows []struct {
+		Period  string
+		Count   int64
+		Counted bool
+	}
+	result := q.db.Raw(consumeSQL, map[string]interface{}{
+		"subject": subject,
+		"cost":    cost,
+		"day":     day,
+		"month":   month,
+		"daily":   dailyLimit,
+		"monthly": monthlyLimit,
+	}).Scan(&rows)
+	if result.Error != nil {
+		return 0, 0, false, result.Error
+	}
+	// no rows are returned for refused first requests of both windows
+	var daily, monthly int64
+	counted := false
+	for _, row := range rows {
+		counted = row.Counted
+		if row.Period == models.PeriodDay {
+			daily = row.Count
+		} else {
+			monthly = row.Count
+		}
+	}
+	return daily, monthly, counted, nil
+}
+
+// Usage returns the requests of the subject in the day and month starting at the given times.
+func (q *QuotaRepositoryImpl) Usage(subject string, day, month time.Time) (int64, int64, error) {
+	var usages []models.QuotaUsage
+	result := q.db.Where("subject = ? AND ((period = ? AND start = ?) OR (period = ? AND start = ?))",
+		subject, models.PeriodDay, day, models.PeriodMonth, month).Find(&usages)
+	if result.Error != nil {
+		return 0, 0, result.Error
+	}
+	var daily, monthly int64
+	for _, usage := range usages {
+		if usage.Period == models.PeriodDay {
+			daily = usage.Count
+		} else {
+			monthly = usage.Count
+		}
+	}
+	return daily, monthly, nil
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoTenant - repository is used without being bound to a tenant.
+var ErrNoTenant = errors.New("repository is not bound to a tenant")
+
+// TenantScope limits a query to the rows of the tenant. Without tenant the
+// query fails before it reaches the database.
+func TenantScope(tenant string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if tenant == "" {
+			db.AddError(ErrNoTenant)
+			return db
+		}
+		// qualified, so that joins cannot make it ambiguous
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: tenant})
+	}
+}
@@ -0,0 +1,101 @@
+package dbutils
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"go-test/requestid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RequestComments - gorm plugin starting the statements of a request with a
+// /* request_id=... */ comment, shown by pg_stat_activity and the Postgres
+// logs. Statements run with the context of the request, see the WithContext
+// methods of the repositories.
+type RequestComments struct{}
+
+func (RequestComments) Name() string {
+	return "request_comments"
+}
+
+func (RequestComments) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("request_comments:create", requestComment("INSERT")),
+		callbacks.Query().Before("gorm:query").Register("request_comments:query", requestComment("SELECT")),
+		callbacks.Update().Before("gorm:update").Register("request_comments:update", requestComment("UPDATE")),
+		callbacks.Delete().Before("gorm:delete").Register("request_comments:delete", requestComment("DELETE")),
+		callbacks.Row().Before("gorm:row").Register("request_comments:row", requestComment("SELECT")),
+		callbacks.Raw().Before("gorm:raw").Register("request_comments:raw", requestComment("")),
+	)
+}
+
+// requestComment puts the comment before the first clause of the statement,
+// which gorm builds later, or before the SQL of raw statements
+func requestComment(first string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		id := requestid.FromContext(db.Statement.Context)
+		if id == "" || db.Error != nil {
+			return
+		}
+		// ids never contain */, see requestid.Valid
+		comment := "/* request_id=" + id + " */"
+		if db.Statement.SQL.Len() > 0 {
+			sql := db.Statement.SQL.String()
+			db.Statement.SQL.Reset()
+			db.Statement.SQL.WriteString(comment + " " + sql)
+			return
+		}
+		if first == "" {
+			return
+		}
+		c := db.Statement.Clauses[first]
+		c.BeforeExpression = clause.Expr{SQL: comment}
+		db.Statement.Clauses[first] = c
+	}
+}
+
+// RequestLogger - Redis hook logging commands with the request id of their
+// context. Redis keeps no metadata per command, and pooled connections are
+// shared by requests, so the id cannot be sent along. The logs are where
+// requests and calls meet instead: failed commands are always logged, all
+// others only with Commands, the debug switch REDIS_LOG_COMMANDS.
+type RequestLogger struct {
+	Commands bool
+}
+
+func (RequestLogger) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (l RequestLogger) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		l.log(ctx, err, cmd)
+		return err
+	}
+}
+
+func (l RequestLogger) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			l.log(ctx, cmd.Err(), cmd)
+		}
+		return err
+	}
+}
+
+// log logs errors of commands, missing keys are no failure, and the name and
+// key of the others when Commands is set. Values are left out, they may be
+// large or personal.
+func (l RequestLogger) log(ctx context.Context, err error, cmd redis.Cmder) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		requestid.Printf(ctx, "redis: %s: %v", cmd.Name(), err)
+		return
+	}
+	if l.Commands {
+		requestid.Printf(ctx, "redis: %s %v", cmd.Name(), cmd.Args()[1:min(len(cmd.Args()), 2)])
+	}
+}
@@ -0,0 +1,18 @@
+package gql
+
+import (
+	"github.com/graphql-go/graphql"
+	"go-test/auth"
+	"go-test/problems"
+)
+
+// require wraps the resolver of a field which needs the permission, every
+// field is allowed without policy
+func (r *Resolver) require(permission string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if principal, ok := auth.FromContext(p.Context); ok && r.Policy != nil && !r.Policy.Allows(principal, permission) {
+			return nil, resolverError(p.Context, problems.Forbidden("Permission "+permission+" is required"))
+		}
+		return resolve(p)
+	}
+}
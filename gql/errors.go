@@ -0,0 +1,56 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"go-test/problems"
+	"go-test/requestid"
+	"go-test/validation"
+	"net/http"
+)
+
+// key of the Accept-Language header in the resolver context
+type languageKey struct{}
+
+// problemError reports a problem as GraphQL error with its type and status as extensions.
+type problemError struct {
+	problem problems.Problem
+}
+
+func (e *problemError) Error() string {
+	if e.problem.Detail != "" {
+		return e.problem.Detail
+	}
+	return e.problem.Title
+}
+
+func (e *problemError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"type":   e.problem.Type,
+		"status": e.problem.Status,
+	}
+	if len(e.problem.Errors) > 0 {
+		extensions["errors"] = e.problem.Errors
+	}
+	if e.problem.RequestID != "" {
+		extensions["request_id"] = e.problem.RequestID
+	}
+	return extensions
+}
+
+// resolverError maps errors of resolvers the same way the REST error middleware does
+func resolverError(ctx context.Context, err error) error {
+	problem := problems.From(err).Problem
+	problem.RequestID = requestid.FromContext(ctx)
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		language, _ := ctx.Value(languageKey{}).(string)
+		problem.Errors, _ = validation.FieldErrors(validationErrors, language)
+	}
+	if problem.Status >= http.StatusInternalServerError {
+		// the cause is never sent to the client
+		requestid.Printf(ctx, "graphql: %v", err)
+	}
+	return &problemError{problem: problem}
+}
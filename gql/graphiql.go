@@ -0,0 +1,8 @@
+package gql
+
+import _ "embed"
+
+// GraphiQL - in-browser IDE posting queries to the page URL.
+//
+//go:embed graphiql.html
+var GraphiQL []byte
@@ -0,0 +1,90 @@
+package gql
+
+import (
+	"fmt"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"strings"
+)
+
+// Limits - bounds of accepted queries, zero disables a bound.
+type Limits struct {
+	// nesting of selection sets
+	MaxDepth int
+	// selected fields, every alias counts
+	MaxComplexity int
+}
+
+// checkLimits measures the operations of the query with fragments expanded,
+// introspection fields are not counted so that GraphiQL keeps working.
+func checkLimits(query string, limits Limits) error {
+	if limits.MaxDepth == 0 && limits.MaxComplexity == 0 {
+		return nil
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		// reported by the executor with its location
+		return nil
+	}
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		m := measure{fragments: fragments, visiting: map[string]bool{}}
+		depth := m.selectionSet(operation.SelectionSet, 1)
+		if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, limits.MaxDepth)
+		}
+		if limits.MaxComplexity > 0 && m.complexity > limits.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", m.complexity, limits.MaxComplexity)
+		}
+	}
+	return nil
+}
+
+type measure struct {
+	fragments  map[string]*ast.FragmentDefinition
+	visiting   map[string]bool
+	complexity int
+}
+
+// selectionSet counts the fields of the set and returns its depth
+func (m *measure) selectionSet(set *ast.SelectionSet, depth int) int {
+	if set == nil {
+		return depth - 1
+	}
+	deepest := depth
+	for _, selection := range set.Selections {
+		d := depth
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			m.complexity++
+			d = m.selectionSet(s.SelectionSet, depth+1)
+		case *ast.InlineFragment:
+			d = m.selectionSet(s.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			fragment, ok := m.fragments[s.Name.Value]
+			// unknown and cyclic fragments are rejected by validation
+			if !ok || m.visiting[s.Name.Value] {
+				continue
+			}
+			m.visiting[s.Name.Value] = true
+			d = m.selectionSet(fragment.SelectionSet, depth)
+			m.visiting[s.Name.Value] = false
+		}
+		if d > deepest {
+			deepest = d
+		}
+	}
+	return deepest
+}
@@ -0,0 +1,81 @@
+package gql
+
+import (
+	"go-test/db-utils/repository"
+	"go-test/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// key of the per-request loader in the resolver context
+type loaderKey struct{}
+
+// AnimalLoader - collects the animal ids requested by one query and fetches
+// them with a single cache and database round trip once the first is needed.
+type AnimalLoader struct {
+	fetch   func(ids []uint) (map[uint]models.AnimalWithID, error)
+	mu      sync.Mutex
+	pending []uint
+	loaded  map[uint]models.AnimalWithID
+	errs    map[uint]error
+}
+
+func NewAnimalLoader(fetch func(ids []uint) (map[uint]models.AnimalWithID, error)) *AnimalLoader {
+	return &AnimalLoader{
+		fetch:  fetch,
+		loaded: map[uint]models.AnimalWithID{},
+		errs:   map[uint]error{},
+	}
+}
+
+// Load queues the id and returns a thunk resolved by the executor after the
+// other fields of the same level have been queued.
+func (l *AnimalLoader) Load(id uint) func() (interface{}, error) {
+	l.mu.Lock()
+	l.pending = append(l.pending, id)
+	l.mu.Unlock()
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.dispatch()
+		if err, ok := l.errs[id]; ok {
+			return nil, err
+		}
+		animal, ok := l.loaded[id]
+		if !ok {
+			return nil, &repository.NotFoundError{Id: id, When: time.Now()}
+		}
+		return animal, nil
+	}
+}
+
+// dispatch fetches the queued ids which were not loaded yet
+func (l *AnimalLoader) dispatch() {
+	var ids []uint
+	seen := map[uint]bool{}
+	for _, id := range l.pending {
+		_, loaded := l.loaded[id]
+		_, failed := l.errs[id]
+		if !loaded && !failed && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	l.pending = nil
+	if len(ids) == 0 {
+		return
+	}
+	// fields are queued in no particular order
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	animals, err := l.fetch(ids)
+	for _, id := range ids {
+		if err != nil {
+			l.errs[id] = err
+		} else if animal, ok := animals[id]; ok {
+			l.loaded[id] = animal
+		}
+	}
+}
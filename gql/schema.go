@@ -0,0 +1,313 @@
+// Package gql serves the animal repository over GraphQL, with the cache,
+// validation and error reporting of the REST handlers.
+package gql
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"go-test/animals"
+	"go-test/auth"
+	"go-test/db-utils/repository"
+	"go-test/models"
+	"go-test/problems"
+	"sort"
+)
+
+// Resolver - dependencies of the resolvers, the same the REST handlers use.
+type Resolver struct {
+	Store *animals.Store
+	// permissions of mutations, not checked when nil
+	Policy *auth.Policy
+}
+
+// Request - GraphQL request of a POST body.
+type Request struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+var animalTypeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "AnimalType",
+	Fields: graphql.Fields{
+		"code": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var animalType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Animal",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.AnimalWithID).ID, nil
+			},
+		},
+		"name": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.AnimalWithID).Animal.Name, nil
+			},
+		},
+		"description": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.AnimalWithID).Animal.Description, nil
+			},
+		},
+		"type": &graphql.Field{
+			Type: animalTypeType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				code := p.Source.(models.AnimalWithID).Animal.Type
+				name, ok := models.AnimalTypes[code]
+				if !ok {
+					return nil, nil
+				}
+				return models.AnimalType{Code: code, Name: name}, nil
+			},
+		},
+	},
+})
+
+var animalInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "AnimalInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"type":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: ""},
+	},
+})
+
+var idArgument = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}
+
+// NewSchema creates the schema of queries and mutations mirroring the REST routes.
+func NewSchema(r *Resolver) (graphql.Schema, error) {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"animal": &graphql.Field{
+				Type:    animalType,
+				Args:    graphql.FieldConfigArgument{"id": idArgument},
+				Resolve: r.animal,
+			},
+			"animals": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(animalType))),
+				Description: "Active animals, all of them when ids are not given.",
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.Int))},
+				},
+				Resolve: r.animals,
+			},
+			"animalCount": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Int),
+				Resolve: r.animalCount,
+			},
+			"animalTypes": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(animalTypeType))),
+				Resolve: animalTypes,
+			},
+		},
+	})
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createAnimal": &graphql.Field{
+				Type:    animalType,
+				Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(animalInputType)}},
+				Resolve: r.require(auth.PermCreate, r.createAnimal),
+			},
+			"replaceAnimal": &graphql.Field{
+				Type: animalType,
+				Args: graphql.FieldConfigArgument{
+					"id":    idArgument,
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(animalInputType)},
+				},
+				Resolve: r.require(auth.PermReplace, r.replaceAnimal),
+			},
+			"deleteAnimal": &graphql.Field{
+				Type:    animalType,
+				Args:    graphql.FieldConfigArgument{"id": idArgument},
+				Resolve: r.require(auth.PermDelete, r.deleteAnimal),
+			},
+			"updateAnimalDescription": &graphql.Field{
+				Type: animalType,
+				Args: graphql.FieldConfigArgument{
+					"id":          idArgument,
+					"description": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.require(auth.PermReplace, r.updateAnimalDescription),
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// Execute runs the request after checking its limits, animals requested by
+// id within the request are fetched together.
+func Execute(ctx context.Context, schema graphql.Schema, r *Resolver, request Request, limits Limits, acceptLanguage string) *graphql.Result {
+	if err := checkLimits(request.Query, limits); err != nil {
+		e := &problemError{problem: problems.BadRequest(err.Error()).Problem}
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{{Message: e.Error(), Extensions: e.Extensions()}}}
+	}
+	ctx = context.WithValue(ctx, loaderKey{}, NewAnimalLoader(func(ids []uint) (map[uint]models.AnimalWithID, error) {
+		return r.Store.GetMany(ctx, ids)
+	}))
+	ctx = context.WithValue(ctx, languageKey{}, acceptLanguage)
+	return graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  request.Query,
+		OperationName:  request.OperationName,
+		VariableValues: request.Variables,
+		Context:        ctx,
+	})
+}
+
+func (r *Resolver) animal(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	load := p.Context.Value(loaderKey{}).(*AnimalLoader).Load(id)
+	return func() (interface{}, error) {
+		animal, err := load()
+		if err != nil {
+			return nil, resolverError(p.Context, err)
+		}
+		return animal, nil
+	}, nil
+}
+
+func (r *Resolver) animals(p graphql.ResolveParams) (interface{}, error) {
+	raw, ok := p.Args["ids"].([]interface{})
+	if !ok {
+		list, err := r.Store.List(p.Context)
+		if err != nil {
+			return nil, resolverError(p.Context, err)
+		}
+		if list == nil {
+			list = []models.AnimalWithID{}
+		}
+		return list, nil
+	}
+	// missing ids are left out, as in FindByIDs
+	loader := p.Context.Value(loaderKey{}).(*AnimalLoader)
+	loads := make([]func() (interface{}, error), 0, len(raw))
+	for _, id := range raw {
+		loads = append(loads, loader.Load(uint(id.(int))))
+	}
+	return func() (interface{}, error) {
+		list := make([]models.AnimalWithID, 0, len(loads))
+		for _, load := range loads {
+			animal, err := load()
+			if err == nil {
+				list = append(list, animal.(models.AnimalWithID))
+				continue
+			}
+			var notFound *repository.NotFoundError
+			if !errors.As(err, &notFound) {
+				return nil, resolverError(p.Context, err)
+			}
+		}
+		return list, nil
+	}, nil
+}
+
+func (r *Resolver) animalCount(p graphql.ResolveParams) (interface{}, error) {
+	count, err := r.Store.Count(p.Context)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	return int(count), nil
+}
+
+func animalTypes(p graphql.ResolveParams) (interface{}, error) {
+	types := make([]models.AnimalType, 0, len(models.AnimalTypes))
+	for code, name := range models.AnimalTypes {
+		types = append(types, models.AnimalType{Code: code, Name: name})
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Code < types[j].Code
+	})
+	return types, nil
+}
+
+func (r *Resolver) createAnimal(p graphql.ResolveParams) (interface{}, error) {
+	input, err := animalInput(p)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	animal, err := r.Store.Create(p.Context, input)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	return animal, nil
+}
+
+func (r *Resolver) replaceAnimal(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	input, err := animalInput(p)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	animal, err := r.Store.Replace(p.Context, id, input)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	return animal, nil
+}
+
+func (r *Resolver) deleteAnimal(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	animal, err := r.Store.Delete(p.Context, id)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	return animal, nil
+}
+
+func (r *Resolver) updateAnimalDescription(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	input := models.AnimalDescription{Description: p.Args["description"].(string)}
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	animal, err := r.Store.UpdateDescription(p.Context, id, input.Description)
+	if err != nil {
+		return nil, resolverError(p.Context, err)
+	}
+	return animal, nil
+}
+
+func idArg(p graphql.ResolveParams) (uint, error) {
+	id, _ := p.Args["id"].(int)
+	if id < 0 {
+		return 0, problems.BadRequest("ID must not be negative")
+	}
+	return uint(id), nil
+}
+
+// animalInput decodes and validates the input argument like the REST bodies
+func animalInput(p graphql.ResolveParams) (models.Animal, error) {
+	raw := p.Args["input"].(map[string]interface{})
+	input := models.Animal{}
+	input.Name, _ = raw["name"].(string)
+	input.Type, _ = raw["type"].(int)
+	input.Description, _ = raw["description"].(string)
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		return input, err
+	}
+	return input, nil
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/db-utils/repository"
+)
+
+// checkpoint - state of a running job which outlives its attempt, so that
+// retried and requeued jobs continue where the previous attempt stopped
+type checkpoint struct {
+	repository repository.JobRepository
+	id         uint
+	attempt    int
+	saved      []byte
+}
+
+// key of the checkpoint in job contexts
+type checkpointKey struct{}
+
+// Checkpoint returns the state saved by a previous attempt of the job of ctx,
+// nil on the first attempt and outside of jobs.
+func Checkpoint(ctx context.Context) []byte {
+	if cp, ok := ctx.Value(checkpointKey{}).(*checkpoint); ok {
+		return cp.saved
+	}
+	return nil
+}
+
+// SaveCheckpoint stores the state of the job of ctx, later attempts get it by
+// Checkpoint. Does nothing outside of jobs.
+func SaveCheckpoint(ctx context.Context, state interface{}) error {
+	cp, ok := ctx.Value(checkpointKey{}).(*checkpoint)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := cp.repository.SaveCheckpoint(cp.id, cp.attempt, string(data)); err != nil {
+		return err
+	}
+	cp.saved = data
+	return nil
+}
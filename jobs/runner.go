@@ -0,0 +1,306 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/requestid"
+	"go-test/tenants"
+	"log"
+	"sync"
+	"time"
+)
+
+// Handler - executes one job kind, progress reports done and total units of work.
+// Jobs may run more than once, handlers which must not repeat work save their
+// state by SaveCheckpoint and resume from Checkpoint.
+type Handler func(ctx context.Context, payload []byte, progress func(done, total int)) (interface{}, error)
+
+// RetryPolicy - how many times a failed job is attempted and how long to wait in between.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration // doubled after every failed attempt
+}
+
+// ErrUnknownKind - no handler is registered for the job kind.
+var ErrUnknownKind = errors.New("unknown job kind")
+
+// permanentError - failure which is not worth retrying.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks a handler error, so that the job fails without further attempts.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+type Runner struct {
+	repository repository.JobRepository
+	handlers   map[string]Handler
+	workers    int
+	poll       time.Duration
+	retry      RetryPolicy
+	wake       chan struct{}
+
+	mu      sync.Mutex
+	cancels map[uint]context.CancelFunc
+}
+
+func NewRunner(rp repository.JobRepository, workers int, poll time.Duration, retry RetryPolicy) *Runner {
+	// sane defaults for missing configuration
+	if workers <= 0 {
+		workers = 1
+	}
+	if poll <= 0 {
+		poll = 5 * time.Second
+	}
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 1
+	}
+	return &Runner{
+		repository: rp,
+		handlers:   map[string]Handler{},
+		workers:    workers,
+		poll:       poll,
+		retry:      retry,
+		wake:       make(chan struct{}, 1),
+		cancels:    map[uint]context.CancelFunc{},
+	}
+}
+
+// Register sets the handler of a job kind, must be called before Run.
+func (r *Runner) Register(kind string, handler Handler) {
+	r.handlers[kind] = handler
+}
+
+// Enqueue stores a new job of the tenant of ctx, it is picked up by the first
+// free worker and handled in the context of the same tenant and request id.
+func (r *Runner) Enqueue(ctx context.Context, kind string, payload interface{}) (models.Job, error) {
+	if _, ok := r.handlers[kind]; !ok {
+		return models.Job{}, ErrUnknownKind
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.Job{}, err
+	}
+	job, err := r.repository.ForTenant(tenants.FromContext(ctx)).WithContext(ctx).Create(models.Job{
+		Kind:        kind,
+		Payload:     string(data),
+		MaxAttempts: r.retry.MaxAttempts,
+		RequestID:   requestid.FromContext(ctx),
+	})
+	if err != nil {
+		return job, err
+	}
+	// do not wait for the next poll
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Cancel stops a queued job of the tenant of ctx right away and asks a running one to stop.
+func (r *Runner) Cancel(ctx context.Context, id uint) (models.Job, error) {
+	job, err := r.repository.ForTenant(tenants.FromContext(ctx)).WithContext(ctx).Cancel(id)
+	if err != nil {
+		return job, err
+	}
+	// running on this replica, others notice the flag on progress report
+	r.mu.Lock()
+	cancel, ok := r.cancels[id]
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return job, nil
+}
+
+// Run starts the worker pool and blocks until ctx is done.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < r.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+	r.requeueStale(ctx)
+	wg.Wait()
+}
+
+// requeueStale periodically returns jobs of crashed workers to the queue
+func (r *Runner) requeueStale(ctx context.Context) {
+	ticker := time.NewTicker(r.poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// running jobs send heartbeat at least every poll interval
+			n, err := r.repository.RequeueStale(time.Now().Add(-10 * r.poll))
+			if err != nil {
+				log.Printf("Failed to requeue stale jobs: %v", err)
+			} else if n > 0 {
+				log.Printf("Requeued %d stale jobs", n)
+			}
+		}
+	}
+}
+
+func (r *Runner) work(ctx context.Context) {
+	ticker := time.NewTicker(r.poll)
+	defer ticker.Stop()
+
+	for {
+		// drain the queue before sleeping
+		for ctx.Err() == nil {
+			job, err := r.repository.ClaimNext()
+			if errors.Is(err, repository.ErrNoJob) {
+				break
+			}
+			if err != nil {
+				log.Printf("Failed to claim job: %v", err)
+				break
+			}
+			// log lines and statements of the job carry the id of its request
+			r.execute(requestid.WithID(ctx, job.RequestID), job)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) execute(ctx context.Context, job models.Job) {
+	jobCtx, cancel := context.WithCancel(tenants.WithTenant(ctx, job.TenantID))
+	var saved []byte
+	if job.Checkpoint != "" {
+		saved = []byte(job.Checkpoint)
+	}
+	jobCtx = context.WithValue(jobCtx, checkpointKey{}, &checkpoint{repository: r.repository, id: job.ID, attempt: job.Attempts, saved: saved})
+	defer cancel()
+	r.mu.Lock()
+	r.cancels[job.ID] = cancel
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.cancels, job.ID)
+		r.mu.Unlock()
+	}()
+
+	// keep the heartbeat while the handler does not report progress
+	progress := 0
+	var progressMu sync.Mutex
+	report := func() {
+		progressMu.Lock()
+		current := progress
+		progressMu.Unlock()
+		cancelled, err := r.repository.UpdateProgress(job.ID, job.Attempts, current)
+		if errors.Is(err, repository.ErrJobLost) {
+			// declared stale and given to another attempt, stop repeating its work
+			requestid.Printf(ctx, "Job %d is no longer held by attempt %d", job.ID, job.Attempts)
+			cancel()
+			return
+		}
+		if err != nil {
+			requestid.Printf(ctx, "Failed to update progress of job %d: %v", job.ID, err)
+			return
+		}
+		if cancelled {
+			cancel()
+		}
+	}
+	heartbeatDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(r.poll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatDone:
+				return
+			case <-ticker.C:
+				report()
+			}
+		}
+	}()
+
+	result, err := r.call(jobCtx, job, func(done, total int) {
+		if total <= 0 {
+			return
+		}
+		progressMu.Lock()
+		changed := progress != done*100/total
+		progress = done * 100 / total
+		progressMu.Unlock()
+		if changed {
+			report()
+		}
+	})
+	close(heartbeatDone)
+
+	r.finish(ctx, jobCtx, job, result, err)
+}
+
+// call runs the handler, recovering from its panics
+func (r *Runner) call(ctx context.Context, job models.Job, progress func(done, total int)) (result interface{}, err error) {
+	handler, ok := r.handlers[job.Kind]
+	if !ok {
+		return nil, Permanent(ErrUnknownKind)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return handler(ctx, []byte(job.Payload), progress)
+}
+
+func (r *Runner) finish(ctx, jobCtx context.Context, job models.Job, result interface{}, err error) {
+	var saveErr error
+	switch {
+	case ctx.Err() != nil:
+		// shutting down, let the job run again later
+		saveErr = r.repository.Retry(job.ID, job.Attempts, time.Now(), "interrupted by shutdown")
+	case jobCtx.Err() != nil:
+		saveErr = r.repository.Finish(job.ID, job.Attempts, models.JobCancelled, "", "")
+	case err != nil:
+		var permanent *permanentError
+		if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+			requestid.Printf(ctx, "Job %d (%s) failed: %v", job.ID, job.Kind, err)
+			saveErr = r.repository.Finish(job.ID, job.Attempts, models.JobFailed, "", err.Error())
+		} else {
+			// exponential backoff between attempts
+			delay := r.retry.Backoff << (job.Attempts - 1)
+			saveErr = r.repository.Retry(job.ID, job.Attempts, time.Now().Add(delay), err.Error())
+		}
+	default:
+		data, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			saveErr = r.repository.Finish(job.ID, job.Attempts, models.JobFailed, "", marshalErr.Error())
+		} else {
+			saveErr = r.repository.Finish(job.ID, job.Attempts, models.JobSucceeded, string(data), "")
+		}
+	}
+	if saveErr != nil {
+		requestid.Printf(ctx, "Failed to save state of job %d: %v", job.ID, saveErr)
+	}
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
-	ginratelimit "github.com/ljahier/gin-ratelimit"
 	"go-test/middleware"
-	"go-test/routers"
+	"go-test/rpc"
 	"go-test/service"
 	"go-test/utils"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -19,41 +25,81 @@ func main() {
 	service := service.NewService(&_cfg)
 
 	// get an engine instance
-	r := gin.Default()
+	r := gin.New()
+	// request ids first, so that every log line can name them
+	r.Use(middleware.RequestID(), gin.LoggerWithFormatter(middleware.AccessLog), gin.Recovery())
 	r.ForwardedByClientIP = true
 	r.SetTrustedProxies([]string{"127.0.0.1"})
 
 	// middleware
-	r.Use(middleware.CORSMiddleware())                                       // preflight requests
-	tb := ginratelimit.NewTokenBucket(_cfg.RequestsPerMinute, 1*time.Minute) // rate limiting
-	r.Use(ginratelimit.RateLimitByIP(tb))
+	r.Use(middleware.ErrorMiddleware()) // problem details for c.Errors
+	cors := middleware.CORSPolicy{
+		AllowedOrigins:   _cfg.CORSAllowedOrigins,
+		AllowedMethods:   _cfg.CORSAllowedMethods,
+		AllowedHeaders:   _cfg.CORSAllowedHeaders,
+		ExposedHeaders:   _cfg.CORSExposedHeaders,
+		AllowCredentials: _cfg.CORSAllowCredentials,
+		MaxAge:           time.Duration(_cfg.CORSMaxAge) * time.Second,
+	}
+	if err := cors.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	r.Use(middleware.CORSMiddleware(cors)) // preflight requests
+	// rate limits are applied by the routes, once their client is known
 
 	// connect routers
 	// middleware for connect and trace handlers
+	trace := service.Trace(r)
 	r.Use(func(c *gin.Context) {
 		if c.Request.Method == "CONNECT" {
-			routers.ConnectHandler(c)
-		} else if c.Request.Method == "TRACE" && c.Request.URL.Path == "/animals" {
-			routers.TraceAnimalRoute(c)
+			service.Connect(c)
+		} else if c.Request.Method == "TRACE" {
+			trace(c)
 		} else {
 			c.Next()
 		}
 	})
-	r.OPTIONS("/*path", routers.OptionsHandler) // all URLs
-
-	r.GET("/animals", service.GetAnimal)
-	r.HEAD("/animals", service.GetAnimalCount)
-	r.GET("/animals/:id", service.GetAnimal)
-	r.POST("/animals", service.CreateAnimal)
-	r.PUT("/animals/:id", service.ReplaceAnimal)
-	r.DELETE("/animals/:id", service.DeleteAnimal)
-	r.PATCH("/animals/:id/description", service.UpdateAnimalDescription) // change only description field
+	r.NoRoute(middleware.NotFoundHandler)
+	service.RegisterRoutes(r)
 
 	// setup database health checking loop every 10 seconds
 	go utils.DataBaseHealthPollingLoop(service.PostgresClient, time.Duration(_cfg.DBHeathInterval)*time.Second)
-	// run the server
-	err := r.Run(":3000")
-	if err != nil {
+	// serve gRPC on its own port
+	go func() {
+		lis, err := net.Listen("tcp", _cfg.GRPCAddress)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// calls count against the same budgets and quotas as requests
+		metering := rpc.Metering{RateLimiter: service.RateLimiter, RateLimits: service.RateLimits, Quotas: service.Quotas}
+		if err := rpc.NewServer(service.Animals, service.Verifier, service.APIKeys, service.Policy, metering).Serve(lis); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	// stop on SIGTERM, interrupted jobs are requeued by the runner
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// process background jobs
+	jobsDone := make(chan struct{})
+	go func() {
+		service.Jobs.Run(ctx)
+		close(jobsDone)
+	}()
+	// run the server until the signal, then let running requests finish
+	server := &http.Server{Addr: ":3000", Handler: r}
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Failed to shut down the server: %v", err)
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal(err)
 	}
+	<-serverDone
+	<-jobsDone
 }
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-test/auth"
+	"go-test/problems"
+	"strings"
+)
+
+// Authenticate rejects requests without a valid API key or bearer token and
+// stores the principal of the credential for handlers. Either of verifier and
+// keys may be nil, its credential is not accepted then.
+func Authenticate(verifier *auth.Verifier, keys *auth.APIKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" && keys != nil {
+			principal, err := keys.Verify(c.Request.Context(), key)
+			if err != nil {
+				if errors.Is(err, auth.ErrInvalidAPIKey) {
+					err = problems.Unauthorized("API key is invalid, revoked or expired")
+				}
+				// lookup failures are reported by the error middleware
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			auth.Set(c, principal)
+			c.Next()
+			return
+		}
+
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok || verifier == nil {
+			if verifier != nil {
+				c.Header("WWW-Authenticate", "Bearer")
+			}
+			c.Error(problems.Unauthorized("Request needs " + credentials(verifier, keys)))
+			c.Abort()
+			return
+		}
+		principal, err := verifier.Verify(c.Request.Context(), token)
+		if err != nil {
+			// the cause is never sent to the client
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			e := problems.Unauthorized("Bearer token is invalid or expired")
+			e.Err = err
+			c.Error(e)
+			c.Abort()
+			return
+		}
+		auth.Set(c, principal)
+		c.Next()
+	}
+}
+
+// Require rejects principals without every one of the permissions in the
+// policy. Anonymous requests, when authentication is off, are not limited.
+func Require(policy *auth.Policy, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := auth.Current(c); ok {
+			for _, permission := range permissions {
+				if !policy.Allows(principal, permission) {
+					c.Error(problems.Forbidden("Permission " + permission + " is required"))
+					c.Abort()
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// credentials names the accepted credentials in problem details
+func credentials(verifier *auth.Verifier, keys *auth.APIKeys) string {
+	switch {
+	case verifier != nil && keys != nil:
+		return "an X-API-Key header or a bearer token"
+	case keys != nil:
+		return "an X-API-Key header"
+	}
+	return "a bearer token"
+}
+
+// bearerToken reads the token of an "Authorization: Bearer <token>" header
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
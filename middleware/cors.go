@@ -1,15 +1,133 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-test/problems"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func CORSMiddleware() gin.HandlerFunc {
+// CORSPolicy - cross-origin requests browsers may send to the API.
+type CORSPolicy struct {
+	// exact origins or patterns like https://*.example.com, * allows every origin
+	AllowedOrigins []string
+	AllowedMethods []string
+	// request headers of preflight requests, matched case-insensitively
+	AllowedHeaders []string
+	// response headers readable by scripts
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// how long browsers may cache preflight answers
+	MaxAge time.Duration
+}
+
+// Validate rejects policies browsers refuse or which expose credentials to every site.
+func (p CORSPolicy) Validate() error {
+	if p.AllowCredentials && slices.Contains(p.AllowedOrigins, "*") {
+		return errors.New("cors: credentials cannot be allowed for every origin")
+	}
+	for _, origin := range p.AllowedOrigins {
+		if strings.Count(origin, "*") > 1 || (origin != "*" && strings.Contains(origin, "*") && !strings.Contains(origin, "://*.")) {
+			return errors.New("cors: invalid origin pattern " + origin)
+		}
+	}
+	return nil
+}
+
+// allowsOrigin reports whether the origin matches one of the allowed origins
+func (p CORSPolicy) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		// one or more subdomain labels in place of the wildcard
+		prefix, suffix, ok := strings.Cut(allowed, "*")
+		if !ok || len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+		if labels := origin[len(prefix) : len(origin)-len(suffix)]; !strings.ContainsAny(labels, "/:@") {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders reports whether every header of the comma separated list is allowed
+func (p CORSPolicy) allowsHeaders(headers string) bool {
+	if slices.Contains(p.AllowedHeaders, "*") {
+		return true
+	}
+	for _, header := range strings.Split(headers, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !slices.ContainsFunc(p.AllowedHeaders, func(allowed string) bool {
+			return strings.EqualFold(allowed, header)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// CORSMiddleware answers preflight requests of allowed origins and marks the
+// responses of their actual requests readable. Requests of other origins are
+// served without CORS headers, so that browsers keep their responses from scripts.
+func CORSMiddleware(policy CORSPolicy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH, CONNECT, TRACE")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Via")
-		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
+		// responses differ by origin, caches must not mix them
+		c.Writer.Header().Add("Vary", "Origin")
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		allowed := policy.allowsOrigin(origin)
+
+		requestedMethod := c.GetHeader("Access-Control-Request-Method")
+		if c.Request.Method == http.MethodOptions && requestedMethod != "" {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			requestedHeaders := c.GetHeader("Access-Control-Request-Headers")
+			if !allowed || !slices.Contains(policy.AllowedMethods, requestedMethod) || !policy.allowsHeaders(requestedHeaders) {
+				c.Error(problems.Forbidden("Cross-origin request is not allowed"))
+				c.Abort()
+				return
+			}
+			setAllowOrigin(c, policy, origin)
+			c.Header("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+			if requestedHeaders != "" {
+				c.Header("Access-Control-Allow-Headers", requestedHeaders)
+			}
+			if policy.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
 
+		if allowed {
+			setAllowOrigin(c, policy, origin)
+			if len(policy.ExposedHeaders) > 0 {
+				c.Header("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+			}
+		}
 		c.Next()
 	}
 }
+
+// setAllowOrigin echoes the matched origin, or * for every origin without credentials
+func setAllowOrigin(c *gin.Context, policy CORSPolicy, origin string) {
+	if slices.Contains(policy.AllowedOrigins, "*") {
+		c.Header("Access-Control-Allow-Origin", "*")
+		return
+	}
+	c.Header("Access-Control-Allow-Origin", origin)
+	if policy.AllowCredentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+}
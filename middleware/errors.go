@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go-test/problems"
+	"go-test/requestid"
+	"go-test/validation"
+	"net/http"
+)
+
+// ErrorMiddleware renders the last error of c.Errors as problem details,
+// unless the handler has already written a response.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		problem := problems.From(c.Errors.Last().Err).Problem
+		problem.Instance = c.Request.URL.RequestURI()
+		problem.RequestID = requestid.FromContext(c.Request.Context())
+		// field messages in the language of the client
+		var validationErrors validator.ValidationErrors
+		if errors.As(c.Errors.Last().Err, &validationErrors) {
+			var locale string
+			problem.Errors, locale = validation.FieldErrors(validationErrors, c.GetHeader("Accept-Language"))
+			c.Header("Content-Language", locale)
+		}
+		if problem.Status >= http.StatusInternalServerError {
+			// the cause is never sent to the client
+			requestid.Printf(c.Request.Context(), "%s %s: %v", c.Request.Method, c.Request.URL.Path, c.Errors.Last().Err)
+		}
+		c.Header("Content-Type", problems.ContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// NotFoundHandler reports unknown routes as problem details.
+func NotFoundHandler(c *gin.Context) {
+	c.Error(problems.NotFound("No route matches " + c.Request.URL.Path))
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-test/auth"
+	"go-test/problems"
+	"net/http"
+	"strings"
+)
+
+// ProxyAuthenticate rejects CONNECT requests without valid Proxy-Authorization
+// or without the tunnel permission. Bearer tokens are accepted as they are in
+// Authorization, API keys as the password of Basic credentials, so that
+// proxy clients can send them. Either of verifier and keys may be nil.
+func ProxyAuthenticate(verifier *auth.Verifier, keys *auth.APIKeys, policy *auth.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := proxyPrincipal(c, verifier, keys)
+		if err != nil {
+			var problem *problems.Error
+			if errors.As(err, &problem) && problem.Status == http.StatusProxyAuthRequired {
+				c.Header("Proxy-Authenticate", proxyChallenges(verifier, keys))
+			}
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if !policy.Allows(principal, auth.PermTunnel) {
+			c.Error(problems.Forbidden("Permission " + auth.PermTunnel + " is required"))
+			c.Abort()
+			return
+		}
+		// the chain continues without c.Next, so that handlers may call it directly
+		auth.Set(c, principal)
+	}
+}
+
+// proxyPrincipal verifies the credential of the Proxy-Authorization header
+func proxyPrincipal(c *gin.Context, verifier *auth.Verifier, keys *auth.APIKeys) (auth.Principal, error) {
+	header := c.GetHeader("Proxy-Authorization")
+	if token, ok := bearerToken(header); ok && verifier != nil {
+		principal, err := verifier.Verify(c.Request.Context(), token)
+		if err != nil {
+			e := proxyUnauthorized("Bearer token is invalid or expired")
+			e.Err = err
+			return principal, e
+		}
+		return principal, nil
+	}
+	if key, ok := basicPassword(header); ok && keys != nil {
+		principal, err := keys.Verify(c.Request.Context(), key)
+		if errors.Is(err, auth.ErrInvalidAPIKey) {
+			return principal, proxyUnauthorized("API key is invalid, revoked or expired")
+		}
+		return principal, err
+	}
+	return auth.Principal{}, proxyUnauthorized("Tunnels need a Proxy-Authorization header")
+}
+
+func proxyUnauthorized(detail string) *problems.Error {
+	return problems.New(http.StatusProxyAuthRequired, problems.TypeUnauthorized, detail)
+}
+
+// proxyChallenges names the accepted schemes in Proxy-Authenticate
+func proxyChallenges(verifier *auth.Verifier, keys *auth.APIKeys) string {
+	var challenges []string
+	if verifier != nil {
+		challenges = append(challenges, "Bearer")
+	}
+	if keys != nil {
+		challenges = append(challenges, `Basic realm="api-key"`)
+	}
+	return strings.Join(challenges, ", ")
+}
+
+// basicPassword reads the password of a "Basic <base64 user:password>" header
+func basicPassword(header string) (string, bool) {
+	scheme, encoded, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", false
+	}
+	_, password, ok := strings.Cut(string(decoded), ":")
+	return password, ok && password != ""
+}
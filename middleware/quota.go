@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-test/problems"
+	"go-test/quotas"
+	"go-test/requestid"
+	"net/http"
+	"time"
+)
+
+// Quota counts requests against the quotas of their API key or tenant. Clients
+// beyond a quota get 429 until its window resets, those nearing one a
+// Quota-Warning header per window. Requests pass when counting fails, and the
+// failure is logged.
+func Quota(q *quotas.Quotas) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header, err := ChargeQuota(c.Request.Context(), q)
+		for name, values := range header {
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+		}
+	}
+}
+
+// ChargeQuota counts a request of the client of ctx, independent of the
+// transport. It returns the headers of the response, with the problem to
+// answer once a quota is spent.
+func ChargeQuota(ctx context.Context, q *quotas.Quotas) (http.Header, error) {
+	header := http.Header{}
+	usage, err := q.Consume(ctx, quotas.SubjectOf(ctx))
+	if err != nil && !errors.Is(err, quotas.ErrExceeded) {
+		requestid.Printf(ctx, "quota: %v", err)
+		return header, nil
+	}
+	windows := []struct {
+		name   string
+		window quotas.Window
+	}{{"daily", usage.Daily}, {"monthly", usage.Monthly}}
+	for _, w := range windows {
+		if err != nil && w.window.Spent() {
+			header.Set("Retry-After", ceilSeconds(w.window.Resets.Sub(q.Now())))
+			return header, problems.QuotaExceeded(fmt.Sprintf("The %s quota of %d requests of plan %s is spent until %s",
+				w.name, w.window.Limit, usage.Plan, w.window.Resets.Format(time.RFC3339)))
+		}
+	}
+	for _, w := range windows {
+		if q.Near(w.window) {
+			header.Add("Quota-Warning", fmt.Sprintf("%s; used=%d; limit=%d; reset=%s",
+				w.name, w.window.Used, w.window.Limit, w.window.Resets.Format(time.RFC3339)))
+		}
+	}
+	return header, nil
+}
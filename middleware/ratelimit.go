@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-test/auth"
+	"go-test/problems"
+	"go-test/ratelimit"
+	"go-test/requestid"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit charges requests to the budget of their client, identified by API
+// key, user or, for anonymous requests, address. Clients over budget get 429
+// with Retry-After. When Redis fails requests pass, and the failure is logged.
+// Like ProxyAuthenticate it never calls c.Next, so handlers may call it directly.
+func RateLimit(limiter *ratelimit.Limiter, rules ratelimit.Rules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header, err := ChargeRateLimit(c.Request.Context(), limiter, rules, c.Request.Method, c.FullPath(), rateLimitClient(c))
+		for name, values := range header {
+			c.Writer.Header()[name] = values
+		}
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+		}
+	}
+}
+
+// ChargeRateLimit charges a request of the route to the budget of the client,
+// independent of the transport. It returns the RateLimit headers of the
+// response, with the problem to answer once the budget is spent.
+func ChargeRateLimit(ctx context.Context, limiter *ratelimit.Limiter, rules ratelimit.Rules, method, path, client string) (http.Header, error) {
+	header := http.Header{}
+	bucket, limit, cost := rules.For(method, path)
+	result, err := limiter.Allow(ctx, bucket+":"+client, limit, cost)
+	if err != nil {
+		requestid.Printf(ctx, "rate limit: %v", err)
+		return header, nil
+	}
+
+	header.Set("RateLimit-Limit", strconv.Itoa(limit.Capacity()))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", ceilSeconds(result.ResetAfter))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s;burst=%d", limit.Rate, ceilSeconds(limit.Period), limit.Capacity()))
+	if !result.Allowed {
+		header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+		return header, problems.RateLimited("Request budget is spent, retry in " + ceilSeconds(result.RetryAfter) + " seconds")
+	}
+	return header, nil
+}
+
+// RateLimitAuthentication guards authenticate with the budget of the address
+// of the client: addresses whose budget is spent get 429 before credentials
+// are checked, and every request failing authentication is charged to it.
+// Valid credentials are charged to their own budget by RateLimit instead.
+func RateLimitAuthentication(limiter *ratelimit.Limiter, rules ratelimit.Rules, authenticate gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bucket, limit, cost := rules.For(c.Request.Method, c.FullPath())
+		key := bucket + ":ip:" + c.ClientIP()
+		result, err := limiter.Check(c.Request.Context(), key, limit, cost)
+		if err != nil {
+			requestid.Printf(c.Request.Context(), "rate limit: %v", err)
+		} else if !result.Allowed {
+			c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+			c.Error(problems.RateLimited("Request budget is spent, retry in " + ceilSeconds(result.RetryAfter) + " seconds"))
+			c.Abort()
+			return
+		}
+
+		authenticate(c)
+		if _, ok := auth.Current(c); ok || !c.IsAborted() {
+			return
+		}
+		if _, err := limiter.Allow(c.Request.Context(), key, limit, cost); err != nil {
+			requestid.Printf(c.Request.Context(), "rate limit: %v", err)
+		}
+	}
+}
+
+// rateLimitClient names the budget of the client of the request
+func rateLimitClient(c *gin.Context) string {
+	principal, ok := auth.Current(c)
+	return ratelimit.Client(principal, ok, c.ClientIP())
+}
+
+// ceilSeconds - whole seconds of headers, rounded up so that clients never retry early
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
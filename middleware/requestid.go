@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-test/requestid"
+	"time"
+)
+
+// RequestID keeps the X-Request-ID of the client, or generates one when it is
+// missing or malformed, stores it in the request context and echoes it in the
+// response. Operations of a batch share the id of the batch. Runs first.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.FromContext(c.Request.Context())
+		}
+		if id == "" {
+			id = requestid.New()
+		}
+		c.Request = c.Request.WithContext(requestid.WithID(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+		c.Next()
+	}
+}
+
+// AccessLog formats the access log lines of gin like its default logger,
+// followed by the request id.
+func AccessLog(param gin.LogFormatterParams) string {
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | request_id=%s\n%s",
+		param.TimeStamp.Format(time.DateTime),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		param.Path,
+		requestid.FromContext(param.Request.Context()),
+		param.ErrorMessage,
+	)
+}
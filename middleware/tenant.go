@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-test/auth"
+	"go-test/problems"
+	"go-test/tenants"
+)
+
+// Tenant resolves the tenant of the request from its principal or the
+// X-Tenant-ID header and stores it in the request context, which scopes every
+// repository and cache access of the handlers. Runs after Authenticate.
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, authenticated := auth.Current(c)
+		tenant, err := tenants.Resolve(principal, authenticated, c.GetHeader(tenants.Header))
+		if err != nil {
+			if errors.Is(err, tenants.ErrForeignTenant) {
+				c.Error(problems.Forbidden("Tenant " + c.GetHeader(tenants.Header) + " is not accessible with this credential"))
+			} else {
+				c.Error(problems.BadRequest(tenants.Header + " must be 1 to 63 lower-case letters, digits or dashes"))
+			}
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(tenants.WithTenant(c.Request.Context(), tenant))
+		c.Next()
+	}
+}
@@ -1,13 +1,83 @@
 package models
 
+import (
+	"encoding/xml"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+)
+
+// AnimalTypes - supported animal types by their code.
+var AnimalTypes = map[int]string{
+	1: "mammal",
+	2: "bird",
+	3: "reptile",
+	4: "amphibian",
+	5: "fish",
+	6: "invertebrate",
+}
+
 type Animal struct {
-	Name        string `json:"name"`
-	Type        int    `json:"type"`
-	Description string `json:"description"`
+	Name        string `json:"name" xml:"name" yaml:"name" binding:"required,nocontrol,max=100"`
+	Type        int    `json:"type" xml:"type" yaml:"type" binding:"animaltype"`
+	Description string `json:"description" xml:"description" yaml:"description" binding:"max=2000"`
+}
+
+// Normalize cleans up input before validation.
+func (a *Animal) Normalize() {
+	// single spaces only, names are shown in one line
+	a.Name = strings.Join(strings.Fields(norm.NFC.String(a.Name)), " ")
+	a.Description = normalizeText(a.Description)
+}
+
+// AnimalDescription - input of the description update.
+type AnimalDescription struct {
+	Description string `json:"description" xml:"description" yaml:"description" binding:"max=2000"`
+}
+
+func (d *AnimalDescription) Normalize() {
+	d.Description = normalizeText(d.Description)
+}
+
+// normalizeText trims multi-line text and unifies its line endings
+func normalizeText(s string) string {
+	s = strings.ReplaceAll(norm.NFC.String(s), "\r\n", "\n")
+	return strings.TrimSpace(s)
 }
 
 // AnimalWithID - one record processed into json parseable object.
 type AnimalWithID struct {
-	ID     int    `json:"id"`
-	Animal Animal `json:"data"`
+	XMLName xml.Name `json:"-" xml:"animal" yaml:"-"`
+	ID      int      `json:"id" xml:"id" yaml:"id"`
+	Animal  Animal   `json:"data" xml:"data" yaml:"data"`
+}
+
+// AnimalList - list of records wrapped into a single XML root element.
+type AnimalList struct {
+	XMLName xml.Name       `xml:"animals"`
+	Animals []AnimalWithID `xml:"animal"`
+}
+
+// AnimalV2 - record with flattened data, representation of API version 2.
+type AnimalV2 struct {
+	XMLName     xml.Name `json:"-" xml:"animal" yaml:"-"`
+	ID          int      `json:"id" xml:"id" yaml:"id"`
+	Name        string   `json:"name" xml:"name" yaml:"name"`
+	Type        int      `json:"type" xml:"type" yaml:"type"`
+	Description string   `json:"description" xml:"description" yaml:"description"`
+}
+
+// V2 flattens the record into the version 2 representation.
+func (a AnimalWithID) V2() AnimalV2 {
+	return AnimalV2{
+		ID:          a.ID,
+		Name:        a.Animal.Name,
+		Type:        a.Animal.Type,
+		Description: a.Animal.Description,
+	}
+}
+
+// AnimalV2List - version 2 list wrapped into a single XML root element.
+type AnimalV2List struct {
+	XMLName xml.Name   `xml:"animals"`
+	Animals []AnimalV2 `xml:"animal"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKeyInput - input of the API key creation.
+type APIKeyInput struct {
+	Name   string   `json:"name" binding:"required,nocontrol,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=read write admin tunnel"`
+	// never expires when missing
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKey - API key processed into json parseable object. The key itself is
+// only returned on creation and rotation.
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
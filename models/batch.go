@@ -0,0 +1,25 @@
+package models
+
+import "encoding/json"
+
+// BatchOperation - one sub-request of a batch, the body is sent as JSON.
+type BatchOperation struct {
+	Method string          `json:"method" binding:"required,oneof=GET HEAD POST PUT PATCH DELETE"`
+	Path   string          `json:"path" binding:"required,startswith=/"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchResult - response of one sub-request, JSON bodies are embedded as they are.
+type BatchResult struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResponse - results in the order of the operations.
+type BatchResponse struct {
+	Transactional bool `json:"transactional"`
+	// set when a failed operation rolled the transaction back
+	RolledBack bool          `json:"rolled_back,omitempty"`
+	Results    []BatchResult `json:"results"`
+}
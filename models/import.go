@@ -0,0 +1,26 @@
+package models
+
+// ImportRecord - one parsed row of an imported dataset, ID and ExternalID are optional keys.
+type ImportRecord struct {
+	Line       int
+	ID         uint
+	ExternalID string
+	Animal     Animal
+}
+
+// ImportRowError - validation or database error of a single imported row.
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportReport - result of an import or of its dry run.
+type ImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Total   int              `json:"total"`
+	Valid   int              `json:"valid"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors"`
+}
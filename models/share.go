@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// ShareInput - input of sharing a record with a user or a group.
+type ShareInput struct {
+	Type string `json:"type" binding:"required,oneof=user group"`
+	// subject of the user or name of the group
+	Name string `json:"name" binding:"required,nocontrol,max=200"`
+}
+
+// Share - user or group allowed to change a record of someone else.
+type Share struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
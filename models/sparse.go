@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+// AnimalType - entry of the AnimalTypes catalog.
+type AnimalType struct {
+	Code int    `json:"code" xml:"code" yaml:"code"`
+	Name string `json:"name" xml:"name" yaml:"name"`
+}
+
+// Sparse - record with the requested fields only, rendered by name in every format.
+type Sparse map[string]interface{}
+
+// MarshalXML writes the fields as elements, the id first like in full records and
+// the rest in name order. A record alone is an animal element.
+func (s Sparse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if start.Name.Local == "Sparse" {
+		start.Name.Local = "animal"
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "id" || names[j] == "id" {
+			return names[i] == "id"
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		if err := e.EncodeElement(s[name], xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// SparseList - list of sparse records wrapped into a single XML root element.
+type SparseList struct {
+	XMLName xml.Name `xml:"animals"`
+	Animals []Sparse `xml:"animal"`
+}
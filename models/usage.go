@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// QuotaInput - plan and limits an operator sets for an API key or tenant.
+type QuotaInput struct {
+	// default plan when empty
+	Plan string `json:"plan" binding:"omitempty,nocontrol,max=50"`
+	// limits of the plan when missing, 0 is unlimited
+	DailyLimit   *int64 `json:"daily_limit" binding:"omitempty,min=0"`
+	MonthlyLimit *int64 `json:"monthly_limit" binding:"omitempty,min=0"`
+}
+
+// QuotaWindow - requests of the current day or month, limit 0 is unlimited.
+type QuotaWindow struct {
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+	Remaining int64     `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// Usage - quotas of an API key or tenant and the requests counted against them.
+type Usage struct {
+	Subject    string      `json:"subject"`
+	Plan       string      `json:"plan"`
+	Overridden bool        `json:"overridden"`
+	Daily      QuotaWindow `json:"daily"`
+	Monthly    QuotaWindow `json:"monthly"`
+}
@@ -0,0 +1,167 @@
+// Package openapi describes the routes of a gin engine as an OpenAPI 3.1 document.
+package openapi
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-test/problems"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Route - documentation of one registered route, keyed by "METHOD /path" in gin syntax.
+type Route struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// query and header parameters, path parameters are derived from the path
+	Parameters []Parameter
+	// input model, nil when the route takes no body
+	Body interface{}
+	// media types of the body, JSON when empty
+	BodyTypes  []string
+	Responses  map[int]Reply
+	Deprecated bool
+}
+
+// Reply - one documented response of a route.
+type Reply struct {
+	Description string
+	// output model, nil for responses without body
+	Body interface{}
+	// media types of the body, JSON when empty
+	Types   []string
+	Headers map[string]Header
+}
+
+// Key - key of a route in the documentation table.
+func Key(method, path string) string {
+	return method + " " + path
+}
+
+// Build documents every route of the engine. Routes without an entry in docs
+// and entries without a route are reported as an error.
+func Build(info Info, routes gin.RoutesInfo, docs map[string]Route) (*Document, error) {
+	s := newSchemas()
+	problem := s.of(problems.Problem{})
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+	}
+
+	var missing []string
+	documented := map[string]bool{}
+	for _, route := range routes {
+		key := Key(route.Method, route.Path)
+		spec, ok := docs[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		documented[key] = true
+
+		path, parameters := convertPath(route.Path)
+		op := &Operation{
+			Summary:     spec.Summary,
+			Description: spec.Description,
+			Tags:        spec.Tags,
+			OperationID: operationID(route.Method, route.Path),
+			Parameters:  append(parameters, spec.Parameters...),
+			Responses:   map[string]*Response{},
+			Deprecated:  spec.Deprecated,
+		}
+		if spec.Body != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: content(s.of(spec.Body), spec.BodyTypes)}
+		}
+		for status, reply := range spec.Responses {
+			response := &Response{Description: reply.Description, Headers: reply.Headers}
+			if reply.Body != nil {
+				response.Content = content(s.of(reply.Body), reply.Types)
+			}
+			op.Responses[strconv.Itoa(status)] = response
+		}
+		// every route may fail with problem details
+		op.Responses["default"] = &Response{
+			Description: "Error reported as RFC 7807 problem details",
+			Content:     content(problem, []string{problems.ContentType}),
+		}
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = op
+	}
+	for key := range docs {
+		if !documented[key] {
+			missing = append(missing, key+" (no such route)")
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("routes without OpenAPI entry: %s", strings.Join(missing, ", "))
+	}
+	doc.Components.Schemas = s.components
+	return doc, nil
+}
+
+// Path - OpenAPI path of a gin path, e.g. /animals/{id} of /animals/:id.
+func Path(path string) string {
+	converted, _ := convertPath(path)
+	return converted
+}
+
+// convertPath turns gin path parameters into OpenAPI ones, :id and *path become {id} and {path}
+func convertPath(path string) (string, []Parameter) {
+	var parameters []Parameter
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		schema := &Schema{Type: "string"}
+		if name == "id" {
+			schema = &Schema{Type: "integer", Minimum: new(float64)}
+		}
+		parameters = append(parameters, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+// operationID is unique per method and path, e.g. get_animals_id
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.TrimLeft(segment, ":*")
+		if segment != "" {
+			id += "_" + strings.ReplaceAll(segment, "-", "_")
+		}
+	}
+	return id
+}
+
+func content(schema *Schema, types []string) map[string]MediaType {
+	if len(types) == 0 {
+		types = []string{"application/json"}
+	}
+	media := map[string]MediaType{}
+	for _, t := range types {
+		media[t] = MediaType{Schema: schema}
+	}
+	return media
+}
+
+// HeaderSchema - documented response header of the given JSON type.
+func HeaderSchema(description, jsonType string) Header {
+	return Header{Description: description, Schema: &Schema{Type: jsonType}}
+}
+
+// Query - optional query parameter of the given JSON type.
+func Query(name, description, jsonType string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: jsonType}}
+}
@@ -0,0 +1,8 @@
+package openapi
+
+import _ "embed"
+
+// UI - Redoc page rendering the document served at /openapi.json.
+//
+//go:embed redoc.html
+var UI []byte
@@ -0,0 +1,190 @@
+package problems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"go-test/db-utils/repository"
+	"io"
+	"net/http"
+)
+
+// ContentType - media type of problem responses.
+const ContentType = "application/problem+json"
+
+// problem types, relative to the API root
+const (
+	TypeBlank            = "about:blank"
+	TypeNotFound         = "/problems/not-found"
+	TypeUnauthorized     = "/problems/unauthorized"
+	TypeForbidden        = "/problems/forbidden"
+	TypeValidation       = "/problems/validation-error"
+	TypeMalformedBody    = "/problems/malformed-body"
+	TypeTimeout          = "/problems/timeout"
+	TypeCacheUnavailable = "/problems/cache-unavailable"
+	TypeInternal         = "/problems/internal-error"
+	TypeRateLimited      = "/problems/rate-limited"
+	TypeQuotaExceeded    = "/problems/quota-exceeded"
+)
+
+// Problem - error response as defined by RFC 7807.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// X-Request-ID of the request, quoted by clients reporting the problem
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// FieldError - validation failure of a single input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error - error carrying the problem it is reported as.
+type Error struct {
+	Problem
+	// cause is logged, but never sent to the client
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Title, e.Err)
+	}
+	if e.Detail != "" {
+		return fmt.Sprintf("%s: %s", e.Title, e.Detail)
+	}
+	return e.Title
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New creates a problem of the given status and type, titled by the status text.
+func New(status int, problemType, detail string) *Error {
+	return &Error{Problem: Problem{
+		Type:   problemType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}}
+}
+
+func BadRequest(detail string) *Error {
+	return New(http.StatusBadRequest, TypeBlank, detail)
+}
+
+func NotFound(detail string) *Error {
+	return New(http.StatusNotFound, TypeNotFound, detail)
+}
+
+// Unauthorized reports a request without valid credentials.
+func Unauthorized(detail string) *Error {
+	return New(http.StatusUnauthorized, TypeUnauthorized, detail)
+}
+
+// Forbidden reports a request whose client may not perform it.
+func Forbidden(detail string) *Error {
+	return New(http.StatusForbidden, TypeForbidden, detail)
+}
+
+// NotAcceptable reports a response which cannot be served in any requested media type.
+func NotAcceptable(detail string) *Error {
+	return New(http.StatusNotAcceptable, TypeBlank, detail)
+}
+
+func Validation(fields []FieldError) *Error {
+	e := New(http.StatusBadRequest, TypeValidation, "Request has invalid fields")
+	e.Title = "Validation failed"
+	e.Errors = fields
+	return e
+}
+
+func Timeout() *Error {
+	e := New(http.StatusGatewayTimeout, TypeTimeout, "Request took too long to process")
+	e.Title = "Request timeout"
+	return e
+}
+
+// Cache reports a failed Redis operation.
+func Cache(err error) *Error {
+	e := New(http.StatusServiceUnavailable, TypeCacheUnavailable, "Cache is temporarily unavailable")
+	e.Title = "Cache unavailable"
+	e.Err = err
+	return e
+}
+
+// RateLimited reports a client over its request budget.
+func RateLimited(detail string) *Error {
+	return New(http.StatusTooManyRequests, TypeRateLimited, detail)
+}
+
+// QuotaExceeded reports a client which spent the daily or monthly quota of its plan.
+func QuotaExceeded(detail string) *Error {
+	e := New(http.StatusTooManyRequests, TypeQuotaExceeded, detail)
+	e.Title = "Quota exceeded"
+	return e
+}
+
+func Internal(err error) *Error {
+	e := New(http.StatusInternalServerError, TypeInternal, "")
+	e.Err = err
+	return e
+}
+
+// From maps any error to the problem reported to the client.
+func From(err error) *Error {
+	var problem *Error
+	if errors.As(err, &problem) {
+		return problem
+	}
+	var notFound *repository.NotFoundError
+	if errors.As(err, &notFound) {
+		e := NotFound(fmt.Sprintf("Record %d not found", notFound.Id))
+		e.Err = err
+		return e
+	}
+	if errors.Is(err, repository.ErrNotPermitted) {
+		e := Forbidden("Record is owned by another user and not shared with you")
+		e.Err = err
+		return e
+	}
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		e := Validation(fieldErrors(validationErrors))
+		e.Err = err
+		return e
+	}
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &syntaxError) || errors.As(err, &typeError) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		e := New(http.StatusBadRequest, TypeMalformedBody, "Request body could not be decoded")
+		e.Err = err
+		return e
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		e := Timeout()
+		e.Err = err
+		return e
+	}
+	return Internal(err)
+}
+
+func fieldErrors(errs validator.ValidationErrors) []FieldError {
+	var fields []FieldError
+	for _, fe := range errs {
+		fields = append(fields, FieldError{
+			Field:   fe.Field(),
+			Message: fmt.Sprintf("failed on the '%s' rule", fe.Tag()),
+		})
+	}
+	return fields
+}
@@ -0,0 +1,999 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: animal.proto
+
+package animalpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AnimalEvent_Type int32
+
+const (
+	AnimalEvent_TYPE_UNSPECIFIED         AnimalEvent_Type = 0
+	AnimalEvent_TYPE_CREATED             AnimalEvent_Type = 1
+	AnimalEvent_TYPE_REPLACED            AnimalEvent_Type = 2
+	AnimalEvent_TYPE_DELETED             AnimalEvent_Type = 3
+	AnimalEvent_TYPE_DESCRIPTION_UPDATED AnimalEvent_Type = 4
+)
+
+// Enum value maps for AnimalEvent_Type.
+var (
+	AnimalEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_CREATED",
+		2: "TYPE_REPLACED",
+		3: "TYPE_DELETED",
+		4: "TYPE_DESCRIPTION_UPDATED",
+	}
+	AnimalEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED":         0,
+		"TYPE_CREATED":             1,
+		"TYPE_REPLACED":            2,
+		"TYPE_DELETED":             3,
+		"TYPE_DESCRIPTION_UPDATED": 4,
+	}
+)
+
+func (x AnimalEvent_Type) Enum() *AnimalEvent_Type {
+	p := new(AnimalEvent_Type)
+	*p = x
+	return p
+}
+
+func (x AnimalEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AnimalEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_animal_proto_enumTypes[0].Descriptor()
+}
+
+func (AnimalEvent_Type) Type() protoreflect.EnumType {
+	return &file_animal_proto_enumTypes[0]
+}
+
+func (x AnimalEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AnimalEvent_Type.Descriptor instead.
+func (AnimalEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{11, 0}
+}
+
+// Animal - input fields of an animal record.
+type Animal struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type        int32  `protobuf:"varint,2,opt,name=type,proto3" json:"type,omitempty"`
+	Description string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+}
+
+func (x *Animal) Reset() {
+	*x = Animal{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Animal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Animal) ProtoMessage() {}
+
+func (x *Animal) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Animal.ProtoReflect.Descriptor instead.
+func (*Animal) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Animal) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Animal) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Animal) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+// AnimalWithID - one stored record.
+type AnimalWithID struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   int64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Data *Animal `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *AnimalWithID) Reset() {
+	*x = AnimalWithID{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnimalWithID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnimalWithID) ProtoMessage() {}
+
+func (x *AnimalWithID) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnimalWithID.ProtoReflect.Descriptor instead.
+func (*AnimalWithID) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AnimalWithID) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AnimalWithID) GetData() *Animal {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// AnimalList - response of the animal list.
+type AnimalList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Animals []*AnimalWithID `protobuf:"bytes,1,rep,name=animals,proto3" json:"animals,omitempty"`
+}
+
+func (x *AnimalList) Reset() {
+	*x = AnimalList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnimalList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnimalList) ProtoMessage() {}
+
+func (x *AnimalList) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnimalList.ProtoReflect.Descriptor instead.
+func (*AnimalList) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AnimalList) GetAnimals() []*AnimalWithID {
+	if x != nil {
+		return x.Animals
+	}
+	return nil
+}
+
+// AnimalDescription - input of the description update.
+type AnimalDescription struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Description string `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
+}
+
+func (x *AnimalDescription) Reset() {
+	*x = AnimalDescription{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnimalDescription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnimalDescription) ProtoMessage() {}
+
+func (x *AnimalDescription) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnimalDescription.ProtoReflect.Descriptor instead.
+func (*AnimalDescription) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AnimalDescription) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type GetAnimalRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetAnimalRequest) Reset() {
+	*x = GetAnimalRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetAnimalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAnimalRequest) ProtoMessage() {}
+
+func (x *GetAnimalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAnimalRequest.ProtoReflect.Descriptor instead.
+func (*GetAnimalRequest) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetAnimalRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListAnimalsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListAnimalsRequest) Reset() {
+	*x = ListAnimalsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListAnimalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAnimalsRequest) ProtoMessage() {}
+
+func (x *ListAnimalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAnimalsRequest.ProtoReflect.Descriptor instead.
+func (*ListAnimalsRequest) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{5}
+}
+
+type CreateAnimalRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Animal *Animal `protobuf:"bytes,1,opt,name=animal,proto3" json:"animal,omitempty"`
+}
+
+func (x *CreateAnimalRequest) Reset() {
+	*x = CreateAnimalRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateAnimalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAnimalRequest) ProtoMessage() {}
+
+func (x *CreateAnimalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAnimalRequest.ProtoReflect.Descriptor instead.
+func (*CreateAnimalRequest) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreateAnimalRequest) GetAnimal() *Animal {
+	if x != nil {
+		return x.Animal
+	}
+	return nil
+}
+
+type ReplaceAnimalRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     int64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Animal *Animal `protobuf:"bytes,2,opt,name=animal,proto3" json:"animal,omitempty"`
+}
+
+func (x *ReplaceAnimalRequest) Reset() {
+	*x = ReplaceAnimalRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReplaceAnimalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplaceAnimalRequest) ProtoMessage() {}
+
+func (x *ReplaceAnimalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplaceAnimalRequest.ProtoReflect.Descriptor instead.
+func (*ReplaceAnimalRequest) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ReplaceAnimalRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ReplaceAnimalRequest) GetAnimal() *Animal {
+	if x != nil {
+		return x.Animal
+	}
+	return nil
+}
+
+type DeleteAnimalRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *DeleteAnimalRequest) Reset() {
+	*x = DeleteAnimalRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteAnimalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAnimalRequest) ProtoMessage() {}
+
+func (x *DeleteAnimalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAnimalRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAnimalRequest) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteAnimalRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type UpdateDescriptionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Description string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+}
+
+func (x *UpdateDescriptionRequest) Reset() {
+	*x = UpdateDescriptionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateDescriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDescriptionRequest) ProtoMessage() {}
+
+func (x *UpdateDescriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDescriptionRequest.ProtoReflect.Descriptor instead.
+func (*UpdateDescriptionRequest) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateDescriptionRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateDescriptionRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type WatchAnimalsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *WatchAnimalsRequest) Reset() {
+	*x = WatchAnimalsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchAnimalsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchAnimalsRequest) ProtoMessage() {}
+
+func (x *WatchAnimalsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchAnimalsRequest.ProtoReflect.Descriptor instead.
+func (*WatchAnimalsRequest) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{10}
+}
+
+// AnimalEvent - change of one record.
+type AnimalEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type   AnimalEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=animals.v1.AnimalEvent_Type" json:"type,omitempty"`
+	Animal *AnimalWithID    `protobuf:"bytes,2,opt,name=animal,proto3" json:"animal,omitempty"`
+}
+
+func (x *AnimalEvent) Reset() {
+	*x = AnimalEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_animal_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnimalEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnimalEvent) ProtoMessage() {}
+
+func (x *AnimalEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_animal_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnimalEvent.ProtoReflect.Descriptor instead.
+func (*AnimalEvent) Descriptor() ([]byte, []int) {
+	return file_animal_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AnimalEvent) GetType() AnimalEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return AnimalEvent_TYPE_UNSPECIFIED
+}
+
+func (x *AnimalEvent) GetAnimal() *AnimalWithID {
+	if x != nil {
+		return x.Animal
+	}
+	return nil
+}
+
+var File_animal_proto protoreflect.FileDescriptor
+
+var file_animal_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a,
+	0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x22, 0x52, 0x0a, 0x06, 0x41, 0x6e,
+	0x69, 0x6d, 0x61, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x0b,
+	0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x46,
+	0x0a, 0x0c, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x26,
+	0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61,
+	0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c,
+	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x40, 0x0a, 0x0a, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c,
+	0x4c, 0x69, 0x73, 0x74, 0x12, 0x32, 0x0a, 0x07, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x52,
+	0x07, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x22, 0x35, 0x0a, 0x11, 0x41, 0x6e, 0x69, 0x6d,
+	0x61, 0x6c, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x0a,
+	0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x22,
+	0x22, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x02, 0x69, 0x64, 0x22, 0x14, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x6e, 0x69, 0x6d, 0x61,
+	0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x41, 0x0a, 0x13, 0x43, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x2a, 0x0a, 0x06, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e,
+	0x69, 0x6d, 0x61, 0x6c, 0x52, 0x06, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x22, 0x52, 0x0a, 0x14,
+	0x52, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x2a, 0x0a, 0x06, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76,
+	0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x52, 0x06, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c,
+	0x22, 0x25, 0x0a, 0x13, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x22, 0x4c, 0x0a, 0x18, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x15, 0x0a, 0x13, 0x57, 0x61, 0x74, 0x63, 0x68, 0x41, 0x6e,
+	0x69, 0x6d, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xe4, 0x01, 0x0a,
+	0x0b, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x30, 0x0a, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1c, 0x2e, 0x61, 0x6e, 0x69,
+	0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x30,
+	0x0a, 0x06, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d,
+	0x61, 0x6c, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x52, 0x06, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c,
+	0x22, 0x71, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x10,
+	0x0a, 0x0c, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x52, 0x45, 0x41, 0x54, 0x45, 0x44, 0x10, 0x01,
+	0x12, 0x11, 0x0a, 0x0d, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52, 0x45, 0x50, 0x4c, 0x41, 0x43, 0x45,
+	0x44, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x45, 0x4c, 0x45,
+	0x54, 0x45, 0x44, 0x10, 0x03, 0x12, 0x1c, 0x0a, 0x18, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x45,
+	0x53, 0x43, 0x52, 0x49, 0x50, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45,
+	0x44, 0x10, 0x04, 0x32, 0xfd, 0x03, 0x0a, 0x0d, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3d, 0x0a, 0x03, 0x47, 0x65, 0x74, 0x12, 0x1c, 0x2e, 0x61,
+	0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x6e, 0x69,
+	0x6d, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x61, 0x6e, 0x69,
+	0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x57, 0x69,
+	0x74, 0x68, 0x49, 0x44, 0x12, 0x42, 0x0a, 0x04, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x1e, 0x2e, 0x61,
+	0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x6e,
+	0x69, 0x6d, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x61,
+	0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c,
+	0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x30, 0x01, 0x12, 0x43, 0x0a, 0x06, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x12, 0x1f, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x12, 0x45, 0x0a,
+	0x07, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x12, 0x20, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61,
+	0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x41, 0x6e, 0x69,
+	0x6d, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x61, 0x6e, 0x69,
+	0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x57, 0x69,
+	0x74, 0x68, 0x49, 0x44, 0x12, 0x43, 0x0a, 0x06, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x1f,
+	0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x18, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x69,
+	0x6d, 0x61, 0x6c, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x12, 0x53, 0x0a, 0x11, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x24,
+	0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x2e, 0x76,
+	0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x12, 0x43,
+	0x0a, 0x05, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1f, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x61,
+	0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x30, 0x01, 0x42, 0x18, 0x5a, 0x16, 0x67, 0x6f, 0x2d, 0x74, 0x65, 0x73, 0x74, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x6e, 0x69, 0x6d, 0x61, 0x6c, 0x70, 0x62, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_animal_proto_rawDescOnce sync.Once
+	file_animal_proto_rawDescData = file_animal_proto_rawDesc
+)
+
+func file_animal_proto_rawDescGZIP() []byte {
+	file_animal_proto_rawDescOnce.Do(func() {
+		file_animal_proto_rawDescData = protoimpl.X.CompressGZIP(file_animal_proto_rawDescData)
+	})
+	return file_animal_proto_rawDescData
+}
+
+var file_animal_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_animal_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_animal_proto_goTypes = []interface{}{
+	(AnimalEvent_Type)(0),            // 0: animals.v1.AnimalEvent.Type
+	(*Animal)(nil),                   // 1: animals.v1.Animal
+	(*AnimalWithID)(nil),             // 2: animals.v1.AnimalWithID
+	(*AnimalList)(nil),               // 3: animals.v1.AnimalList
+	(*AnimalDescription)(nil),        // 4: animals.v1.AnimalDescription
+	(*GetAnimalRequest)(nil),         // 5: animals.v1.GetAnimalRequest
+	(*ListAnimalsRequest)(nil),       // 6: animals.v1.ListAnimalsRequest
+	(*CreateAnimalRequest)(nil),      // 7: animals.v1.CreateAnimalRequest
+	(*ReplaceAnimalRequest)(nil),     // 8: animals.v1.ReplaceAnimalRequest
+	(*DeleteAnimalRequest)(nil),      // 9: animals.v1.DeleteAnimalRequest
+	(*UpdateDescriptionRequest)(nil), // 10: animals.v1.UpdateDescriptionRequest
+	(*WatchAnimalsRequest)(nil),      // 11: animals.v1.WatchAnimalsRequest
+	(*AnimalEvent)(nil),              // 12: animals.v1.AnimalEvent
+}
+var file_animal_proto_depIdxs = []int32{
+	1,  // 0: animals.v1.AnimalWithID.data:type_name -> animals.v1.Animal
+	2,  // 1: animals.v1.AnimalList.animals:type_name -> animals.v1.AnimalWithID
+	1,  // 2: animals.v1.CreateAnimalRequest.animal:type_name -> animals.v1.Animal
+	1,  // 3: animals.v1.ReplaceAnimalRequest.animal:type_name -> animals.v1.Animal
+	0,  // 4: animals.v1.AnimalEvent.type:type_name -> animals.v1.AnimalEvent.Type
+	2,  // 5: animals.v1.AnimalEvent.animal:type_name -> animals.v1.AnimalWithID
+	5,  // 6: animals.v1.AnimalService.Get:input_type -> animals.v1.GetAnimalRequest
+	6,  // 7: animals.v1.AnimalService.List:input_type -> animals.v1.ListAnimalsRequest
+	7,  // 8: animals.v1.AnimalService.Create:input_type -> animals.v1.CreateAnimalRequest
+	8,  // 9: animals.v1.AnimalService.Replace:input_type -> animals.v1.ReplaceAnimalRequest
+	9,  // 10: animals.v1.AnimalService.Delete:input_type -> animals.v1.DeleteAnimalRequest
+	10, // 11: animals.v1.AnimalService.UpdateDescription:input_type -> animals.v1.UpdateDescriptionRequest
+	11, // 12: animals.v1.AnimalService.Watch:input_type -> animals.v1.WatchAnimalsRequest
+	2,  // 13: animals.v1.AnimalService.Get:output_type -> animals.v1.AnimalWithID
+	2,  // 14: animals.v1.AnimalService.List:output_type -> animals.v1.AnimalWithID
+	2,  // 15: animals.v1.AnimalService.Create:output_type -> animals.v1.AnimalWithID
+	2,  // 16: animals.v1.AnimalService.Replace:output_type -> animals.v1.AnimalWithID
+	2,  // 17: animals.v1.AnimalService.Delete:output_type -> animals.v1.AnimalWithID
+	2,  // 18: animals.v1.AnimalService.UpdateDescription:output_type -> animals.v1.AnimalWithID
+	12, // 19: animals.v1.AnimalService.Watch:output_type -> animals.v1.AnimalEvent
+	13, // [13:20] is the sub-list for method output_type
+	6,  // [6:13] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_animal_proto_init() }
+func file_animal_proto_init() {
+	if File_animal_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_animal_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Animal); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnimalWithID); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnimalList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnimalDescription); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetAnimalRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListAnimalsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateAnimalRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplaceAnimalRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteAnimalRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdateDescriptionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchAnimalsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_animal_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnimalEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_animal_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_animal_proto_goTypes,
+		DependencyIndexes: file_animal_proto_depIdxs,
+		EnumInfos:         file_animal_proto_enumTypes,
+		MessageInfos:      file_animal_proto_msgTypes,
+	}.Build()
+	File_animal_proto = out.File
+	file_animal_proto_rawDesc = nil
+	file_animal_proto_goTypes = nil
+	file_animal_proto_depIdxs = nil
+}
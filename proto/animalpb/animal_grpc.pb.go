@@ -0,0 +1,364 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: animal.proto
+
+package animalpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AnimalService_Get_FullMethodName               = "/animals.v1.AnimalService/Get"
+	AnimalService_List_FullMethodName              = "/animals.v1.AnimalService/List"
+	AnimalService_Create_FullMethodName            = "/animals.v1.AnimalService/Create"
+	AnimalService_Replace_FullMethodName           = "/animals.v1.AnimalService/Replace"
+	AnimalService_Delete_FullMethodName            = "/animals.v1.AnimalService/Delete"
+	AnimalService_UpdateDescription_FullMethodName = "/animals.v1.AnimalService/UpdateDescription"
+	AnimalService_Watch_FullMethodName             = "/animals.v1.AnimalService/Watch"
+)
+
+// AnimalServiceClient is the client API for AnimalService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AnimalService - animal records over gRPC, same records and rules as the REST API.
+type AnimalServiceClient interface {
+	Get(ctx context.Context, in *GetAnimalRequest, opts ...grpc.CallOption) (*AnimalWithID, error)
+	// List streams the active records one by one.
+	List(ctx context.Context, in *ListAnimalsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnimalWithID], error)
+	Create(ctx context.Context, in *CreateAnimalRequest, opts ...grpc.CallOption) (*AnimalWithID, error)
+	Replace(ctx context.Context, in *ReplaceAnimalRequest, opts ...grpc.CallOption) (*AnimalWithID, error)
+	Delete(ctx context.Context, in *DeleteAnimalRequest, opts ...grpc.CallOption) (*AnimalWithID, error)
+	UpdateDescription(ctx context.Context, in *UpdateDescriptionRequest, opts ...grpc.CallOption) (*AnimalWithID, error)
+	// Watch streams changes made through any API until the client cancels.
+	Watch(ctx context.Context, in *WatchAnimalsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnimalEvent], error)
+}
+
+type animalServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnimalServiceClient(cc grpc.ClientConnInterface) AnimalServiceClient {
+	return &animalServiceClient{cc}
+}
+
+func (c *animalServiceClient) Get(ctx context.Context, in *GetAnimalRequest, opts ...grpc.CallOption) (*AnimalWithID, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnimalWithID)
+	err := c.cc.Invoke(ctx, AnimalService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *animalServiceClient) List(ctx context.Context, in *ListAnimalsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnimalWithID], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AnimalService_ServiceDesc.Streams[0], AnimalService_List_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListAnimalsRequest, AnimalWithID]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnimalService_ListClient = grpc.ServerStreamingClient[AnimalWithID]
+
+func (c *animalServiceClient) Create(ctx context.Context, in *CreateAnimalRequest, opts ...grpc.CallOption) (*AnimalWithID, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnimalWithID)
+	err := c.cc.Invoke(ctx, AnimalService_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *animalServiceClient) Replace(ctx context.Context, in *ReplaceAnimalRequest, opts ...grpc.CallOption) (*AnimalWithID, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnimalWithID)
+	err := c.cc.Invoke(ctx, AnimalService_Replace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *animalServiceClient) Delete(ctx context.Context, in *DeleteAnimalRequest, opts ...grpc.CallOption) (*AnimalWithID, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnimalWithID)
+	err := c.cc.Invoke(ctx, AnimalService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *animalServiceClient) UpdateDescription(ctx context.Context, in *UpdateDescriptionRequest, opts ...grpc.CallOption) (*AnimalWithID, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnimalWithID)
+	err := c.cc.Invoke(ctx, AnimalService_UpdateDescription_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *animalServiceClient) Watch(ctx context.Context, in *WatchAnimalsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnimalEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AnimalService_ServiceDesc.Streams[1], AnimalService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchAnimalsRequest, AnimalEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnimalService_WatchClient = grpc.ServerStreamingClient[AnimalEvent]
+
+// AnimalServiceServer is the server API for AnimalService service.
+// All implementations must embed UnimplementedAnimalServiceServer
+// for forward compatibility.
+//
+// AnimalService - animal records over gRPC, same records and rules as the REST API.
+type AnimalServiceServer interface {
+	Get(context.Context, *GetAnimalRequest) (*AnimalWithID, error)
+	// List streams the active records one by one.
+	List(*ListAnimalsRequest, grpc.ServerStreamingServer[AnimalWithID]) error
+	Create(context.Context, *CreateAnimalRequest) (*AnimalWithID, error)
+	Replace(context.Context, *ReplaceAnimalRequest) (*AnimalWithID, error)
+	Delete(context.Context, *DeleteAnimalRequest) (*AnimalWithID, error)
+	UpdateDescription(context.Context, *UpdateDescriptionRequest) (*AnimalWithID, error)
+	// Watch streams changes made through any API until the client cancels.
+	Watch(*WatchAnimalsRequest, grpc.ServerStreamingServer[AnimalEvent]) error
+	mustEmbedUnimplementedAnimalServiceServer()
+}
+
+// UnimplementedAnimalServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAnimalServiceServer struct{}
+
+func (UnimplementedAnimalServiceServer) Get(context.Context, *GetAnimalRequest) (*AnimalWithID, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedAnimalServiceServer) List(*ListAnimalsRequest, grpc.ServerStreamingServer[AnimalWithID]) error {
+	return status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedAnimalServiceServer) Create(context.Context, *CreateAnimalRequest) (*AnimalWithID, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedAnimalServiceServer) Replace(context.Context, *ReplaceAnimalRequest) (*AnimalWithID, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Replace not implemented")
+}
+func (UnimplementedAnimalServiceServer) Delete(context.Context, *DeleteAnimalRequest) (*AnimalWithID, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedAnimalServiceServer) UpdateDescription(context.Context, *UpdateDescriptionRequest) (*AnimalWithID, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDescription not implemented")
+}
+func (UnimplementedAnimalServiceServer) Watch(*WatchAnimalsRequest, grpc.ServerStreamingServer[AnimalEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedAnimalServiceServer) mustEmbedUnimplementedAnimalServiceServer() {}
+func (UnimplementedAnimalServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeAnimalServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnimalServiceServer will
+// result in compilation errors.
+type UnsafeAnimalServiceServer interface {
+	mustEmbedUnimplementedAnimalServiceServer()
+}
+
+func RegisterAnimalServiceServer(s grpc.ServiceRegistrar, srv AnimalServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAnimalServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AnimalService_ServiceDesc, srv)
+}
+
+func _AnimalService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAnimalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnimalServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnimalService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnimalServiceServer).Get(ctx, req.(*GetAnimalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnimalService_List_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListAnimalsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnimalServiceServer).List(m, &grpc.GenericServerStream[ListAnimalsRequest, AnimalWithID]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnimalService_ListServer = grpc.ServerStreamingServer[AnimalWithID]
+
+func _AnimalService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAnimalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnimalServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnimalService_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnimalServiceServer).Create(ctx, req.(*CreateAnimalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnimalService_Replace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplaceAnimalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnimalServiceServer).Replace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnimalService_Replace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnimalServiceServer).Replace(ctx, req.(*ReplaceAnimalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnimalService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAnimalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnimalServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnimalService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnimalServiceServer).Delete(ctx, req.(*DeleteAnimalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnimalService_UpdateDescription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDescriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnimalServiceServer).UpdateDescription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnimalService_UpdateDescription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnimalServiceServer).UpdateDescription(ctx, req.(*UpdateDescriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnimalService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAnimalsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnimalServiceServer).Watch(m, &grpc.GenericServerStream[WatchAnimalsRequest, AnimalEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnimalService_WatchServer = grpc.ServerStreamingServer[AnimalEvent]
+
+// AnimalService_ServiceDesc is the grpc.ServiceDesc for AnimalService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnimalService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "animals.v1.AnimalService",
+	HandlerType: (*AnimalServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _AnimalService_Get_Handler,
+		},
+		{
+			MethodName: "Create",
+			Handler:    _AnimalService_Create_Handler,
+		},
+		{
+			MethodName: "Replace",
+			Handler:    _AnimalService_Replace_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _AnimalService_Delete_Handler,
+		},
+		{
+			MethodName: "UpdateDescription",
+			Handler:    _AnimalService_UpdateDescription_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "List",
+			Handler:       _AnimalService_List_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _AnimalService_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "animal.proto",
+}
@@ -0,0 +1,29 @@
+package animalpb
+
+import "go-test/models"
+
+// NewAnimalWithID converts a record into its message.
+func NewAnimalWithID(animal models.AnimalWithID) *AnimalWithID {
+	return &AnimalWithID{
+		Id: int64(animal.ID),
+		Data: &Animal{
+			Name:        animal.Animal.Name,
+			Type:        int32(animal.Animal.Type),
+			Description: animal.Animal.Description,
+		},
+	}
+}
+
+// Model converts the message into the input model.
+func (x *Animal) Model() models.Animal {
+	return models.Animal{
+		Name:        x.GetName(),
+		Type:        int(x.GetType()),
+		Description: x.GetDescription(),
+	}
+}
+
+// Model converts the message into the input model.
+func (x *AnimalDescription) Model() models.AnimalDescription {
+	return models.AnimalDescription{Description: x.GetDescription()}
+}
@@ -0,0 +1,219 @@
+// Package quotas counts the requests of API keys and tenants against the
+// daily and monthly quotas of their plan.
+package quotas
+
+import (
+	"context"
+	"errors"
+	"go-test/auth"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/tenants"
+	"sync"
+	"time"
+)
+
+// ErrUnknownPlan - no plan of the name is configured.
+var ErrUnknownPlan = errors.New("unknown quota plan")
+
+// ErrExceeded - the request does not fit into a quota and was not counted.
+var ErrExceeded = errors.New("quota exceeded")
+
+// cacheTTL - lifetime of cached quotas, overrides reach other replicas after it.
+const cacheTTL = 30 * time.Second
+
+// Plan - requests allowed per day and month, zero is unlimited.
+type Plan struct {
+	Daily   int64
+	Monthly int64
+}
+
+// Window - requests of a subject in the current day or month.
+type Window struct {
+	Used  int64
+	Limit int64
+	// start of the next window
+	Resets time.Time
+}
+
+// Spent reports whether a limited window has no requests left.
+func (w Window) Spent() bool {
+	return w.Limit > 0 && w.Used >= w.Limit
+}
+
+// Remaining - requests left in the window, zero when spent and for unlimited windows.
+func (w Window) Remaining() int64 {
+	if w.Limit <= 0 || w.Used >= w.Limit {
+		return 0
+	}
+	return w.Limit - w.Used
+}
+
+// Usage - quotas of a subject and the requests counted against them.
+type Usage struct {
+	Subject string
+	Plan    string
+	Daily   Window
+	Monthly Window
+	// overrides of the plan limits are set
+	Overridden bool
+}
+
+// Quotas - plans and counters of every subject.
+type Quotas struct {
+	Repository *repository.QuotaRepository
+	Plans      map[string]Plan
+	// plan of subjects without one
+	DefaultPlan string
+	// share of a quota from which responses carry a warning
+	WarnAt float64
+	// time.Now when nil
+	Clock func() time.Time
+
+	mu     sync.Mutex
+	cached map[string]cachedQuota
+}
+
+// cachedQuota - quota of a subject as read at a time
+type cachedQuota struct {
+	quota models.Quota
+	read  time.Time
+}
+
+// SubjectOf - quotas are kept per API key, and per tenant for users and anonymous clients.
+func SubjectOf(ctx context.Context) string {
+	if principal, ok := auth.FromContext(ctx); ok && principal.Method == auth.MethodAPIKey {
+		// api-key:<id>
+		return principal.Subject
+	}
+	return "tenant:" + tenants.FromContext(ctx)
+}
+
+// Consume counts a request of the subject and returns its usage. Requests
+// beyond a quota are not counted, the usage is returned with ErrExceeded.
+func (q *Quotas) Consume(ctx context.Context, subject string) (Usage, error) {
+	quota, err := q.find(ctx, subject)
+	if err != nil {
+		return Usage{}, err
+	}
+	day, month := q.windows()
+	usage := q.usage(quota, day, month, 0, 0)
+	daily, monthly, counted, err := (*q.Repository).WithContext(ctx).Consume(subject, 1, day, month, usage.Daily.Limit, usage.Monthly.Limit)
+	if err != nil {
+		return Usage{}, err
+	}
+	usage.Daily.Used, usage.Monthly.Used = daily, monthly
+	if !counted {
+		return usage, ErrExceeded
+	}
+	return usage, nil
+}
+
+// find returns the quota of the subject, read at most once per cacheTTL
+func (q *Quotas) find(ctx context.Context, subject string) (models.Quota, error) {
+	now := q.Now()
+	q.mu.Lock()
+	entry, ok := q.cached[subject]
+	q.mu.Unlock()
+	if ok && now.Sub(entry.read) < cacheTTL {
+		return entry.quota, nil
+	}
+	quota, err := (*q.Repository).WithContext(ctx).Find(subject)
+	if err != nil {
+		return quota, err
+	}
+	q.mu.Lock()
+	if q.cached == nil {
+		q.cached = map[string]cachedQuota{}
+	}
+	q.cached[subject] = cachedQuota{quota: quota, read: now}
+	q.mu.Unlock()
+	return quota, nil
+}
+
+// forget drops the cached quota of the subject after changes
+func (q *Quotas) forget(subject string) {
+	q.mu.Lock()
+	delete(q.cached, subject)
+	q.mu.Unlock()
+}
+
+// Usage returns the usage of the subject without counting a request.
+func (q *Quotas) Usage(ctx context.Context, subject string) (Usage, error) {
+	rp := (*q.Repository).WithContext(ctx)
+	quota, err := rp.Find(subject)
+	if err != nil {
+		return Usage{}, err
+	}
+	day, month := q.windows()
+	daily, monthly, err := rp.Usage(subject, day, month)
+	if err != nil {
+		return Usage{}, err
+	}
+	return q.usage(quota, day, month, daily, monthly), nil
+}
+
+// Override stores the plan and limits of the subject, nil limits keep those of the plan.
+func (q *Quotas) Override(ctx context.Context, subject, plan string, daily, monthly *int64, updatedBy string) (Usage, error) {
+	if _, ok := q.Plans[plan]; plan != "" && !ok {
+		return Usage{}, ErrUnknownPlan
+	}
+	_, err := (*q.Repository).WithContext(ctx).Save(models.Quota{Subject: subject, Plan: plan, DailyLimit: daily, MonthlyLimit: monthly, UpdatedBy: updatedBy})
+	if err != nil {
+		return Usage{}, err
+	}
+	q.forget(subject)
+	return q.Usage(ctx, subject)
+}
+
+// Reset puts the subject back on the default plan.
+func (q *Quotas) Reset(ctx context.Context, subject string) (Usage, error) {
+	if err := (*q.Repository).WithContext(ctx).Delete(subject); err != nil {
+		return Usage{}, err
+	}
+	q.forget(subject)
+	return q.Usage(ctx, subject)
+}
+
+// Near reports whether a limited window of the usage reached the warning share.
+func (q *Quotas) Near(window Window) bool {
+	return q.WarnAt > 0 && window.Limit > 0 && float64(window.Used) >= q.WarnAt*float64(window.Limit)
+}
+
+// Now - time of the clock of the quotas.
+func (q *Quotas) Now() time.Time {
+	if q.Clock != nil {
+		return q.Clock()
+	}
+	return time.Now()
+}
+
+// windows returns the start of the current day and month in UTC
+func (q *Quotas) windows() (time.Time, time.Time) {
+	now := q.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// usage applies the plan and overrides of the quota to the counts
+func (q *Quotas) usage(quota models.Quota, day, month time.Time, daily, monthly int64) Usage {
+	name := quota.Plan
+	if name == "" {
+		name = q.DefaultPlan
+	}
+	plan := q.Plans[name]
+	usage := Usage{
+		Subject:    quota.Subject,
+		Plan:       name,
+		Daily:      Window{Used: daily, Limit: plan.Daily, Resets: day.AddDate(0, 0, 1)},
+		Monthly:    Window{Used: monthly, Limit: plan.Monthly, Resets: month.AddDate(0, 1, 0)},
+		Overridden: quota.DailyLimit != nil || quota.MonthlyLimit != nil,
+	}
+	if quota.DailyLimit != nil {
+		usage.Daily.Limit = *quota.DailyLimit
+	}
+	if quota.MonthlyLimit != nil {
+		usage.Monthly.Limit = *quota.MonthlyLimit
+	}
+	return usage
+}
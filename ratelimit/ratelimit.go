@@ -0,0 +1,134 @@
+// Package ratelimit limits requests with the generic cell rate algorithm in
+// Redis, so that every replica of the service draws on the same budget.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// Limit - requests allowed per period, of which up to Burst at once.
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	// Rate when zero
+	Burst int
+}
+
+// PerMinute - limit of rate requests a minute, all of them at once.
+func PerMinute(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+// Capacity - requests allowed at once.
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// Result - outcome of a request against its limit.
+type Result struct {
+	Limit     Limit
+	Allowed   bool
+	Remaining int
+	// wait until the request would be allowed, zero when allowed
+	RetryAfter time.Duration
+	// wait until the full burst is available again
+	ResetAfter time.Duration
+}
+
+// gcra keeps the theoretical arrival time of the next request per key, in
+// seconds since 2017 to keep the precision of Lua numbers. The time of the
+// Redis server is used, so that replicas agree on it. Dry runs only report
+// whether the request would be allowed.
+var gcra = redis.NewScript(`
+if redis.replicate_commands then
+  redis.replicate_commands()
+end
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+local dry = ARGV[5] == "1"
+
+local time = redis.call("TIME")
+local now = (tonumber(time[1]) - 1483228800) + tonumber(time[2]) / 1000000
+
+local emission = period / rate
+local tat = tonumber(redis.call("GET", key)) or now
+if tat < now then
+  tat = now
+end
+local new_tat = tat + emission * cost
+local diff = now - (new_tat - emission * burst)
+if diff < 0 then
+  return {0, 0, tostring(-diff), tostring(tat - now)}
+end
+local reset_after = new_tat - now
+if reset_after > 0 and not dry then
+  redis.call("SET", key, tostring(new_tat), "EX", math.ceil(reset_after))
+end
+return {1, math.floor(diff / emission), "0", tostring(reset_after)}
+`)
+
+// Limiter - budgets of clients kept in Redis.
+type Limiter struct {
+	Redis *redis.Client
+	// prepended to every key
+	Prefix string
+}
+
+// Allow takes cost requests of the budget of key, unless that exceeds the limit.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit, cost int) (Result, error) {
+	return l.run(ctx, key, limit, cost, false)
+}
+
+// Check reports whether cost requests would be allowed, leaving the budget of key as it is.
+func (l *Limiter) Check(ctx context.Context, key string, limit Limit, cost int) (Result, error) {
+	return l.run(ctx, key, limit, cost, true)
+}
+
+func (l *Limiter) run(ctx context.Context, key string, limit Limit, cost int, dry bool) (Result, error) {
+	result := Result{Limit: limit}
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		return result, fmt.Errorf("ratelimit: invalid limit %d per %s", limit.Rate, limit.Period)
+	}
+	if cost > limit.Capacity() {
+		// would never be allowed, it takes the whole burst instead
+		cost = limit.Capacity()
+	}
+	values, err := gcra.Run(ctx, l.Redis, []string{l.Prefix + key}, limit.Capacity(), limit.Rate, limit.Period.Seconds(), cost, dry).Slice()
+	if err != nil {
+		return result, err
+	}
+	if len(values) != 4 {
+		return result, fmt.Errorf("ratelimit: unexpected reply %v", values)
+	}
+	allowed, _ := values[0].(int64)
+	remaining, _ := values[1].(int64)
+	result.Allowed = allowed == 1
+	result.Remaining = int(remaining)
+	if result.RetryAfter, err = seconds(values[2]); err != nil {
+		return result, err
+	}
+	if result.ResetAfter, err = seconds(values[3]); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// seconds parses a duration returned as string by the script
+func seconds(value interface{}) (time.Duration, error) {
+	text, _ := value.(string)
+	s, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ratelimit: unexpected duration %v", value)
+	}
+	return time.Duration(s * float64(time.Second)), nil
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"go-test/auth"
+	"regexp"
+)
+
+// Rule - budget and cost of the requests of a route.
+type Rule struct {
+	// own budget of the route, the default one when nil
+	Limit *Limit
+	// requests taken from the budget, 1 when zero
+	Cost int
+}
+
+// Rules - default budget and the rules of routes, keyed by "METHOD /path" in gin syntax.
+type Rules struct {
+	Default Limit
+	Routes  map[string]Rule
+}
+
+// versioned paths share the rule of the unversioned path
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+/`)
+
+// For returns the bucket, limit and cost of requests of the route. Routes
+// without own limit share the default bucket.
+func (r Rules) For(method, path string) (string, Limit, int) {
+	key := method + " " + versionPrefix.ReplaceAllString(path, "/")
+	rule, ok := r.Routes[key]
+	if !ok {
+		return "default", r.Default, 1
+	}
+	cost := rule.Cost
+	if cost <= 0 {
+		cost = 1
+	}
+	if rule.Limit == nil {
+		return "default", r.Default, cost
+	}
+	return key, *rule.Limit, cost
+}
+
+// Client names the budget of a client, users per tenant. Anonymous clients
+// share the budget of their address.
+func Client(principal auth.Principal, authenticated bool, address string) string {
+	switch {
+	case !authenticated:
+		return "ip:" + address
+	case principal.Method == auth.MethodAPIKey:
+		// api-key:<id>
+		return principal.Subject
+	}
+	return "user:" + principal.Tenant + ":" + principal.Subject
+}
@@ -0,0 +1,59 @@
+// Package requestid correlates the log lines, problems, queries and cache
+// calls of a request by its X-Request-ID, carried by the request context.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"regexp"
+)
+
+// Header - request and response header of the id, the gRPC metadata key is its lower-case form.
+const Header = "X-Request-ID"
+
+// ids of clients are kept when short and free of characters which could
+// forge log lines or end SQL comments
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// key of the request id in request contexts
+type idKey struct{}
+
+// WithID returns a copy of ctx carrying the request id.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the request id of the context, empty when it has none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// Valid reports whether an id sent by a client may be used as is.
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
+
+// New generates a random id in the form of a version 4 UUID.
+func New() string {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		// never happens on supported platforms
+		panic(err)
+	}
+	random[6] = random[6]&0x0f | 0x40
+	random[8] = random[8]&0x3f | 0x80
+	id := hex.EncodeToString(random)
+	return id[:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:]
+}
+
+// Printf logs like log.Printf, prefixed with the request id of the context if any.
+func Printf(ctx context.Context, format string, v ...interface{}) {
+	if id := FromContext(ctx); id != "" {
+		log.Printf("request_id=%s "+format, append([]interface{}{id}, v...)...)
+		return
+	}
+	log.Printf(format, v...)
+}
@@ -2,12 +2,11 @@ package routers
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
+	"go-test/animals"
 	"go-test/db-utils/repository"
 	"go-test/models"
+	"go-test/problems"
 	"net/http"
 	"strconv"
 	"sync"
@@ -15,39 +14,45 @@ import (
 )
 
 func GetAnimals(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalRepository) {
+	// optional pagination
+	current, err := parsePage(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// only the requested columns are selected
+	columns, err := parseSparse(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	// setting various timeouts for different handlers
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// channels to receive the result
 	resultChan := make(chan []models.AnimalWithID, 1)
+	errChan := make(chan error, 1)
 
 	// launch fetching in go-routine
 	go func() {
-		mu.Lock()
-		defer mu.Unlock()
-
-		// select all records from the animals table
-		var animals, err = (*rp).FindAll()
+		store := animals.Store{Mu: mu, Repository: rp}
+		var resAnimalList []models.AnimalWithID
+		var err error
+		if current.size > 0 {
+			// only the requested page is read, the count gives the last one
+			var total int64
+			resAnimalList, total, err = store.ListPage(ctx, columns, (current.number-1)*current.size, current.size)
+			current.total = int(total)
+		} else {
+			// select all records from the animals table
+			resAnimalList, err = store.ListColumns(ctx, columns)
+		}
 		if err != nil {
-			// log the error
-			c.Error(err)
-			// respond with an internal server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
+			errChan <- err
 			return
 		}
-		// convert results into JSON parseable format
-		var resAnimalList []models.AnimalWithID
-		for _, animal := range animals {
-			resAnimalList = append(resAnimalList, models.AnimalWithID{
-				ID: int(animal.ID),
-				Animal: models.Animal{
-					Name:        animal.Name,
-					Type:        animal.Type,
-					Description: animal.Description,
-				},
-			})
-		}
 		// exit on normal execution or on timeout
 		select {
 		case resultChan <- resAnimalList:
@@ -56,23 +61,48 @@ func GetAnimals(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalRepository)
 	}()
 	select {
 	case res := <-resultChan:
-		c.JSON(http.StatusOK, res)
+		if current.size > 0 {
+			c.Set(pageKey, current)
+		}
+		respond(c, http.StatusOK, res)
+	case err := <-errChan:
+		// reported by the error middleware
+		c.Error(err)
 	case <-ctx.Done():
-		c.JSON(http.StatusRequestTimeout, gin.H{"error": "request timeout"})
+		c.Error(problems.Timeout())
 	}
 }
 
-func GetAnimalCount(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalRepository) {
-	mu.Lock()
-	defer mu.Unlock()
+// maxPerPage - largest page of the animal list.
+const maxPerPage = 500
 
+// parsePage reads ?page and ?per_page, without per_page all records are served
+func parsePage(c *gin.Context) (page, error) {
+	current := page{number: 1}
+	if raw := c.Query("page"); raw != "" {
+		number, err := strconv.Atoi(raw)
+		if err != nil || number < 1 {
+			return current, problems.BadRequest("page must be a positive number")
+		}
+		current.number = number
+	}
+	if raw := c.Query("per_page"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size < 1 || size > maxPerPage {
+			return current, problems.BadRequest("per_page must be a number between 1 and 500")
+		}
+		current.size = size
+	}
+	return current, nil
+}
+
+func GetAnimalCount(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalRepository) {
 	// get count from the animals table
-	count, err := (*rp).GetCount()
+	store := animals.Store{Mu: mu, Repository: rp}
+	count, err := store.Count(c.Request.Context())
 	if err != nil {
-		// log the error
+		// reported by the error middleware
 		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
 		return
 	}
 	// set the custom item length header to number of records in DB
@@ -80,268 +110,120 @@ func GetAnimalCount(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalReposit
 	c.Status(http.StatusOK)
 }
 
-func GetAnimalByID(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalRepository, rdb *redis.Client) {
+func GetAnimalByID(c *gin.Context, store *animals.Store) {
 	// retrieving URL id param
 	id, err := strconv.Atoi(c.Param("id"))
 	// invalid id
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID must be a number"})
-		return
-	}
-	mu.Lock()
-	defer mu.Unlock()
-
-	// try to find in cache
-	val, err := rdb.Get(context.Background(), strconv.Itoa(id)).Result()
-	if err == nil {
-		// unmarshal
-		var res *models.AnimalWithID
-		err = json.Unmarshal([]byte(val), &res)
-		if err != nil {
-			// cannot unmarshal struct
-			// log the error
-			c.Error(err)
-			// respond with an internal server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot unmarshal struct"})
-			return
-		}
-		c.JSON(http.StatusOK, res)
+		c.Error(problems.BadRequest("ID must be a number"))
 		return
 	}
 
-	animal, err := (*rp).FindByID(uint(id))
+	// only the requested columns are selected
+	columns, err := parseSparse(c)
 	if err != nil {
-		var notFound *repository.NotFoundError
-		if errors.As(err, &notFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Animal not found"})
-			return
-		}
-		// log the error
 		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
 		return
 	}
 
-	var response = models.AnimalWithID{
-		ID: id,
-		Animal: models.Animal{
-			Name:        animal.Name,
-			Type:        animal.Type,
-			Description: animal.Description,
-		},
-	}
-	jsonValue, err := json.Marshal(response)
+	// cached records are served without query, per projection
+	animal, err := store.GetColumns(c.Request.Context(), uint(id), columns)
 	if err != nil {
-		// cannot marshal struct
-		// log the error
+		// not found and query errors are reported by the error middleware
 		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot marshal struct"})
 		return
 	}
-	// cache animal by id
-	err = rdb.Set(context.Background(), strconv.Itoa(id), jsonValue, 1*time.Hour).Err()
-	if err != nil {
-		panic(err)
-	}
 
 	// send the requested animal
-	c.JSON(http.StatusOK, response)
-	return
+	respond(c, http.StatusOK, animal)
 }
 
-func CreateAnimal(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalRepository) {
-	mu.Lock()
-	defer mu.Unlock()
-
+func CreateAnimal(c *gin.Context, store *animals.Store) {
 	// incorrect input format handling
 	var animalInput models.Animal
-	if err := c.ShouldBindJSON(&animalInput); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bind(c, &animalInput); err != nil {
+		c.Error(err)
 		return
 	}
 
-	animal, err := (*rp).Create(animalInput)
+	animal, err := store.Create(c.Request.Context(), animalInput)
 	if err != nil {
-		// log the error
+		// reported by the error middleware
 		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
 		return
 	}
 
 	// return created animal
-	c.JSON(http.StatusOK, models.AnimalWithID{
-		ID: int(animal.ID),
-		Animal: models.Animal{
-			Name:        animal.Name,
-			Type:        animal.Type,
-			Description: animal.Description,
-		},
-	})
+	respond(c, http.StatusOK, animal)
 }
 
-func ReplaceAnimal(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalRepository, rdb *redis.Client) {
+func ReplaceAnimal(c *gin.Context, store *animals.Store) {
 	// retrieving URL id param
 	id, err := strconv.Atoi(c.Param("id"))
 	// invalid id
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID must be a number"})
+		c.Error(problems.BadRequest("ID must be a number"))
 		return
 	}
 	// incorrect input format handling
 	var animalInput models.Animal
-	if err := c.ShouldBindJSON(&animalInput); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	mu.Lock()
-	defer mu.Unlock()
-
-	// invalidate cache
-	err = rdb.Del(context.Background(), strconv.Itoa(id)).Err()
-	if err != nil {
-		// log the error
+	if err := bind(c, &animalInput); err != nil {
 		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete key from Redis"})
 		return
 	}
-	animal, err := (*rp).Replace(uint(id), animalInput)
+
+	// the cached record is invalidated first
+	animal, err := store.Replace(c.Request.Context(), uint(id), animalInput)
 	if err != nil {
-		var notFound *repository.NotFoundError
-		if errors.As(err, &notFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Animal not found"})
-			return
-		}
-		// log the error
+		// not found, cache and query errors are reported by the error middleware
 		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.AnimalWithID{
-		ID: int(animal.ID),
-		Animal: models.Animal{
-			Name:        animal.Name,
-			Type:        animal.Type,
-			Description: animal.Description,
-		},
-	})
+	respond(c, http.StatusOK, animal)
 }
 
-func DeleteAnimal(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalRepository, rdb *redis.Client) {
+func DeleteAnimal(c *gin.Context, store *animals.Store) {
 	// retrieving URL id param
 	id, err := strconv.Atoi(c.Param("id"))
 	// invalid id
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID must be a number"})
+		c.Error(problems.BadRequest("ID must be a number"))
 		return
 	}
-	mu.Lock()
-	defer mu.Unlock()
 
-	// invalidate cache
-	err = rdb.Del(context.Background(), strconv.Itoa(id)).Err()
-	if err != nil {
-		// log the error
-		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete key from Redis"})
-		return
-	}
-	animal, err := (*rp).Delete(uint(id))
+	animal, err := store.Delete(c.Request.Context(), uint(id))
 	if err != nil {
-		var notFound *repository.NotFoundError
-		if errors.As(err, &notFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Animal not found"})
-			return
-		}
-		// log the error
+		// not found, cache and query errors are reported by the error middleware
 		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
 		return
 	}
 
 	// send deleted animal
-	c.JSON(http.StatusOK, models.AnimalWithID{
-		ID: int(animal.ID),
-		Animal: models.Animal{
-			Name:        animal.Name,
-			Type:        animal.Type,
-			Description: animal.Description,
-		},
-	})
+	respond(c, http.StatusOK, animal)
 }
 
-func UpdateAnimalDescription(c *gin.Context, mu *sync.Mutex, rp *repository.AnimalRepository, rdb *redis.Client) {
+func UpdateAnimalDescription(c *gin.Context, store *animals.Store) {
 	// retrieving URL id param
 	id, err := strconv.Atoi(c.Param("id"))
 	// invalid id
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID must be a number"})
-		return
-	}
-
-	// invalidate cache
-	err = rdb.Del(context.Background(), strconv.Itoa(id)).Err()
-	if err != nil {
-		// log the error
-		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete key from Redis"})
+		c.Error(problems.BadRequest("ID must be a number"))
 		return
 	}
 	// incorrect input format handling
-	var input struct {
-		Description string `json:"description"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var input models.AnimalDescription
+	if err := bind(c, &input); err != nil {
+		c.Error(err)
 		return
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-
-	animal, err := (*rp).UpdateDescription(uint(id), input.Description)
+	animal, err := store.UpdateDescription(c.Request.Context(), uint(id), input.Description)
 	if err != nil {
-		var notFound *repository.NotFoundError
-		if errors.As(err, &notFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Animal not found"})
-			return
-		}
-		// log the error
+		// not found, cache and query errors are reported by the error middleware
 		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute query"})
-		return
-	}
-
-	c.JSON(http.StatusOK, models.AnimalWithID{
-		ID: int(animal.ID),
-		Animal: models.Animal{
-			Name:        animal.Name,
-			Type:        animal.Type,
-			Description: animal.Description,
-		},
-	})
-}
-
-func TraceAnimalRoute(c *gin.Context) {
-	var animal models.Animal
-	if err := c.ShouldBindJSON(&animal); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// correct header
-	c.Header("Content-Type", "message/http")
-	// send processed proxy list
-	c.Header("Via", c.GetHeader("Via"))
-	// send body as is
-	c.JSON(http.StatusOK, animal)
+	respond(c, http.StatusOK, animal)
 }
@@ -0,0 +1,171 @@
+package routers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-test/auth"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	outputModels "go-test/models"
+	"go-test/problems"
+	"go-test/tenants"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CreateAPIKey creates a key of scopes granting no more than the caller holds.
+func CreateAPIKey(c *gin.Context, rp *repository.APIKeyRepository, policy *auth.Policy) {
+	// incorrect input format handling
+	var input outputModels.APIKeyInput
+	if err := bind(c, &input); err != nil {
+		c.Error(err)
+		return
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		c.Error(problems.BadRequest("expires_at must be in the future"))
+		return
+	}
+	if !grantable(c, policy, input.Scopes) {
+		return
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		c.Error(problems.Internal(err))
+		return
+	}
+	record, err := (*rp).ForTenant(tenants.FromContext(c.Request.Context())).WithContext(c.Request.Context()).Create(models.APIKey{
+		Name:      input.Name,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    strings.Join(input.Scopes, " "),
+		ExpiresAt: input.ExpiresAt,
+	})
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	// the only time the key is shown
+	res := apiKeyResponse(record)
+	res.Key = key
+	c.JSON(http.StatusCreated, res)
+}
+
+func GetAPIKeys(c *gin.Context, rp *repository.APIKeyRepository) {
+	keys, err := (*rp).ForTenant(tenants.FromContext(c.Request.Context())).WithContext(c.Request.Context()).FindAll()
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	res := []outputModels.APIKey{}
+	for _, key := range keys {
+		res = append(res, apiKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// RotateAPIKey replaces the secret of a key, whose scopes must grant no more
+// than the caller holds since the new secret is shown to the caller.
+func RotateAPIKey(c *gin.Context, rp *repository.APIKeyRepository, policy *auth.Policy) {
+	// retrieving URL id param
+	id, err := strconv.Atoi(c.Param("id"))
+	// invalid id
+	if err != nil {
+		c.Error(problems.BadRequest("ID must be a number"))
+		return
+	}
+	existing, err := (*rp).ForTenant(tenants.FromContext(c.Request.Context())).WithContext(c.Request.Context()).FindByID(uint(id))
+	if err != nil {
+		// not found and query errors are reported by the error middleware
+		c.Error(err)
+		return
+	}
+	if !grantable(c, policy, strings.Fields(existing.Scopes)) {
+		return
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		c.Error(problems.Internal(err))
+		return
+	}
+	record, err := (*rp).ForTenant(tenants.FromContext(c.Request.Context())).WithContext(c.Request.Context()).Rotate(uint(id), prefix, hash)
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyRevoked) {
+			c.Error(problems.New(http.StatusConflict, problems.TypeBlank, "API key is revoked"))
+			return
+		}
+		// not found and query errors are reported by the error middleware
+		c.Error(err)
+		return
+	}
+	// the old key stops working, the new one is shown once
+	res := apiKeyResponse(record)
+	res.Key = key
+	c.JSON(http.StatusOK, res)
+}
+
+// RevokeAPIKey revokes a key, whose scopes must grant no more than the caller
+// holds, so that no key shuts out a stronger one.
+func RevokeAPIKey(c *gin.Context, rp *repository.APIKeyRepository, policy *auth.Policy) {
+	// retrieving URL id param
+	id, err := strconv.Atoi(c.Param("id"))
+	// invalid id
+	if err != nil {
+		c.Error(problems.BadRequest("ID must be a number"))
+		return
+	}
+	existing, err := (*rp).ForTenant(tenants.FromContext(c.Request.Context())).WithContext(c.Request.Context()).FindByID(uint(id))
+	if err != nil {
+		// not found and query errors are reported by the error middleware
+		c.Error(err)
+		return
+	}
+	if !grantable(c, policy, strings.Fields(existing.Scopes)) {
+		return
+	}
+
+	record, err := (*rp).ForTenant(tenants.FromContext(c.Request.Context())).WithContext(c.Request.Context()).Revoke(uint(id))
+	if err != nil {
+		// not found and query errors are reported by the error middleware
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, apiKeyResponse(record))
+}
+
+// grantable rejects scopes granting a permission the caller lacks, so that no
+// key mints or takes over a stronger one
+func grantable(c *gin.Context, policy *auth.Policy, scopes []string) bool {
+	principal, ok := auth.Current(c)
+	if !ok {
+		// authentication is off
+		return true
+	}
+	for _, scope := range scopes {
+		for _, permission := range policy.Scopes[scope] {
+			if !policy.Allows(principal, permission) {
+				c.Error(problems.Forbidden("Scope " + scope + " grants permission " + permission + ", which the credential lacks"))
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func apiKeyResponse(key models.APIKey) outputModels.APIKey {
+	return outputModels.APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     strings.Fields(key.Scopes),
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,170 @@
+package routers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-test/animals"
+	"go-test/models"
+	"go-test/problems"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// maxBatchOperations - operations accepted in one batch.
+const maxBatchOperations = 50
+
+// errOperationFailed rolls back a transactional batch
+var errOperationFailed = errors.New("batch operation failed")
+
+// BatchHandler executes the operations of a batch one by one through the engine,
+// with the headers of the batch request. With ?transactional=true animal record
+// operations run in one transaction of the store, which the first failed
+// operation rolls back.
+func BatchHandler(engine http.Handler, store *animals.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var operations []models.BatchOperation
+		if err := c.ShouldBindJSON(&operations); err != nil {
+			c.Error(err)
+			return
+		}
+		if len(operations) == 0 || len(operations) > maxBatchOperations {
+			c.Error(problems.BadRequest(fmt.Sprintf("A batch takes 1 to %d operations", maxBatchOperations)))
+			return
+		}
+		transactional := c.Query("transactional") == "true"
+		for i, operation := range operations {
+			p, err := operationPath(operation.Path)
+			if err != nil || p == "/batch" {
+				c.Error(problems.BadRequest(fmt.Sprintf("Operation %d has an invalid path, batches cannot be nested", i)))
+				return
+			}
+			if transactional && !isAnimalRecordPath(p) {
+				c.Error(problems.BadRequest(fmt.Sprintf("Operation %d is not on animal records, which are the only ones taking part in transactions", i)))
+				return
+			}
+		}
+
+		response := models.BatchResponse{Transactional: transactional, Results: []models.BatchResult{}}
+		if !transactional {
+			for _, operation := range operations {
+				response.Results = append(response.Results, runOperation(c.Request.Context(), engine, c.Request, operation))
+			}
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		err := store.Transaction(c.Request.Context(), func(ctx context.Context) error {
+			for _, operation := range operations {
+				result := runOperation(ctx, engine, c.Request, operation)
+				response.Results = append(response.Results, result)
+				if result.Status >= http.StatusBadRequest {
+					return errOperationFailed
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, errOperationFailed) {
+			response.RolledBack = true
+			// operations after the failed one are not executed
+			for len(response.Results) < len(operations) {
+				response.Results = append(response.Results, models.BatchResult{Status: http.StatusFailedDependency})
+			}
+		} else if err != nil {
+			// begin or commit failed
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// operationPath returns the clean path of an operation without its query
+func operationPath(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return path.Clean(u.Path), nil
+}
+
+// isAnimalRecordPath accepts the animal routes of every version, export and import excluded
+func isAnimalRecordPath(p string) bool {
+	for _, prefix := range []string{"/v1", "/v2"} {
+		if trimmed, ok := strings.CutPrefix(p, prefix); ok && strings.HasPrefix(trimmed, "/") {
+			p = trimmed
+		}
+	}
+	if p == "/animals/export" || p == "/animals/import" {
+		return false
+	}
+	return p == "/animals" || strings.HasPrefix(p, "/animals/")
+}
+
+// runOperation serves one operation as JSON request through the engine
+func runOperation(ctx context.Context, engine http.Handler, batch *http.Request, operation models.BatchOperation) models.BatchResult {
+	var body io.Reader
+	if len(operation.Body) > 0 {
+		body = bytes.NewReader(operation.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, operation.Method, operation.Path, body)
+	if err != nil {
+		problem := problems.BadRequest(err.Error()).Problem
+		problemBody, _ := json.Marshal(problem)
+		return models.BatchResult{Status: problem.Status, Body: problemBody}
+	}
+	// credentials and language of the batch apply to every operation
+	req.Header = batch.Header.Clone()
+	req.Header.Del("Content-Length")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.RemoteAddr = batch.RemoteAddr
+	req.Host = batch.Host
+
+	w := &operationRecorder{header: http.Header{}, status: http.StatusOK}
+	engine.ServeHTTP(w, req)
+
+	result := models.BatchResult{Status: w.status, Headers: map[string]string{}}
+	for name, values := range w.header {
+		result.Headers[name] = strings.Join(values, ", ")
+	}
+	switch {
+	case w.body.Len() == 0:
+	case json.Valid(w.body.Bytes()):
+		result.Body = w.body.Bytes()
+	default:
+		// other bodies are embedded as JSON string
+		result.Body, _ = json.Marshal(w.body.String())
+	}
+	return result
+}
+
+// operationRecorder - response writer keeping the response of an operation in memory.
+type operationRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *operationRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *operationRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *operationRecorder) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
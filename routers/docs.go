@@ -0,0 +1,31 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/openapi"
+	"net/http"
+	"sync"
+)
+
+// OpenAPIHandler serves the OpenAPI document, built on first request
+// when every route of the engine is registered.
+func OpenAPIHandler(build func() (*openapi.Document, error)) gin.HandlerFunc {
+	var once sync.Once
+	var doc *openapi.Document
+	var err error
+	return func(c *gin.Context) {
+		once.Do(func() {
+			doc, err = build()
+		})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// DocsHandler serves the UI of the OpenAPI document.
+func DocsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.UI)
+}
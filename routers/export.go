@@ -0,0 +1,114 @@
+package routers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go-test/db-utils/repository"
+	"go-test/models"
+	"go-test/problems"
+	"go-test/tenants"
+	"net/http"
+	"strconv"
+)
+
+// flush streamed output to the client every exportFlushRows records
+const exportFlushRows = 500
+
+// ExportAnimals streams the records of the tenant. The cursor only reads, so
+// it runs without the store lock and slow downloads never block writers.
+func ExportAnimals(c *gin.Context, rp *repository.AnimalRepository) {
+	// csv is the default export format
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		c.Error(problems.BadRequest("format must be csv or ndjson"))
+		return
+	}
+	// open a database cursor instead of loading the whole table
+	rows, err := (*rp).ForTenant(tenants.FromContext(c.Request.Context())).WithContext(c.Request.Context()).Rows()
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	defer rows.Close()
+
+	// download headers
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Header("Content-Disposition", `attachment; filename="animals.`+format+`"`)
+	c.Status(http.StatusOK)
+
+	// the status is already sent, so stream errors are only logged
+	if format == "csv" {
+		err = exportCSV(c, rows)
+	} else {
+		err = exportNDJSON(c, rows)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+func exportCSV(c *gin.Context, rows repository.AnimalRows) error {
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write([]string{"id", "name", "type", "description"}); err != nil {
+		return err
+	}
+	for n := 1; rows.Next(); n++ {
+		animal, err := rows.Scan()
+		if err != nil {
+			return err
+		}
+		record := []string{
+			strconv.Itoa(int(animal.ID)),
+			animal.Name,
+			strconv.Itoa(animal.Type),
+			animal.Description,
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		if n%exportFlushRows == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+	}
+	w.Flush()
+	c.Writer.Flush()
+	return w.Error()
+}
+
+func exportNDJSON(c *gin.Context, rows repository.AnimalRows) error {
+	// encoder writes one JSON document per line
+	enc := json.NewEncoder(c.Writer)
+	for n := 1; rows.Next(); n++ {
+		animal, err := rows.Scan()
+		if err != nil {
+			return err
+		}
+		// lines have the shape of the requested API version
+		err = enc.Encode(versioned(c, models.AnimalWithID{
+			ID: int(animal.ID),
+			Animal: models.Animal{
+				Name:        animal.Name,
+				Type:        animal.Type,
+				Description: animal.Description,
+			},
+		}))
+		if err != nil {
+			return err
+		}
+		if n%exportFlushRows == 0 {
+			c.Writer.Flush()
+		}
+	}
+	c.Writer.Flush()
+	return nil
+}
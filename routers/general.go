@@ -1,103 +1,79 @@
 package routers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
-	"io"
-	"log"
+	"go-test/auth"
+	"go-test/problems"
+	"go-test/requestid"
+	"go-test/tunnel"
 	"net"
 	"net/http"
-	"net/url"
 )
 
-func OptionsHandler(c *gin.Context) {
-	// success
-	c.Status(http.StatusNoContent)
-}
+// ConnectHandler opens a tunnel to the host:port of a CONNECT request when the
+// proxy permits the destination and the client has a tunnel to spare, then
+// relays traffic until either side closes or a timeout expires.
+func ConnectHandler(c *gin.Context, proxy *tunnel.Proxy) {
+	target := c.Request.URL.Host
+	if target == "" {
+		target = c.Request.Host
+	}
+	// tunnels are counted per authenticated client
+	client := c.ClientIP()
+	if principal, ok := auth.Current(c); ok {
+		client = principal.Subject
+	}
 
-func ConnectHandler(c *gin.Context) {
-	// parse the destination url
-	remote, err := url.Parse("http://" + c.Request.Host)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Host URL"})
+	destination, err := proxy.Resolve(c.Request.Context(), target)
+	switch {
+	case errors.Is(err, tunnel.ErrInvalidTarget):
+		c.Error(problems.BadRequest("CONNECT target must be host:port"))
+		return
+	case errors.Is(err, tunnel.ErrForbiddenDestination):
+		c.Error(problems.Forbidden("Tunnels to " + target + " are not allowed"))
+		return
+	case err != nil:
+		e := problems.New(http.StatusBadGateway, problems.TypeBlank, "Failed to resolve destination")
+		e.Err = err
+		c.Error(e)
+		return
+	}
+
+	release, ok := proxy.Acquire(client)
+	if !ok {
+		c.Error(problems.New(http.StatusTooManyRequests, problems.TypeBlank, "Too many open tunnels"))
 		return
 	}
+	defer release()
 
 	// connecting to the destination server via tcp
-	destConn, err := net.Dial("tcp", remote.Host)
+	destConn, err := proxy.Dial(c.Request.Context(), destination)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to connect to destination"})
+		status := http.StatusBadGateway
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			status = http.StatusGatewayTimeout
+		}
+		e := problems.New(status, problems.TypeBlank, "Failed to connect to destination")
+		e.Err = err
+		c.Error(e)
 		return
 	}
-	defer func(destConn net.Conn) {
-		err := destConn.Close()
-		if err != nil {
-			// log the error
-			c.Error(err)
-			// respond with an internal server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close destination connection"})
-			return
-		}
-	}(destConn)
 
 	// make it callers responsibility to close the connection
-	clientConn, _, err := c.Writer.Hijack()
+	clientConn, buffered, err := c.Writer.Hijack()
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to hijack the connection"})
+		destConn.Close()
+		c.Error(problems.New(http.StatusServiceUnavailable, problems.TypeBlank, "Failed to hijack the connection"))
 		return
 	}
-	defer func(clientConn net.Conn) {
-		err := clientConn.Close()
-		if err != nil {
-			// log the error
-			c.Error(err)
-			// respond with an internal server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close client connection"})
-			return
-		}
-	}(clientConn)
-
-	log.Println("TCP connection established. Starting to forward traffic")
-
-	// launch a go-routine to forward traffic
-	go func() {
-		defer func(clientConn net.Conn) {
-			err := clientConn.Close()
-			if err != nil {
-				// log the error
-				c.Error(err)
-				// respond with an internal server error
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close client connection"})
-				return
-			}
-		}(clientConn)
-		defer func(destConn net.Conn) {
-			err := destConn.Close()
-			if err != nil {
-				// log the error
-				c.Error(err)
-				// respond with an internal server error
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close destination connection"})
-				return
-
-			}
-		}(destConn)
-		_, err := io.Copy(destConn, clientConn)
-		if err != nil {
-			// log the error
-			c.Error(err)
-			// respond with an internal server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to forward traffic"})
-			return
-		}
-	}()
-
-	_, err = io.Copy(clientConn, destConn)
-	if err != nil {
-		// log the error
-		c.Error(err)
-		// respond with an internal server error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to forward traffic"})
+	// connection is hijacked, errors are only logged from here on
+	if _, err := clientConn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		requestid.Printf(c.Request.Context(), "tunnel to %s: %v", target, err)
+		clientConn.Close()
+		destConn.Close()
 		return
 	}
-	log.Println("Connection closed")
+	proxy.Relay(clientConn, buffered.Reader, destConn, tunnel.Record{Client: client, Target: target, Destination: destination})
 }
@@ -0,0 +1,32 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"go-test/gql"
+	"go-test/problems"
+	"net/http"
+)
+
+// GraphQLHandler executes GraphQL requests against the schema.
+func GraphQLHandler(schema graphql.Schema, resolver *gql.Resolver, limits gql.Limits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request gql.Request
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(err)
+			return
+		}
+		// errors of the query are part of the result
+		result := gql.Execute(c.Request.Context(), schema, resolver, request, limits, c.GetHeader("Accept-Language"))
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// GraphiQLHandler serves the GraphiQL IDE in debug mode only.
+func GraphiQLHandler(c *gin.Context) {
+	if gin.Mode() != gin.DebugMode {
+		c.Error(problems.NotFound("GraphiQL is available in debug mode only"))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", gql.GraphiQL)
+}
@@ -0,0 +1,125 @@
+package routers
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go-test/models"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MIMEHAL - JSON with hypermedia links of the HAL format.
+const MIMEHAL = "application/hal+json"
+
+// key of the list page in the gin context
+const pageKey = "page"
+
+// Link - HAL link object.
+type Link struct {
+	Href string `json:"href"`
+}
+
+// page - part of a list served by the request, size 0 serves all records
+type page struct {
+	number int
+	size   int
+	total  int
+}
+
+// hal adds _links to animal records, lists embed their records next to pagination links.
+// Other objects are returned as they are.
+func hal(c *gin.Context, obj interface{}) interface{} {
+	links := NewLinkBuilder(c)
+	prefix := apiPrefix(c.Request.URL.Path)
+	switch v := obj.(type) {
+	case models.AnimalWithID:
+		return halRecord(links, prefix, v, v.ID)
+	case models.AnimalV2:
+		return halRecord(links, prefix, v, v.ID)
+	case models.Sparse:
+		id, _ := v["id"].(int)
+		return halRecord(links, prefix, v, id)
+	case []models.Sparse:
+		records := make([]interface{}, 0, len(v))
+		for _, animal := range v {
+			id, _ := animal["id"].(int)
+			records = append(records, halRecord(links, prefix, animal, id))
+		}
+		return halList(c, links, prefix, records)
+	case []models.AnimalWithID:
+		records := make([]interface{}, 0, len(v))
+		for _, animal := range v {
+			records = append(records, halRecord(links, prefix, animal, animal.ID))
+		}
+		return halList(c, links, prefix, records)
+	case []models.AnimalV2:
+		records := make([]interface{}, 0, len(v))
+		for _, animal := range v {
+			records = append(records, halRecord(links, prefix, animal, animal.ID))
+		}
+		return halList(c, links, prefix, records)
+	}
+	return obj
+}
+
+// halRecord merges the links into the fields of the record
+func halRecord(links LinkBuilder, prefix string, record interface{}, id int) interface{} {
+	fields := map[string]interface{}{}
+	data, _ := json.Marshal(record)
+	json.Unmarshal(data, &fields)
+	fields["_links"] = map[string]Link{
+		"self":       {Href: links.URL(prefix+"/animals/"+strconv.Itoa(id), nil)},
+		"collection": {Href: links.URL(prefix+"/animals", nil)},
+	}
+	return fields
+}
+
+func halList(c *gin.Context, links LinkBuilder, prefix string, records []interface{}) interface{} {
+	collection := prefix + "/animals"
+	p, ok := c.Get(pageKey)
+	current, _ := p.(page)
+	if !ok || current.size == 0 {
+		// one page with all records
+		current = page{number: 1, size: len(records), total: len(records)}
+	}
+	pageURL := func(number int) Link {
+		query := url.Values{}
+		for key, values := range c.Request.URL.Query() {
+			query[key] = values
+		}
+		query.Set("page", strconv.Itoa(number))
+		if current.size > 0 {
+			query.Set("per_page", strconv.Itoa(current.size))
+		}
+		return Link{Href: links.URL(collection, query)}
+	}
+	last := 1
+	if current.size > 0 && current.total > 0 {
+		last = (current.total + current.size - 1) / current.size
+	}
+	list := map[string]Link{
+		"self":  pageURL(current.number),
+		"first": pageURL(1),
+		"last":  pageURL(last),
+	}
+	if current.number > 1 {
+		list["prev"] = pageURL(min(current.number-1, last))
+	}
+	if current.number < last {
+		list["next"] = pageURL(current.number + 1)
+	}
+	return map[string]interface{}{
+		"_links":    list,
+		"_embedded": map[string]interface{}{"animals": records},
+		"total":     current.total,
+	}
+}
+
+// apiPrefix is the version prefix of the requested animal path, e.g. /v2
+func apiPrefix(path string) string {
+	if i := strings.Index(path, "/animals"); i > 0 {
+		return path[:i]
+	}
+	return ""
+}
@@ -0,0 +1,392 @@
+package routers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go-test/animals"
+	"go-test/auth"
+	"go-test/db-utils/repository"
+	"go-test/jobs"
+	"go-test/models"
+	"go-test/problems"
+	"go-test/validation"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fields an imported column can be mapped to
+var importFields = []string{"id", "external_id", "name", "type", "description"}
+
+// ImportJobKind - kind of background jobs running large imports.
+const ImportJobKind = "animal-import"
+
+// importJobPayload - everything a background import needs, stored with the job
+type importJobPayload struct {
+	Records []models.ImportRecord `json:"records"`
+	Key     string                `json:"key"`
+	Report  models.ImportReport   `json:"report"`
+	// owner of created records, whose rights apply to updated ones
+	Actor repository.Actor `json:"actor"`
+}
+
+// importCheckpoint - progress of a background import saved after every row,
+// so that retried and requeued jobs do not insert rows twice
+type importCheckpoint struct {
+	Offset int                 `json:"offset"`
+	Report models.ImportReport `json:"report"`
+}
+
+// ImportJobHandler runs imports enqueued by ImportAnimals, for the tenant of the job.
+func ImportJobHandler(store *animals.Store) jobs.Handler {
+	return func(ctx context.Context, payload []byte, progress func(done, total int)) (interface{}, error) {
+		var p importJobPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return nil, jobs.Permanent(err)
+		}
+		// continue after the rows of previous attempts
+		cp := importCheckpoint{Report: p.Report}
+		if saved := jobs.Checkpoint(ctx); saved != nil {
+			if err := json.Unmarshal(saved, &cp); err != nil {
+				return nil, jobs.Permanent(err)
+			}
+			if cp.Offset > len(p.Records) {
+				return nil, jobs.Permanent(errors.New("checkpoint is past the last record"))
+			}
+		}
+		return runImport(animals.WithActor(ctx, p.Actor), store, p.Records[cp.Offset:], p.Key, cp.Report, func(done int, report models.ImportReport) error {
+			progress(cp.Offset+done, len(p.Records))
+			// a row written just before a crash is the only one imported again
+			return jobs.SaveCheckpoint(ctx, importCheckpoint{Offset: cp.Offset + done, Report: report})
+		})
+	}
+}
+
+// ImportAnimals imports records through the store, so that cached records are
+// invalidated and changes published like those of single requests.
+func ImportAnimals(c *gin.Context, store *animals.Store, policy *auth.Policy, runner *jobs.Runner, backgroundRows int, maxBytes int64) {
+	// import options
+	dryRun := c.Query("dry_run") == "true"
+	key := repository.ImportKeyNone
+	switch mode := c.DefaultQuery("mode", "insert"); mode {
+	case "insert":
+	case "upsert":
+		key = c.DefaultQuery("key", repository.ImportKeyID)
+		if key != repository.ImportKeyID && key != repository.ImportKeyExternalID {
+			c.Error(problems.BadRequest("key must be id or external_id"))
+			return
+		}
+	default:
+		c.Error(problems.BadRequest("mode must be insert or upsert"))
+		return
+	}
+	mapping, err := parseImportMapping(c.Query("map"))
+	if err != nil {
+		c.Error(problems.BadRequest(err.Error()))
+		return
+	}
+
+	// read the whole body, background imports outlive the request
+	if maxBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+	}
+	var records []models.ImportRecord
+	var rowErrors []models.ImportRowError
+	switch importFormat(c) {
+	case "csv":
+		records, rowErrors, err = parseImportCSV(c.Request.Body, mapping)
+	case "ndjson":
+		records, rowErrors, err = parseImportNDJSON(c.Request.Body, mapping)
+	default:
+		c.Error(problems.New(http.StatusUnsupportedMediaType, problems.TypeBlank, "body must be text/csv or application/x-ndjson"))
+		return
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.Error(problems.New(http.StatusRequestEntityTooLarge, problems.TypeBlank, fmt.Sprintf("Import body exceeds %d bytes", tooLarge.Limit)))
+		return
+	}
+	if err != nil {
+		e := problems.New(http.StatusBadRequest, problems.TypeMalformedBody, err.Error())
+		e.Err = err
+		c.Error(e)
+		return
+	}
+
+	// validate rows before touching the database
+	report := models.ImportReport{DryRun: dryRun, Total: len(records) + len(rowErrors), Errors: append([]models.ImportRowError{}, rowErrors...)}
+	var valid []models.ImportRecord
+	for _, record := range records {
+		if msg := validateImportRecord(&record, key, c.GetHeader("Accept-Language")); msg != "" {
+			report.Errors = append(report.Errors, models.ImportRowError{Line: record.Line, Error: msg})
+			continue
+		}
+		valid = append(valid, record)
+	}
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Line < report.Errors[j].Line
+	})
+	report.Valid = len(valid)
+	report.Failed = len(report.Errors)
+	if dryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	// large imports run in background
+	actor := animals.ActorOf(c.Request.Context(), policy)
+	if backgroundRows > 0 && len(valid) > backgroundRows {
+		job, err := runner.Enqueue(c.Request.Context(), ImportJobKind, importJobPayload{Records: valid, Key: key, Report: report, Actor: actor})
+		if err != nil {
+			// reported by the error middleware
+			c.Error(err)
+			return
+		}
+		c.Header("Location", fmt.Sprintf("/jobs/%d", job.ID))
+		c.JSON(http.StatusAccepted, jobResponse(job))
+		return
+	}
+	report, err = runImport(c.Request.Context(), store, valid, key, report, nil)
+	if err != nil {
+		// request was cancelled by the client
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// runImport writes the records one by one, after is called with the outcome of every row
+func runImport(ctx context.Context, store *animals.Store, records []models.ImportRecord, key string, report models.ImportReport, after func(done int, report models.ImportReport) error) (models.ImportReport, error) {
+	for i, record := range records {
+		// stop on cancellation, rows imported so far are kept
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		// the store locks per row, so that other requests are served meanwhile
+		_, created, err := store.Import(ctx, record, key)
+		if err != nil {
+			var notFound *repository.NotFoundError
+			msg := "Failed to execute query"
+			if errors.As(err, &notFound) {
+				msg = "Animal not found"
+			} else if errors.Is(err, repository.ErrNotPermitted) {
+				msg = "Animal is owned by another user"
+			}
+			report.Errors = append(report.Errors, models.ImportRowError{Line: record.Line, Error: msg})
+			report.Failed++
+		} else if created {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+		if after != nil {
+			if err := after(i+1, report); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, nil
+}
+
+func importFormat(c *gin.Context) string {
+	// explicit format wins over content type
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return "csv"
+	case "application/x-ndjson", "application/ndjson":
+		return "ndjson"
+	}
+	return ""
+}
+
+// parseImportMapping parses "source:field,source:field" column mapping
+func parseImportMapping(raw string) (map[string]string, error) {
+	mapping := map[string]string{}
+	if raw == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		source, field, ok := strings.Cut(pair, ":")
+		if !ok || source == "" {
+			return nil, fmt.Errorf("invalid column mapping %q", pair)
+		}
+		if !isImportField(field) {
+			return nil, fmt.Errorf("unknown field %q in column mapping", field)
+		}
+		mapping[source] = field
+	}
+	return mapping, nil
+}
+
+func isImportField(field string) bool {
+	for _, f := range importFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func mapImportColumn(mapping map[string]string, column string) string {
+	if field, ok := mapping[column]; ok {
+		return field
+	}
+	return column
+}
+
+func parseImportCSV(body io.Reader, mapping map[string]string) ([]models.ImportRecord, []models.ImportRowError, error) {
+	r := csv.NewReader(body)
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read csv header: %w", err)
+	}
+	// column index -> field, unknown columns are skipped
+	fields := make([]string, len(header))
+	for i, column := range header {
+		fields[i] = mapImportColumn(mapping, strings.TrimSpace(column))
+	}
+
+	var records []models.ImportRecord
+	var rowErrors []models.ImportRowError
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+				rowErrors = append(rowErrors, models.ImportRowError{Line: parseErr.StartLine, Error: "wrong number of fields"})
+				continue
+			}
+			return nil, nil, err
+		}
+		// quoted fields may span several lines
+		line, _ := r.FieldPos(0)
+		values := map[string]string{}
+		for i, value := range row {
+			values[fields[i]] = value
+		}
+		record, err := importRecordFromValues(line, values)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Line: line, Error: err.Error()})
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, rowErrors, nil
+}
+
+func parseImportNDJSON(body io.Reader, mapping map[string]string) ([]models.ImportRecord, []models.ImportRowError, error) {
+	scanner := bufio.NewScanner(body)
+	// allow long descriptions in a single line
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var records []models.ImportRecord
+	var rowErrors []models.ImportRowError
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var object map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &object); err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Line: line, Error: "invalid JSON"})
+			continue
+		}
+		values, err := importValuesFromObject(object, mapping)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Line: line, Error: err.Error()})
+			continue
+		}
+		record, err := importRecordFromValues(line, values)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Line: line, Error: err.Error()})
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return records, rowErrors, nil
+}
+
+// importValuesFromObject converts every JSON value to its text form
+func importValuesFromObject(object map[string]any, mapping map[string]string) (map[string]string, error) {
+	values := map[string]string{}
+	for column, value := range object {
+		switch v := value.(type) {
+		case string:
+			values[mapImportColumn(mapping, column)] = v
+		case float64:
+			values[mapImportColumn(mapping, column)] = strconv.FormatFloat(v, 'f', -1, 64)
+		case nil:
+		default:
+			return nil, fmt.Errorf("%s must be a string or a number", column)
+		}
+	}
+	return values, nil
+}
+
+func importRecordFromValues(line int, values map[string]string) (models.ImportRecord, error) {
+	record := models.ImportRecord{
+		Line:       line,
+		ExternalID: strings.TrimSpace(values["external_id"]),
+		Animal: models.Animal{
+			Name:        values["name"],
+			Description: values["description"],
+		},
+	}
+	if raw := strings.TrimSpace(values["id"]); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 0)
+		if err != nil {
+			return record, errors.New("id must be a number")
+		}
+		record.ID = uint(id)
+	}
+	if raw := strings.TrimSpace(values["type"]); raw != "" {
+		animalType, err := strconv.Atoi(raw)
+		if err != nil {
+			return record, errors.New("type must be a number")
+		}
+		record.Animal.Type = animalType
+	}
+	return record, nil
+}
+
+// validateImportRecord applies the rules of the input model, record is normalized in place
+func validateImportRecord(record *models.ImportRecord, key, acceptLanguage string) string {
+	if err := binding.Validator.ValidateStruct(&record.Animal); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return err.Error()
+		}
+		fields, _ := validation.FieldErrors(validationErrors, acceptLanguage)
+		var messages []string
+		for _, field := range fields {
+			messages = append(messages, field.Message)
+		}
+		return strings.Join(messages, "; ")
+	}
+	if key == repository.ImportKeyID && record.ID == 0 {
+		return "id is required for upsert by id"
+	}
+	if key == repository.ImportKeyExternalID && record.ExternalID == "" {
+		return "external_id is required for upsert by external_id"
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package routers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/jobs"
+	outputModels "go-test/models"
+	"go-test/problems"
+	"go-test/tenants"
+	"net/http"
+	"strconv"
+)
+
+// maximal number of jobs in one list response
+const maxJobsLimit = 500
+
+func GetJobs(c *gin.Context, rp *repository.JobRepository) {
+	filter := repository.JobFilter{
+		Kind:   c.Query("kind"),
+		Status: c.Query("status"),
+		Limit:  50,
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxJobsLimit {
+			c.Error(problems.BadRequest("limit must be a number between 1 and 500"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	jobList, err := (*rp).ForTenant(tenants.FromContext(c.Request.Context())).WithContext(c.Request.Context()).FindAll(filter)
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	res := []outputModels.Job{}
+	for _, job := range jobList {
+		res = append(res, jobResponse(job))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func GetJob(c *gin.Context, rp *repository.JobRepository) {
+	// retrieving URL id param
+	id, err := strconv.Atoi(c.Param("id"))
+	// invalid id
+	if err != nil {
+		c.Error(problems.BadRequest("ID must be a number"))
+		return
+	}
+
+	job, err := (*rp).ForTenant(tenants.FromContext(c.Request.Context())).WithContext(c.Request.Context()).FindByID(uint(id))
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, jobResponse(job))
+}
+
+func CancelJob(c *gin.Context, runner *jobs.Runner) {
+	// retrieving URL id param
+	id, err := strconv.Atoi(c.Param("id"))
+	// invalid id
+	if err != nil {
+		c.Error(problems.BadRequest("ID must be a number"))
+		return
+	}
+
+	job, err := runner.Cancel(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrJobFinished) {
+			c.Error(problems.New(http.StatusConflict, problems.TypeBlank, "Job is already finished"))
+			return
+		}
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	// running jobs stop asynchronously
+	c.JSON(http.StatusAccepted, jobResponse(job))
+}
+
+func jobResponse(job models.Job) outputModels.Job {
+	res := outputModels.Job{
+		ID:          job.ID,
+		Kind:        job.Kind,
+		Status:      job.Status,
+		Progress:    job.Progress,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+	if job.Result != "" {
+		res.Result = json.RawMessage(job.Result)
+	}
+	return res
+}
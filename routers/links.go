@@ -0,0 +1,68 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/url"
+	"strings"
+)
+
+// LinkBuilder - absolute URLs of the API as the client sees them, which
+// behind proxies differ from the request by the X-Forwarded-* headers.
+type LinkBuilder struct {
+	scheme string
+	host   string
+	prefix string
+}
+
+// NewLinkBuilder honours the X-Forwarded-* headers only when a trusted proxy
+// sent them, see gin.Engine.SetTrustedProxies, clients could forge them otherwise.
+func NewLinkBuilder(c *gin.Context) LinkBuilder {
+	r := c.Request
+	b := LinkBuilder{scheme: "http", host: r.Host}
+	if r.TLS != nil {
+		b.scheme = "https"
+	}
+	if !fromTrustedProxy(c) {
+		return b
+	}
+	// proxies in a chain append their values, the first one is the client facing
+	if proto := firstValue(r.Header.Get("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+		b.scheme = proto
+	}
+	if host := firstValue(r.Header.Get("X-Forwarded-Host")); host != "" {
+		b.host = host
+	}
+	if prefix := strings.Trim(firstValue(r.Header.Get("X-Forwarded-Prefix")), "/"); prefix != "" {
+		b.prefix = "/" + prefix
+	}
+	return b
+}
+
+// probeAddress - client address forwarded by the probe of fromTrustedProxy
+const probeAddress = "198.51.100.1"
+
+// fromTrustedProxy reports whether the sender of the request is a trusted
+// proxy. Gin keeps its check private, but only trusts the forwarded client
+// address of such proxies, so a copy of the request forwarding a known one
+// tells.
+func fromTrustedProxy(c *gin.Context) bool {
+	probe := c.Copy()
+	probe.Request = c.Request.Clone(c.Request.Context())
+	probe.Request.Header.Set("X-Forwarded-For", probeAddress)
+	probe.Request.Header.Set("X-Real-IP", probeAddress)
+	return probe.RemoteIP() != probeAddress && probe.ClientIP() == probeAddress
+}
+
+// URL returns the absolute URL of an API path with the given query.
+func (b LinkBuilder) URL(path string, query url.Values) string {
+	u := url.URL{Scheme: b.scheme, Host: b.host, Path: b.prefix + path}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+func firstValue(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(value)
+}
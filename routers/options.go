@@ -0,0 +1,157 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/openapi"
+	"go-test/problems"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ResourceOptions - description of a URL, the answer to OPTIONS requests accepting JSON.
+type ResourceOptions struct {
+	Path  string   `json:"path"`
+	Allow []string `json:"allow"`
+	// documented operation of every allowed method but OPTIONS
+	Operations map[string]*openapi.Operation `json:"operations"`
+}
+
+// OptionsHandler answers OPTIONS requests of any URL with the methods the engine
+// routes for it in the Allow header, and 404 when it routes none. Clients asking
+// for JSON also get the documented operations of those methods.
+func OptionsHandler(engine *gin.Engine, describe func() (*openapi.Document, error)) gin.HandlerFunc {
+	var once sync.Once
+	var routes gin.RoutesInfo
+	return func(c *gin.Context) {
+		// routes are complete once the first request is served
+		once.Do(func() {
+			routes = engine.Routes()
+		})
+		path := c.Request.URL.Path
+		matched := routeMethods(routes, path)
+		if len(matched) == 0 {
+			c.Error(problems.NotFound("No resource at " + path))
+			return
+		}
+
+		allow := allowedMethods(matched)
+		c.Header("Allow", strings.Join(allow, ", "))
+		if !acceptsJSON(c.Request) {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		doc, err := describe()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		resource := ResourceOptions{Path: path, Allow: allow, Operations: map[string]*openapi.Operation{}}
+		for method, route := range matched {
+			if item, ok := doc.Paths[openapi.Path(route)]; ok {
+				resource.Operations[method] = (*item)[strings.ToLower(method)]
+			}
+		}
+		c.JSON(http.StatusOK, resource)
+	}
+}
+
+// AllowedMethods returns the methods the routes serve for the path and
+// OPTIONS, sorted for the Allow header, none when no route serves the path.
+func AllowedMethods(routes gin.RoutesInfo, path string) []string {
+	matched := routeMethods(routes, path)
+	if len(matched) == 0 {
+		return nil
+	}
+	return allowedMethods(matched)
+}
+
+// allowedMethods lists the matched methods and OPTIONS in sorted order
+func allowedMethods(matched map[string]string) []string {
+	allow := make([]string, 0, len(matched)+1)
+	for method := range matched {
+		allow = append(allow, method)
+	}
+	allow = append(allow, http.MethodOptions)
+	sort.Strings(allow)
+	return allow
+}
+
+// routeMethods finds the route serving the path for every method except OPTIONS,
+// preferring static segments over parameters and parameters over catch-alls like gin
+func routeMethods(routes gin.RoutesInfo, path string) map[string]string {
+	matched := map[string]string{}
+	for _, route := range routes {
+		if route.Method == http.MethodOptions || !matchRoute(route.Path, path) {
+			continue
+		}
+		if current, ok := matched[route.Method]; !ok || precedes(route.Path, current) {
+			matched[route.Method] = route.Path
+		}
+	}
+	return matched
+}
+
+// matchRoute reports whether the gin route path serves the request path
+func matchRoute(route, path string) bool {
+	routeSegments := strings.Split(route, "/")
+	pathSegments := strings.Split(path, "/")
+	for i, segment := range routeSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(routeSegments) == len(pathSegments)
+}
+
+// precedes reports whether gin prefers route a over route b for a path both match
+func precedes(a, b string) bool {
+	aSegments := strings.Split(a, "/")
+	bSegments := strings.Split(b, "/")
+	for i := 0; i < len(aSegments) && i < len(bSegments); i++ {
+		if rank, other := segmentRank(aSegments[i]), segmentRank(bSegments[i]); rank != other {
+			return rank < other
+		}
+	}
+	return false
+}
+
+// segmentRank orders static segments before parameters and catch-alls
+func segmentRank(segment string) int {
+	switch {
+	case strings.HasPrefix(segment, "*"):
+		return 2
+	case strings.HasPrefix(segment, ":"):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// acceptsJSON reports whether the Accept header names JSON, wildcards keep the empty answer
+func acceptsJSON(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept") {
+		for _, accepted := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+			if err == nil && mediaType == gin.MIMEJSON && params["q"] != "0" {
+				return true
+			}
+		}
+	}
+	return false
+}
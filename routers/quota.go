@@ -0,0 +1,119 @@
+package routers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-test/auth"
+	outputModels "go-test/models"
+	"go-test/problems"
+	"go-test/quotas"
+	"net/http"
+	"strings"
+)
+
+// GetUsage reports the quotas of the API key or tenant of the request.
+func GetUsage(c *gin.Context, q *quotas.Quotas) {
+	if !quotasOn(c, q) {
+		return
+	}
+	usage, err := q.Usage(c.Request.Context(), quotas.SubjectOf(c.Request.Context()))
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, usageResponse(usage))
+}
+
+func GetQuota(c *gin.Context, q *quotas.Quotas) {
+	subject, ok := quotaSubject(c)
+	if !ok || !quotasOn(c, q) {
+		return
+	}
+	usage, err := q.Usage(c.Request.Context(), subject)
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, usageResponse(usage))
+}
+
+// OverrideQuota changes the plan and limits of an API key or tenant.
+func OverrideQuota(c *gin.Context, q *quotas.Quotas) {
+	subject, ok := quotaSubject(c)
+	if !ok || !quotasOn(c, q) {
+		return
+	}
+	// incorrect input format handling
+	var input outputModels.QuotaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var operator string
+	if principal, ok := auth.Current(c); ok {
+		operator = principal.Subject
+	}
+	usage, err := q.Override(c.Request.Context(), subject, input.Plan, input.DailyLimit, input.MonthlyLimit, operator)
+	if errors.Is(err, quotas.ErrUnknownPlan) {
+		c.Error(problems.Validation([]problems.FieldError{{Field: "plan", Message: "must be a configured plan"}}))
+		return
+	}
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, usageResponse(usage))
+}
+
+// ResetQuota puts an API key or tenant back on the default plan.
+func ResetQuota(c *gin.Context, q *quotas.Quotas) {
+	subject, ok := quotaSubject(c)
+	if !ok || !quotasOn(c, q) {
+		return
+	}
+	usage, err := q.Reset(c.Request.Context(), subject)
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, usageResponse(usage))
+}
+
+// quotasOn reports a missing quota configuration as not found
+func quotasOn(c *gin.Context, q *quotas.Quotas) bool {
+	if q == nil {
+		c.Error(problems.NotFound("Quotas are not configured"))
+		return false
+	}
+	return true
+}
+
+// quotaSubject reads the api-key:<id> or tenant:<id> subject of the URL
+func quotaSubject(c *gin.Context) (string, bool) {
+	subject := c.Param("subject")
+	kind, id, _ := strings.Cut(subject, ":")
+	if (kind != "api-key" && kind != "tenant") || id == "" {
+		c.Error(problems.BadRequest("Subject must be api-key:<id> or tenant:<id>"))
+		return "", false
+	}
+	return subject, true
+}
+
+// usageResponse processes the usage into json parseable object
+func usageResponse(usage quotas.Usage) outputModels.Usage {
+	window := func(w quotas.Window) outputModels.QuotaWindow {
+		return outputModels.QuotaWindow{Used: w.Used, Limit: w.Limit, Remaining: w.Remaining(), ResetsAt: w.Resets}
+	}
+	return outputModels.Usage{
+		Subject:    usage.Subject,
+		Plan:       usage.Plan,
+		Overridden: usage.Overridden,
+		Daily:      window(usage.Daily),
+		Monthly:    window(usage.Monthly),
+	}
+}
@@ -0,0 +1,200 @@
+package routers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"github.com/go-playground/validator/v10"
+	"go-test/models"
+	"go-test/problems"
+	"go-test/proto/animalpb"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// key of the negotiated response media type in the gin context
+const formatKey = "format"
+
+// OfferedFormats - response and request body media types of animal routes, the first one is the default.
+var OfferedFormats = []string{
+	binding.MIMEJSON,
+	binding.MIMEXML,
+	binding.MIMEXML2,
+	binding.MIMEYAML,
+	binding.MIMEYAML2,
+	binding.MIMEMSGPACK,
+	binding.MIMEMSGPACK2,
+	binding.MIMEPROTOBUF,
+	MIMEAnimalsV1,
+	MIMEAnimalsV2,
+	MIMEHAL,
+}
+
+var errProtobufNotAcceptable = problems.NotAcceptable("Protobuf is available for complete v1 records only, request another media type")
+
+// protobufServable reports whether the records of the request have a protobuf
+// schema, v2 records and sparse fieldsets have none
+func protobufServable(c *gin.Context) bool {
+	_, hasFields := c.GetQuery("fields")
+	return c.GetInt(versionKey) < 2 && !hasFields && c.Query("include") == ""
+}
+
+var errUnsupportedMediaType = problems.New(http.StatusUnsupportedMediaType, problems.TypeBlank, "Supported media types are "+strings.Join(OfferedFormats, ", "))
+
+// Negotiate rejects requests with unsupported Accept or Content-Type
+// before any handler work is done.
+func Negotiate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.NegotiateFormat(OfferedFormats...)
+		if format == "" {
+			c.Error(problems.NotAcceptable("Available media types are " + strings.Join(OfferedFormats, ", ")))
+			c.Abort()
+			return
+		}
+		// refused before any change is made rather than by respond
+		if format == binding.MIMEPROTOBUF && !protobufServable(c) {
+			c.Error(errProtobufNotAcceptable)
+			c.Abort()
+			return
+		}
+		c.Set(formatKey, format)
+		if _, ok := contentType(c); !ok {
+			c.Error(errUnsupportedMediaType)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// contentType returns media type of the request body, JSON when not set
+func contentType(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Content-Type")
+	if header == "" {
+		return binding.MIMEJSON, true
+	}
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return "", false
+	}
+	for _, accepted := range OfferedFormats {
+		if mediaType == accepted {
+			return mediaType, true
+		}
+	}
+	return "", false
+}
+
+// bind decodes the request body according to its Content-Type
+func bind(c *gin.Context, obj interface{}) error {
+	mediaType, ok := contentType(c)
+	if !ok {
+		return errUnsupportedMediaType
+	}
+	b := binding.Default(c.Request.Method, mediaType)
+	switch mediaType {
+	case binding.MIMEPROTOBUF:
+		return bindProto(c, obj)
+	case MIMEAnimalsV1, MIMEAnimalsV2, MIMEHAL:
+		b = binding.JSON
+	}
+	err := c.ShouldBindWith(obj, b)
+	var validationErrors validator.ValidationErrors
+	if err == nil || errors.As(err, &validationErrors) {
+		return err
+	}
+	// decoders of every format fail with errors of their own
+	return malformedBody(err)
+}
+
+// malformedBody reports a request body which could not be decoded
+func malformedBody(err error) error {
+	e := problems.New(http.StatusBadRequest, problems.TypeMalformedBody, "Request body could not be decoded")
+	e.Err = err
+	return e
+}
+
+// bindProto decodes protobuf messages into input models
+func bindProto(c *gin.Context, obj interface{}) error {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	switch input := obj.(type) {
+	case *models.Animal:
+		var msg animalpb.Animal
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return malformedBody(err)
+		}
+		*input = msg.Model()
+	case *models.AnimalDescription:
+		var msg animalpb.AnimalDescription
+		if err := proto.Unmarshal(data, &msg); err != nil {
+			return malformedBody(err)
+		}
+		*input = msg.Model()
+	default:
+		return errUnsupportedMediaType
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// respond renders obj in the format negotiated from the Accept header
+func respond(c *gin.Context, code int, obj interface{}) {
+	format := c.GetString(formatKey)
+	if format == "" {
+		format = c.NegotiateFormat(OfferedFormats...)
+	}
+	obj = versioned(c, sparse(c, obj))
+	switch format {
+	case binding.MIMEXML, binding.MIMEXML2:
+		// single root element for lists
+		switch list := obj.(type) {
+		case []models.AnimalWithID:
+			obj = models.AnimalList{Animals: list}
+		case []models.AnimalV2:
+			obj = models.AnimalV2List{Animals: list}
+		case []models.Sparse:
+			obj = models.SparseList{Animals: list}
+		}
+		c.XML(code, obj)
+	case binding.MIMEYAML, binding.MIMEYAML2:
+		c.YAML(code, obj)
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		c.Render(code, render.MsgPack{Data: obj})
+	case binding.MIMEPROTOBUF:
+		// messages without protobuf schema cannot be served
+		if msg, ok := toProto(obj); ok {
+			c.ProtoBuf(code, msg)
+			return
+		}
+		c.Error(errProtobufNotAcceptable)
+	case MIMEHAL:
+		c.Header("Content-Type", MIMEHAL+"; charset=utf-8")
+		c.JSON(code, hal(c, obj))
+	case MIMEAnimalsV1, MIMEAnimalsV2:
+		// JSON labelled with the vendor media type
+		c.Header("Content-Type", format+"; charset=utf-8")
+		c.JSON(code, obj)
+	default:
+		c.JSON(code, obj)
+	}
+}
+
+func toProto(obj interface{}) (proto.Message, bool) {
+	switch v := obj.(type) {
+	case models.AnimalWithID:
+		return animalpb.NewAnimalWithID(v), true
+	case []models.AnimalWithID:
+		list := &animalpb.AnimalList{}
+		for _, animal := range v {
+			list.Animals = append(list.Animals, animalpb.NewAnimalWithID(animal))
+		}
+		return list, true
+	}
+	return nil, false
+}
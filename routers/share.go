@@ -0,0 +1,90 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/animals"
+	"go-test/db-utils/models"
+	outputModels "go-test/models"
+	"go-test/problems"
+	"net/http"
+	"strconv"
+)
+
+func GetAnimalShares(c *gin.Context, store *animals.Store) {
+	// retrieving URL id param
+	id, err := strconv.Atoi(c.Param("id"))
+	// invalid id
+	if err != nil {
+		c.Error(problems.BadRequest("ID must be a number"))
+		return
+	}
+
+	shares, err := store.Shares(c.Request.Context(), uint(id))
+	if err != nil {
+		// reported by the error middleware
+		c.Error(err)
+		return
+	}
+	res := []outputModels.Share{}
+	for _, share := range shares {
+		res = append(res, shareResponse(share))
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func ShareAnimal(c *gin.Context, store *animals.Store) {
+	// retrieving URL id param
+	id, err := strconv.Atoi(c.Param("id"))
+	// invalid id
+	if err != nil {
+		c.Error(problems.BadRequest("ID must be a number"))
+		return
+	}
+	// incorrect input format handling
+	var input outputModels.ShareInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// only the owner shares, sharing twice returns the first share
+	share, err := store.Share(c.Request.Context(), uint(id), input.Type, input.Name)
+	if err != nil {
+		// not found and ownership are reported by the error middleware
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, shareResponse(share))
+}
+
+func UnshareAnimal(c *gin.Context, store *animals.Store) {
+	// retrieving URL id params
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(problems.BadRequest("ID must be a number"))
+		return
+	}
+	shareID, err := strconv.Atoi(c.Param("shareId"))
+	if err != nil {
+		c.Error(problems.BadRequest("Share ID must be a number"))
+		return
+	}
+
+	share, err := store.Unshare(c.Request.Context(), uint(id), uint(shareID))
+	if err != nil {
+		// not found and ownership are reported by the error middleware
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, shareResponse(share))
+}
+
+func shareResponse(share models.AnimalShare) outputModels.Share {
+	return outputModels.Share{
+		ID:        share.ID,
+		Type:      share.GranteeType,
+		Name:      share.Grantee,
+		CreatedBy: share.CreatedBy,
+		CreatedAt: share.CreatedAt,
+	}
+}
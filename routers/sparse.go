@@ -0,0 +1,120 @@
+package routers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-test/db-utils/repository"
+	"go-test/models"
+	"go-test/problems"
+	"slices"
+	"strings"
+)
+
+// keys of the requested fields and embedded resources in the gin context
+const (
+	fieldsKey  = "fields"
+	includeKey = "include"
+)
+
+// resources which ?include embeds into records
+var includable = []string{"type"}
+
+// parseSparse reads ?fields and ?include and returns the columns to select,
+// nil for all of them. The id is always selected.
+func parseSparse(c *gin.Context) ([]string, error) {
+	rawFields, hasFields := c.GetQuery("fields")
+	rawInclude := c.Query("include")
+
+	var include []string
+	for _, name := range splitList(rawInclude) {
+		if !slices.Contains(includable, name) {
+			return nil, problems.BadRequest(fmt.Sprintf("include %q is not available, use one of %s", name, strings.Join(includable, ", ")))
+		}
+		include = append(include, name)
+	}
+	c.Set(includeKey, include)
+	if !hasFields {
+		return nil, nil
+	}
+
+	fields := splitList(rawFields)
+	for _, name := range fields {
+		if !slices.Contains(repository.AnimalColumns, name) {
+			return nil, problems.BadRequest(fmt.Sprintf("field %q does not exist, use some of %s", name, strings.Join(repository.AnimalColumns, ", ")))
+		}
+	}
+	c.Set(fieldsKey, fields)
+	// columns in table order, so that equal projections share cache entries
+	var columns []string
+	for _, column := range repository.AnimalColumns {
+		// embedding the type needs its code
+		if column == "id" || slices.Contains(fields, column) || (column == "type" && slices.Contains(include, "type")) {
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
+
+func splitList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// sparse reduces animal records to the requested fields and embeds the included
+// resources, in the representation of the request version
+func sparse(c *gin.Context, obj interface{}) interface{} {
+	raw, hasFields := c.Get(fieldsKey)
+	fields, _ := raw.([]string)
+	include := c.GetStringSlice(includeKey)
+	if !hasFields && len(include) == 0 {
+		return obj
+	}
+	if !hasFields {
+		fields = repository.AnimalColumns
+	}
+	version := c.GetInt(versionKey)
+	switch v := obj.(type) {
+	case models.AnimalWithID:
+		return sparseRecord(v, fields, include, version)
+	case []models.AnimalWithID:
+		list := make([]models.Sparse, 0, len(v))
+		for _, animal := range v {
+			list = append(list, sparseRecord(animal, fields, include, version))
+		}
+		return list
+	}
+	return obj
+}
+
+func sparseRecord(animal models.AnimalWithID, fields, include []string, version int) models.Sparse {
+	values := map[string]interface{}{
+		"name":        animal.Animal.Name,
+		"type":        animal.Animal.Type,
+		"description": animal.Animal.Description,
+	}
+	record := models.Sparse{"id": animal.ID}
+	// v1 keeps the fields in its data wrapper
+	data := record
+	if version < 2 {
+		data = models.Sparse{}
+		record["data"] = data
+	}
+	for _, name := range fields {
+		if value, ok := values[name]; ok {
+			data[name] = value
+		}
+	}
+	if slices.Contains(include, "type") {
+		embedded := models.Sparse{}
+		if name, ok := models.AnimalTypes[animal.Animal.Type]; ok {
+			embedded["type"] = models.AnimalType{Code: animal.Animal.Type, Name: name}
+		}
+		record["_embedded"] = embedded
+	}
+	return record
+}
@@ -0,0 +1,68 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/problems"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MIMEHTTP - media type of HTTP messages returned by TRACE.
+const MIMEHTTP = "message/http"
+
+// TraceHiddenHeaders - credentials never reflected by TRACE.
+var TraceHiddenHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-API-Key"}
+
+// TraceHandler reflects the request line and headers of a TRACE request as
+// received, see RFC 9110 section 9.3.8. Credentials are left out and the
+// service adds itself to Via under the pseudonym. The service never forwards
+// requests, so it answers as final recipient whatever Max-Forwards says.
+func TraceHandler(c *gin.Context, pseudonym string) {
+	maxForwards := c.GetHeader("Max-Forwards")
+	if maxForwards != "" {
+		if n, err := strconv.Atoi(maxForwards); err != nil || n < 0 {
+			c.Error(problems.BadRequest("Max-Forwards must be a non-negative integer"))
+			return
+		}
+	}
+	// clients must not send content with TRACE
+	if c.Request.ContentLength > 0 || len(c.Request.TransferEncoding) > 0 {
+		c.Error(problems.BadRequest("TRACE requests carry no content"))
+		return
+	}
+
+	header := c.Request.Header.Clone()
+	for _, name := range TraceHiddenHeaders {
+		header.Del(name)
+	}
+	// one hop more than received
+	via := append(header.Values("Via"), viaProtocol(c.Request)+" "+pseudonym)
+	header.Set("Via", strings.Join(via, ", "))
+
+	var message strings.Builder
+	message.WriteString(c.Request.Method + " " + c.Request.RequestURI + " " + c.Request.Proto + "\r\n")
+	message.WriteString("Host: " + c.Request.Host + "\r\n")
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			message.WriteString(name + ": " + value + "\r\n")
+		}
+	}
+	message.WriteString("\r\n")
+
+	c.Data(http.StatusOK, MIMEHTTP, []byte(message.String()))
+}
+
+// viaProtocol - received-protocol of Via, the version alone for HTTP
+func viaProtocol(r *http.Request) string {
+	if r.ProtoMajor >= 2 {
+		return strconv.Itoa(r.ProtoMajor)
+	}
+	return strconv.Itoa(r.ProtoMajor) + "." + strconv.Itoa(r.ProtoMinor)
+}
@@ -0,0 +1,122 @@
+package routers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-test/models"
+	"go-test/problems"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vendor media types selecting the API version of unversioned paths
+const (
+	MIMEAnimalsV1 = "application/vnd.animals.v1+json"
+	MIMEAnimalsV2 = "application/vnd.animals.v2+json"
+)
+
+// versions of the API
+const (
+	// DefaultVersion - version of unversioned paths without vendor media type.
+	DefaultVersion = 1
+	LatestVersion  = 2
+)
+
+// key of the API version in the gin context
+const versionKey = "version"
+
+var vendorMediaType = regexp.MustCompile(`^application/vnd\.animals\.v(\d+)\+json$`)
+
+// Deprecation - schedule of a deprecated API version, zero times are not reported.
+type Deprecation struct {
+	Since  time.Time
+	Sunset time.Time
+}
+
+// Version sets the API version of the request. Versioned paths pass their version,
+// unversioned ones pass 0 and select it by the vendor media type of the Accept header.
+// Responses of deprecated versions carry Deprecation and Sunset headers.
+func Version(version int, deprecations map[int]Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested, err := acceptedVersion(c.GetHeader("Accept"))
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		selected := version
+		switch {
+		case version == 0 && requested == 0:
+			selected = DefaultVersion
+		case version == 0:
+			selected = requested
+		case requested != 0 && requested != version:
+			c.Error(problems.NotAcceptable(fmt.Sprintf("Path is version %d, but version %d was requested", version, requested)))
+			c.Abort()
+			return
+		}
+		c.Set(versionKey, selected)
+
+		if deprecation, ok := deprecations[selected]; ok {
+			if !deprecation.Since.IsZero() {
+				// structured field date of RFC 9745
+				c.Header("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+			}
+			if !deprecation.Sunset.IsZero() {
+				c.Header("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+			}
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successorPath(c.Request.URL.Path, version)))
+		}
+		c.Next()
+	}
+}
+
+// acceptedVersion returns the version of the vendor media type in the Accept header, 0 without one
+func acceptedVersion(accept string) (int, error) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		match := vendorMediaType.FindStringSubmatch(mediaType)
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version < 1 || version > LatestVersion {
+			return 0, problems.NotAcceptable(fmt.Sprintf("API version %d does not exist, latest is %d", version, LatestVersion))
+		}
+		return version, nil
+	}
+	return 0, nil
+}
+
+// successorPath is the path of the latest version of the requested resource
+func successorPath(path string, version int) string {
+	if version != 0 {
+		path = strings.TrimPrefix(path, "/v"+strconv.Itoa(version))
+	}
+	return "/v" + strconv.Itoa(LatestVersion) + path
+}
+
+// versioned converts animal records into the representation of the request version
+func versioned(c *gin.Context, obj interface{}) interface{} {
+	if c.GetInt(versionKey) < 2 {
+		return obj
+	}
+	switch v := obj.(type) {
+	case models.AnimalWithID:
+		return v.V2()
+	case []models.AnimalWithID:
+		list := make([]models.AnimalV2, 0, len(v))
+		for _, animal := range v {
+			list = append(list, animal.V2())
+		}
+		return list
+	}
+	return obj
+}
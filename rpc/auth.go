@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"go-test/auth"
+	"go-test/tenants"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// public reports methods served without token, health checks and reflection
+func public(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.") || strings.HasPrefix(method, "/grpc.reflection.")
+}
+
+// permissions of the animal service methods, by method name
+var permissions = map[string]string{
+	"Get":               auth.PermRead,
+	"List":              auth.PermRead,
+	"Watch":             auth.PermRead,
+	"Create":            auth.PermCreate,
+	"Replace":           auth.PermReplace,
+	"UpdateDescription": auth.PermReplace,
+	"Delete":            auth.PermDelete,
+}
+
+// authorize checks the permission of the method, unknown methods are denied
+func authorize(ctx context.Context, policy *auth.Policy, fullMethod string) error {
+	principal, _ := auth.FromContext(ctx)
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	permission, ok := permissions[name]
+	if !ok || !policy.Allows(principal, permission) {
+		return status.Errorf(codes.PermissionDenied, "permission %s is required", permission)
+	}
+	return nil
+}
+
+// authenticate checks the API key or bearer token of the metadata, required
+// once either is configured, and returns the context carrying its principal,
+// and the tenant of the call
+func authenticate(ctx context.Context, verifier *auth.Verifier, keys *auth.APIKeys, policy *auth.Policy, fullMethod string) (context.Context, error) {
+	if verifier != nil || keys != nil {
+		var err error
+		if ctx, err = verify(ctx, verifier, keys); err != nil {
+			return nil, err
+		}
+		if err := authorize(ctx, policy, fullMethod); err != nil {
+			return nil, err
+		}
+	}
+	principal, authenticated := auth.FromContext(ctx)
+	md, _ := metadata.FromIncomingContext(ctx)
+	var requested string
+	if values := md.Get(tenants.Header); len(values) > 0 {
+		requested = values[0]
+	}
+	tenant, err := tenants.Resolve(principal, authenticated, requested)
+	if errors.Is(err, tenants.ErrForeignTenant) {
+		return nil, status.Errorf(codes.PermissionDenied, "tenant %s is not accessible with this credential", requested)
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return tenants.WithTenant(ctx, tenant), nil
+}
+
+// verify checks the x-api-key or, without one, the bearer token of the
+// authorization metadata and returns the context carrying its principal
+func verify(ctx context.Context, verifier *auth.Verifier, keys *auth.APIKeys) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("x-api-key"); len(values) > 0 && keys != nil {
+		principal, err := keys.Verify(ctx, values[0])
+		if errors.Is(err, auth.ErrInvalidAPIKey) {
+			return nil, status.Error(codes.Unauthenticated, "api key is invalid, revoked or expired")
+		}
+		if err != nil {
+			// lookup failures are internal errors
+			return nil, statusError(ctx, err)
+		}
+		return auth.WithPrincipal(ctx, principal), nil
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 || verifier == nil {
+		return nil, status.Error(codes.Unauthenticated, "request needs "+credentials(verifier, keys))
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, status.Error(codes.Unauthenticated, "request needs "+credentials(verifier, keys))
+	}
+	principal, err := verifier.Verify(ctx, strings.TrimSpace(token))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "bearer token is invalid or expired")
+	}
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+// credentials names the accepted credentials in status messages
+func credentials(verifier *auth.Verifier, keys *auth.APIKeys) string {
+	switch {
+	case verifier != nil && keys != nil:
+		return "an x-api-key metadata or a bearer token"
+	case keys != nil:
+		return "an x-api-key metadata"
+	}
+	return "a bearer token"
+}
+
+func unaryAuthenticator(verifier *auth.Verifier, keys *auth.APIKeys, policy *auth.Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if public(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, verifier, keys, policy, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuthenticator(verifier *auth.Verifier, keys *auth.APIKeys, policy *auth.Policy) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if public(info.FullMethod) {
+			return handler(srv, stream)
+		}
+		ctx, err := authenticate(stream.Context(), verifier, keys, policy, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticatedStream - server stream whose context carries the request id, the principal and the tenant
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
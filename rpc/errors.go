@@ -0,0 +1,71 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"go-test/problems"
+	"go-test/requestid"
+	"go-test/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// gRPC codes of problem statuses, others are internal errors
+var codesByStatus = map[int]codes.Code{
+	http.StatusBadRequest:         codes.InvalidArgument,
+	http.StatusForbidden:          codes.PermissionDenied,
+	http.StatusNotFound:           codes.NotFound,
+	http.StatusTooManyRequests:    codes.ResourceExhausted,
+	http.StatusGatewayTimeout:     codes.DeadlineExceeded,
+	http.StatusServiceUnavailable: codes.Unavailable,
+}
+
+// statusError maps errors the same way the REST error middleware does,
+// validation failures carry their fields as BadRequest details.
+func statusError(ctx context.Context, err error) error {
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	problem := problems.From(err).Problem
+	code, ok := codesByStatus[problem.Status]
+	if !ok {
+		// the cause is never sent to the client
+		requestid.Printf(ctx, "grpc: %v", err)
+		code = codes.Internal
+	}
+	message := problem.Title
+	if problem.Detail != "" {
+		message = problem.Detail
+	}
+	st := status.New(code, message)
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return st.Err()
+	}
+	fields, _ := validation.FieldErrors(validationErrors, acceptLanguage(ctx))
+	details := &errdetails.BadRequest{}
+	for _, field := range fields {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field.Field,
+			Description: field.Message,
+		})
+	}
+	if withDetails, err := st.WithDetails(details); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// acceptLanguage reads the language of validation messages from the request metadata
+func acceptLanguage(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("accept-language"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+	"go-test/auth"
+	"go-test/middleware"
+	"go-test/quotas"
+	"go-test/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Metering - budgets and quotas calls are charged to, the same ones as the
+// requests of the REST API. Either part is off while nil.
+type Metering struct {
+	RateLimiter *ratelimit.Limiter
+	RateLimits  ratelimit.Rules
+	Quotas      *quotas.Quotas
+}
+
+// charge takes the call from the rate limit budget of its client, and then
+// from the quotas of its API key or tenant. Rules of methods are keyed like
+// routes, "POST /animals.v1.AnimalService/List". The outcome is sent as
+// header metadata in the lower-case form of the REST headers.
+func (m Metering) charge(ctx context.Context, fullMethod string) error {
+	if m.RateLimiter != nil && m.RateLimits.Default.Rate > 0 {
+		principal, authenticated := auth.FromContext(ctx)
+		header, err := middleware.ChargeRateLimit(ctx, m.RateLimiter, m.RateLimits, http.MethodPost, fullMethod, ratelimit.Client(principal, authenticated, peerAddress(ctx)))
+		if err := sendHeader(ctx, header, err); err != nil {
+			return err
+		}
+	}
+	if m.Quotas != nil {
+		header, err := middleware.ChargeQuota(ctx, m.Quotas)
+		if err := sendHeader(ctx, header, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendHeader sets the headers as metadata of the call, refused calls have no
+// other response, so they get them as trailer too
+func sendHeader(ctx context.Context, header http.Header, err error) error {
+	md := metadata.MD{}
+	for name, values := range header {
+		md.Append(strings.ToLower(name), values...)
+	}
+	// fails only for calls whose header is already sent
+	grpc.SetHeader(ctx, md)
+	if err != nil {
+		grpc.SetTrailer(ctx, md)
+		return statusError(ctx, err)
+	}
+	return nil
+}
+
+// peerAddress - address of the client, the budget of anonymous calls
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+func unaryMeter(m Metering) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if public(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		if err := m.charge(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamMeter charges streams once when they open, however long they run
+func streamMeter(m Metering) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if public(info.FullMethod) {
+			return handler(srv, stream)
+		}
+		if err := m.charge(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
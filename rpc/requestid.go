@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"context"
+	"go-test/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+)
+
+// withRequestID returns the context carrying the x-request-id of the call,
+// generated when missing or malformed, and sends it back as header
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var id string
+	if values := md.Get(requestid.Header); len(values) > 0 && requestid.Valid(values[0]) {
+		id = values[0]
+	} else {
+		id = requestid.New()
+	}
+	// fails only for calls whose header is already sent
+	grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(requestid.Header), id))
+	return requestid.WithID(ctx, id)
+}
+
+func unaryRequestID(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+func streamRequestID(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: withRequestID(stream.Context())})
+}
@@ -0,0 +1,185 @@
+// Package rpc serves the animal records over gRPC, with the store,
+// validation and error mapping of the REST API.
+package rpc
+
+import (
+	"context"
+	"github.com/gin-gonic/gin/binding"
+	"go-test/animals"
+	"go-test/auth"
+	"go-test/models"
+	"go-test/problems"
+	"go-test/proto/animalpb"
+	"go-test/tenants"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// watchBuffer - events kept for a slow Watch client before it misses some.
+const watchBuffer = 64
+
+// AnimalServer - implementation of animalpb.AnimalServiceServer.
+type AnimalServer struct {
+	animalpb.UnimplementedAnimalServiceServer
+	Store *animals.Store
+}
+
+// NewServer creates a gRPC server with the animal service, health checking and reflection.
+// The animal service needs an API key or a bearer token granting the
+// permission of the method in policy when keys or verifier are set, like the
+// REST API. Calls are served for the tenant of the credential or of the
+// x-tenant-id metadata, correlated by the x-request-id metadata and charged
+// to the budgets and quotas of metering.
+func NewServer(store *animals.Store, verifier *auth.Verifier, keys *auth.APIKeys, policy *auth.Policy, metering Metering) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryRequestID, unaryAuthenticator(verifier, keys, policy), unaryMeter(metering)),
+		grpc.ChainStreamInterceptor(streamRequestID, streamAuthenticator(verifier, keys, policy), streamMeter(metering)),
+	)
+	animalpb.RegisterAnimalServiceServer(s, &AnimalServer{Store: store})
+
+	// the process exits when the database is lost, so it serves while running
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(animalpb.AnimalService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	reflection.Register(s)
+	return s
+}
+
+func (s *AnimalServer) Get(ctx context.Context, req *animalpb.GetAnimalRequest) (*animalpb.AnimalWithID, error) {
+	id, err := recordID(req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	animal, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return animalpb.NewAnimalWithID(animal), nil
+}
+
+func (s *AnimalServer) List(req *animalpb.ListAnimalsRequest, stream animalpb.AnimalService_ListServer) error {
+	ctx := stream.Context()
+	err := s.Store.Each(ctx, func(animal models.AnimalWithID) error {
+		return stream.Send(animalpb.NewAnimalWithID(animal))
+	})
+	if err != nil {
+		return statusError(ctx, err)
+	}
+	return nil
+}
+
+func (s *AnimalServer) Create(ctx context.Context, req *animalpb.CreateAnimalRequest) (*animalpb.AnimalWithID, error) {
+	input, err := animalInput(req.GetAnimal())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	animal, err := s.Store.Create(ctx, input)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return animalpb.NewAnimalWithID(animal), nil
+}
+
+func (s *AnimalServer) Replace(ctx context.Context, req *animalpb.ReplaceAnimalRequest) (*animalpb.AnimalWithID, error) {
+	id, err := recordID(req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	input, err := animalInput(req.GetAnimal())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	animal, err := s.Store.Replace(ctx, id, input)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return animalpb.NewAnimalWithID(animal), nil
+}
+
+func (s *AnimalServer) Delete(ctx context.Context, req *animalpb.DeleteAnimalRequest) (*animalpb.AnimalWithID, error) {
+	id, err := recordID(req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	animal, err := s.Store.Delete(ctx, id)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return animalpb.NewAnimalWithID(animal), nil
+}
+
+func (s *AnimalServer) UpdateDescription(ctx context.Context, req *animalpb.UpdateDescriptionRequest) (*animalpb.AnimalWithID, error) {
+	id, err := recordID(req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	input := models.AnimalDescription{Description: req.GetDescription()}
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	animal, err := s.Store.UpdateDescription(ctx, id, input.Description)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return animalpb.NewAnimalWithID(animal), nil
+}
+
+// Watch streams the changes of the tenant made through any API until the client leaves.
+func (s *AnimalServer) Watch(req *animalpb.WatchAnimalsRequest, stream animalpb.AnimalService_WatchServer) error {
+	if s.Store.Events == nil {
+		return status.Error(codes.Unimplemented, "change events are not enabled")
+	}
+	events, unsubscribe := s.Store.Events.Subscribe(watchBuffer)
+	defer unsubscribe()
+	// headers tell the client that no later change is missed
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	tenant := tenants.FromContext(stream.Context())
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-events:
+			if event.Tenant != tenant {
+				continue
+			}
+			err := stream.Send(&animalpb.AnimalEvent{
+				Type:   eventTypes[event.Type],
+				Animal: animalpb.NewAnimalWithID(event.Animal),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+var eventTypes = map[animals.EventType]animalpb.AnimalEvent_Type{
+	animals.Created:            animalpb.AnimalEvent_TYPE_CREATED,
+	animals.Replaced:           animalpb.AnimalEvent_TYPE_REPLACED,
+	animals.Deleted:            animalpb.AnimalEvent_TYPE_DELETED,
+	animals.DescriptionUpdated: animalpb.AnimalEvent_TYPE_DESCRIPTION_UPDATED,
+}
+
+func recordID(id int64) (uint, error) {
+	if id < 0 {
+		return 0, problems.BadRequest("ID must not be negative")
+	}
+	return uint(id), nil
+}
+
+// animalInput normalizes and validates the message like the REST bodies
+func animalInput(msg *animalpb.Animal) (models.Animal, error) {
+	input := msg.Model()
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		return input, err
+	}
+	return input, nil
+}
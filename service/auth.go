@@ -0,0 +1,78 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/auth"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/utils"
+	"log"
+	"time"
+)
+
+// newVerifier builds the bearer token verifier of the configured key sources,
+// nil when none is configured and requests stay anonymous.
+func newVerifier(config *utils.Config) *auth.Verifier {
+	var sources []auth.KeySource
+	if config.AuthJWTSecret != "" {
+		sources = append(sources, auth.HMACSecret(config.AuthJWTSecret))
+	}
+	if config.AuthJWKSFile != "" {
+		keys, err := auth.NewFileKeys(config.AuthJWKSFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sources = append(sources, keys)
+	}
+	if config.AuthJWKSURL != "" {
+		ttl := time.Duration(config.AuthJWKSTTL) * time.Second
+		if ttl <= 0 {
+			ttl = 1 * time.Hour
+		}
+		sources = append(sources, auth.NewRemoteKeys(config.AuthJWKSURL, ttl))
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	return &auth.Verifier{
+		Keys:     auth.Sources(sources...),
+		Issuer:   config.AuthIssuer,
+		Audience: config.AuthAudience,
+		Leeway:   30 * time.Second,
+	}
+}
+
+// newAPIKeys builds the API key validation, nil when API keys are not accepted
+func newAPIKeys(config *utils.Config, rp *repository.APIKeyRepository) *auth.APIKeys {
+	if !config.AuthAPIKeys {
+		return nil
+	}
+	return &auth.APIKeys{Repository: rp, TouchInterval: 1 * time.Minute}
+}
+
+// newPolicy loads the permissions of roles and scopes
+func newPolicy(config *utils.Config) *auth.Policy {
+	if config.RBACPolicyFile == "" {
+		return &auth.DefaultPolicy
+	}
+	policy, err := auth.LoadPolicy(config.RBACPolicyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return &policy
+}
+
+// require returns the middleware of a route needing the permissions
+func (service *Service) require(permissions ...string) gin.HandlerFunc {
+	return middleware.Require(service.Policy, permissions...)
+}
+
+// authenticate returns the middleware of protected routes, which resolves the
+// tenant after authenticating the request when authentication is on and then
+// charges the request to the budget of its client
+func (service *Service) authenticate() []gin.HandlerFunc {
+	if service.Verifier == nil && service.APIKeys == nil {
+		return []gin.HandlerFunc{middleware.Tenant(), service.limit()}
+	}
+	return []gin.HandlerFunc{service.limitAuthentication(middleware.Authenticate(service.Verifier, service.APIKeys)), middleware.Tenant(), service.limit()}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/gql"
+	"go-test/models"
+	"go-test/openapi"
+	"go-test/routers"
+	"net/http"
+	"strings"
+)
+
+// APIInfo - info section of the OpenAPI document.
+var APIInfo = openapi.Info{
+	Title:       "Animals API",
+	Version:     "1.0.0",
+	Description: "Animal records with export, import and background jobs.",
+}
+
+var (
+	fieldsParameter  = openapi.Query("fields", "comma separated fields to read, of id, name, type and description", "string")
+	includeParameter = openapi.Query("include", "comma separated resources to embed, of type", "string")
+)
+
+var allowHeader = map[string]openapi.Header{"Allow": openapi.HeaderSchema("Methods routed for the URL", "string")}
+
+var quotaUsage = openapi.Reply{Description: "Quotas and requests of the current day and month", Body: models.Usage{}}
+
+var jobStatus = openapi.Reply{Description: "Job status", Body: models.Job{}}
+
+// RouteDocs - documentation of every route registered by RegisterRoutes.
+var RouteDocs = routeDocs()
+
+// unversioned routes
+var generalDocs = map[string]openapi.Route{
+	openapi.Key(http.MethodOptions, "/*path"): {
+		Summary:     "Allowed methods of any URL",
+		Description: "Unknown URLs are answered with 404. With Accept: application/json the documented operations of the URL are returned.",
+		Tags:        []string{"general"},
+		Responses: map[int]openapi.Reply{
+			http.StatusNoContent: {Description: "Allowed methods", Headers: allowHeader},
+			http.StatusOK:        {Description: "Allowed methods and their operations", Body: &openapi.Schema{Type: "object"}, Headers: allowHeader},
+		},
+	},
+	openapi.Key(http.MethodPost, "/batch"): {
+		Summary:     "Execute several operations",
+		Description: "Operations run in order through the API with the headers of the batch. With transactional=true only animal record operations are accepted, and the first failure rolls all of them back.",
+		Tags:        []string{"general"},
+		Parameters:  []openapi.Parameter{openapi.Query("transactional", "all or nothing when true", "boolean")},
+		Body:        []models.BatchOperation{},
+		Responses:   map[int]openapi.Reply{http.StatusOK: {Description: "Result of every operation", Body: models.BatchResponse{}}},
+	},
+	openapi.Key(http.MethodPost, "/graphql"): {
+		Summary:     "Execute a GraphQL query",
+		Description: "Errors of the query are reported in the result with status 200.",
+		Tags:        []string{"graphql"},
+		Body:        gql.Request{},
+		Responses:   map[int]openapi.Reply{http.StatusOK: {Description: "GraphQL result", Body: &openapi.Schema{Type: "object"}}},
+	},
+	openapi.Key(http.MethodGet, "/graphql"): {
+		Summary:   "GraphiQL IDE, debug mode only",
+		Tags:      []string{"graphql"},
+		Responses: map[int]openapi.Reply{http.StatusOK: {Description: "HTML page", Body: &openapi.Schema{Type: "string"}, Types: []string{"text/html"}}},
+	},
+	openapi.Key(http.MethodPost, "/admin/api-keys"): {
+		Summary:     "Create an API key",
+		Description: "The key is only returned in this response, send it as X-API-Key. Scopes are read, write, admin and tunnel, and may grant no permission the caller lacks.",
+		Tags:        []string{"admin"},
+		Body:        models.APIKeyInput{},
+		Responses:   map[int]openapi.Reply{http.StatusCreated: {Description: "Created key", Body: models.APIKey{}}},
+	},
+	openapi.Key(http.MethodGet, "/admin/api-keys"): {
+		Summary:   "List API keys",
+		Tags:      []string{"admin"},
+		Responses: map[int]openapi.Reply{http.StatusOK: {Description: "Keys without their secret", Body: []models.APIKey{}}},
+	},
+	openapi.Key(http.MethodPost, "/admin/api-keys/:id/rotate"): {
+		Summary:     "Rotate an API key",
+		Description: "Replaces the secret of the key, the old secret stops working at once. Keys granting a permission the caller lacks are not rotated.",
+		Tags:        []string{"admin"},
+		Responses:   map[int]openapi.Reply{http.StatusOK: {Description: "Key with its new secret", Body: models.APIKey{}}},
+	},
+	openapi.Key(http.MethodDelete, "/admin/api-keys/:id"): {
+		Summary:   "Revoke an API key",
+		Tags:      []string{"admin"},
+		Responses: map[int]openapi.Reply{http.StatusOK: {Description: "Revoked key", Body: models.APIKey{}}},
+	},
+	openapi.Key(http.MethodGet, "/me/usage"): {
+		Summary:     "Get the quota usage of the client",
+		Description: "Quotas are counted per API key, and per tenant for users. Responses of other routes carry Quota-Warning headers once a quota is nearly spent, and fail with 429 once it is spent.",
+		Tags:        []string{"quotas"},
+		Responses:   map[int]openapi.Reply{http.StatusOK: quotaUsage},
+	},
+	openapi.Key(http.MethodGet, "/admin/quotas/:subject"): {
+		Summary:   "Get the quota usage of an API key or tenant",
+		Tags:      []string{"quotas"},
+		Responses: map[int]openapi.Reply{http.StatusOK: quotaUsage},
+	},
+	openapi.Key(http.MethodPut, "/admin/quotas/:subject"): {
+		Summary:     "Override the quotas of an API key or tenant",
+		Description: "Subjects are api-key:<id> or tenant:<id>. Missing limits are those of the plan.",
+		Tags:        []string{"quotas"},
+		Body:        models.QuotaInput{},
+		Responses:   map[int]openapi.Reply{http.StatusOK: quotaUsage},
+	},
+	openapi.Key(http.MethodDelete, "/admin/quotas/:subject"): {
+		Summary:   "Put an API key or tenant back on the default plan",
+		Tags:      []string{"quotas"},
+		Responses: map[int]openapi.Reply{http.StatusOK: quotaUsage},
+	},
+	openapi.Key(http.MethodGet, "/openapi.json"): {
+		Summary:   "OpenAPI document of this API",
+		Tags:      []string{"general"},
+		Responses: map[int]openapi.Reply{http.StatusOK: {Description: "OpenAPI 3.1 document", Body: &openapi.Schema{Type: "object"}}},
+	},
+	openapi.Key(http.MethodGet, "/docs"): {
+		Summary:   "Documentation UI",
+		Tags:      []string{"general"},
+		Responses: map[int]openapi.Reply{http.StatusOK: {Description: "HTML page", Body: &openapi.Schema{Type: "string"}, Types: []string{"text/html"}}},
+	},
+}
+
+// routeDocs documents the API of every version, unversioned paths serve v1 unless
+// the Accept header selects a version
+func routeDocs() map[string]openapi.Route {
+	docs := map[string]openapi.Route{}
+	for key, route := range generalDocs {
+		docs[key] = route
+	}
+	v1 := apiDocs(models.AnimalWithID{}, []models.AnimalWithID{})
+	v2 := apiDocs(models.AnimalV2{}, []models.AnimalV2{})
+	for key, route := range v1 {
+		method, path, _ := strings.Cut(key, " ")
+		route.Description = strings.TrimSpace(route.Description + " Version 2 is selected by Accept: " + routers.MIMEAnimalsV2 + ".")
+		docs[key] = route
+		route.Description = v1[key].Description
+		route.Deprecated = true
+		docs[openapi.Key(method, "/v1"+path)] = route
+	}
+	for key, route := range v2 {
+		method, path, _ := strings.Cut(key, " ")
+		docs[openapi.Key(method, "/v2"+path)] = route
+	}
+	return docs
+}
+
+// apiDocs documents the versioned routes with the record representation of the version
+func apiDocs(animal, list interface{}) map[string]openapi.Route {
+	record := openapi.Reply{Description: "Animal record", Body: animal, Types: routers.OfferedFormats}
+	return map[string]openapi.Route{
+		openapi.Key(http.MethodGet, "/animals"): {
+			Summary:     "List animals",
+			Description: "With Accept: " + routers.MIMEHAL + " records carry _links and lists pagination links.",
+			Tags:        []string{"animals"},
+			Parameters: []openapi.Parameter{
+				fieldsParameter,
+				includeParameter,
+				openapi.Query("page", "page number, 1 by default", "integer"),
+				openapi.Query("per_page", "records per page, 1 to 500, all records when missing", "integer"),
+			},
+			Responses: map[int]openapi.Reply{http.StatusOK: {Description: "Active animals", Body: list, Types: routers.OfferedFormats}},
+		},
+		openapi.Key(http.MethodHead, "/animals"): {
+			Summary: "Count animals",
+			Tags:    []string{"animals"},
+			Responses: map[int]openapi.Reply{http.StatusOK: {
+				Description: "Number of active animals in a header",
+				Headers:     map[string]openapi.Header{"X-Item-Length": openapi.HeaderSchema("Number of active animals", "integer")},
+			}},
+		},
+		openapi.Key(http.MethodGet, "/animals/:id"): {
+			Summary:     "Get an animal",
+			Description: "Records are cached for an hour.",
+			Parameters:  []openapi.Parameter{fieldsParameter, includeParameter},
+			Tags:        []string{"animals"},
+			Responses:   map[int]openapi.Reply{http.StatusOK: record},
+		},
+		openapi.Key(http.MethodPost, "/animals"): {
+			Summary:   "Create an animal",
+			Tags:      []string{"animals"},
+			Body:      models.Animal{},
+			BodyTypes: routers.OfferedFormats,
+			Responses: map[int]openapi.Reply{http.StatusOK: record},
+		},
+		openapi.Key(http.MethodPut, "/animals/:id"): {
+			Summary:   "Replace an animal",
+			Tags:      []string{"animals"},
+			Body:      models.Animal{},
+			BodyTypes: routers.OfferedFormats,
+			Responses: map[int]openapi.Reply{http.StatusOK: record},
+		},
+		openapi.Key(http.MethodDelete, "/animals/:id"): {
+			Summary:   "Delete an animal",
+			Tags:      []string{"animals"},
+			Responses: map[int]openapi.Reply{http.StatusOK: record},
+		},
+		openapi.Key(http.MethodPatch, "/animals/:id/description"): {
+			Summary:   "Change the description of an animal",
+			Tags:      []string{"animals"},
+			Body:      models.AnimalDescription{},
+			BodyTypes: routers.OfferedFormats,
+			Responses: map[int]openapi.Reply{http.StatusOK: record},
+		},
+		openapi.Key(http.MethodGet, "/animals/:id/shares"): {
+			Summary:   "List the shares of an animal",
+			Tags:      []string{"animals"},
+			Responses: map[int]openapi.Reply{http.StatusOK: {Description: "Users and groups allowed to change the record", Body: []models.Share{}}},
+		},
+		openapi.Key(http.MethodPost, "/animals/:id/shares"): {
+			Summary:     "Share an animal",
+			Description: "Editors change only the records they created and those shared with them or their groups. Only the owner shares a record.",
+			Tags:        []string{"animals"},
+			Body:        models.ShareInput{},
+			Responses:   map[int]openapi.Reply{http.StatusCreated: {Description: "Share, the existing one when shared before", Body: models.Share{}}},
+		},
+		openapi.Key(http.MethodDelete, "/animals/:id/shares/:shareId"): {
+			Summary:   "Remove a share of an animal",
+			Tags:      []string{"animals"},
+			Responses: map[int]openapi.Reply{http.StatusOK: {Description: "Removed share", Body: models.Share{}}},
+		},
+		openapi.Key(http.MethodGet, "/animals/export"): {
+			Summary:    "Export animals",
+			Tags:       []string{"animals"},
+			Parameters: []openapi.Parameter{openapi.Query("format", "csv (default) or ndjson", "string")},
+			Responses: map[int]openapi.Reply{http.StatusOK: {
+				Description: "Streamed active animals, one per line",
+				Body:        &openapi.Schema{Type: "string"},
+				Types:       []string{"text/csv", "application/x-ndjson"},
+				Headers:     map[string]openapi.Header{"Content-Disposition": openapi.HeaderSchema("Attachment file name", "string")},
+			}},
+		},
+		openapi.Key(http.MethodPost, "/animals/import"): {
+			Summary:     "Import animals",
+			Description: "Large datasets are imported by a background job, bodies over IMPORT_MAX_BYTES are rejected with 413.",
+			Tags:        []string{"animals"},
+			Parameters: []openapi.Parameter{
+				openapi.Query("format", "csv or ndjson, taken from Content-Type when missing", "string"),
+				openapi.Query("dry_run", "validate only when true", "boolean"),
+				openapi.Query("mode", "insert (default) or upsert", "string"),
+				openapi.Query("key", "upsert key, id (default) or external_id", "string"),
+				openapi.Query("map", "column mapping as source:field pairs", "string"),
+			},
+			Body:      &openapi.Schema{Type: "string"},
+			BodyTypes: []string{"text/csv", "application/x-ndjson"},
+			Responses: map[int]openapi.Reply{
+				http.StatusOK: {Description: "Import report", Body: models.ImportReport{}},
+				http.StatusAccepted: {
+					Description: "Background import job",
+					Body:        models.Job{},
+					Headers:     map[string]openapi.Header{"Location": openapi.HeaderSchema("Status URL of the job", "string")},
+				},
+			},
+		},
+		openapi.Key(http.MethodGet, "/jobs"): {
+			Summary: "List background jobs",
+			Tags:    []string{"jobs"},
+			Parameters: []openapi.Parameter{
+				openapi.Query("kind", "job kind", "string"),
+				openapi.Query("status", "job status", "string"),
+				openapi.Query("limit", "1 to 500, 50 by default", "integer"),
+			},
+			Responses: map[int]openapi.Reply{http.StatusOK: {Description: "Latest jobs", Body: []models.Job{}}},
+		},
+		openapi.Key(http.MethodGet, "/jobs/:id"): {
+			Summary:   "Get a background job",
+			Tags:      []string{"jobs"},
+			Responses: map[int]openapi.Reply{http.StatusOK: jobStatus},
+		},
+		openapi.Key(http.MethodPost, "/jobs/:id/cancel"): {
+			Summary:   "Cancel a background job",
+			Tags:      []string{"jobs"},
+			Responses: map[int]openapi.Reply{http.StatusAccepted: jobStatus},
+		},
+	}
+}
+
+// OpenAPIDocument describes the routes registered on the engine.
+func OpenAPIDocument(r *gin.Engine) (*openapi.Document, error) {
+	return openapi.Build(APIInfo, r.Routes(), RouteDocs)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/quotas"
+	"go-test/routers"
+	"go-test/utils"
+	"log"
+)
+
+// newQuotas reads the plans of API keys and tenants, nil when none is configured
+func newQuotas(config *utils.Config, rp *repository.QuotaRepository) *quotas.Quotas {
+	if len(config.QuotaPlans) == 0 {
+		return nil
+	}
+	plans := map[string]quotas.Plan{}
+	for name, plan := range config.QuotaPlans {
+		plans[name] = quotas.Plan{Daily: plan.Daily, Monthly: plan.Monthly}
+	}
+	if _, ok := plans[config.QuotaDefaultPlan]; !ok {
+		log.Fatalf("QUOTA_DEFAULT_PLAN %q is not one of QUOTA_PLANS", config.QuotaDefaultPlan)
+	}
+	return &quotas.Quotas{Repository: rp, Plans: plans, DefaultPlan: config.QuotaDefaultPlan, WarnAt: float64(config.QuotaWarnPercent) / 100}
+}
+
+// quota returns the middleware counting requests against the quotas of their
+// client, which passes every request when quotas are off
+func (service *Service) quota() gin.HandlerFunc {
+	if service.Quotas == nil {
+		return func(c *gin.Context) {}
+	}
+	return middleware.Quota(service.Quotas)
+}
+
+func (service *Service) GetUsage(c *gin.Context) {
+	routers.GetUsage(c, service.Quotas)
+}
+
+func (service *Service) GetQuota(c *gin.Context) {
+	routers.GetQuota(c, service.Quotas)
+}
+
+func (service *Service) OverrideQuota(c *gin.Context) {
+	routers.OverrideQuota(c, service.Quotas)
+}
+
+func (service *Service) ResetQuota(c *gin.Context) {
+	routers.ResetQuota(c, service.Quotas)
+}
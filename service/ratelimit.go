@@ -0,0 +1,46 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/middleware"
+	"go-test/ratelimit"
+	"go-test/utils"
+)
+
+// newRateLimits reads the default budget of clients and the rules of routes
+func newRateLimits(config *utils.Config) ratelimit.Rules {
+	rules := ratelimit.Rules{
+		Default: ratelimit.PerMinute(config.RequestsPerMinute),
+		Routes:  map[string]ratelimit.Rule{},
+	}
+	rules.Default.Burst = config.RateLimitBurst
+	for route, limit := range config.RateLimitRoutes {
+		rule := ratelimit.Rule{Cost: limit.Cost}
+		if limit.RequestsPerMinute > 0 {
+			own := ratelimit.PerMinute(limit.RequestsPerMinute)
+			own.Burst = limit.Burst
+			rule.Limit = &own
+		}
+		rules.Routes[route] = rule
+	}
+	return rules
+}
+
+// limit returns the middleware charging requests to the budget of their client,
+// which passes every request when rate limiting is off
+func (service *Service) limit() gin.HandlerFunc {
+	if service.RateLimiter == nil || service.RateLimits.Default.Rate <= 0 {
+		return func(c *gin.Context) {}
+	}
+	return middleware.RateLimit(service.RateLimiter, service.RateLimits)
+}
+
+// limitAuthentication charges requests failing authenticate to the budget of
+// their address, and refuses addresses whose budget is spent before checking
+// their credentials
+func (service *Service) limitAuthentication(authenticate gin.HandlerFunc) gin.HandlerFunc {
+	if service.RateLimiter == nil || service.RateLimits.Default.Rate <= 0 {
+		return authenticate
+	}
+	return middleware.RateLimitAuthentication(service.RateLimiter, service.RateLimits, authenticate)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/auth"
+	"go-test/gql"
+	"go-test/openapi"
+	"go-test/routers"
+	"log"
+	"sync"
+	"time"
+)
+
+// RegisterRoutes connects the handlers of the service to the engine.
+// Every route needs an entry in RouteDocs.
+func (service *Service) RegisterRoutes(r *gin.Engine) {
+	// API description, built once all routes are registered
+	document := sync.OnceValues(func() (*openapi.Document, error) {
+		return OpenAPIDocument(r)
+	})
+	// public routes are limited per address
+	limit := service.limit()
+	r.OPTIONS("/*path", limit, routers.OptionsHandler(r, document)) // allowed methods of all URLs
+
+	// API keys or bearer tokens are required once either is configured,
+	// preflight requests and the API description stay public. Records of
+	// protected routes belong to the tenant of the request, whose requests
+	// count against the quota of its API key or tenant.
+	protected := r.Group("", append(service.authenticate(), service.quota())...)
+
+	// today's API is frozen as v1, unversioned paths select the version by Accept header
+	deprecations := service.deprecations()
+	service.registerAPI(protected.Group("/v1", routers.Version(1, deprecations)))
+	service.registerAPI(protected.Group("/v2", routers.Version(2, deprecations)))
+	service.registerAPI(protected.Group("", routers.Version(0, deprecations)))
+
+	// several operations in one request, optionally in one transaction,
+	// each of them authenticated with the headers of the batch
+	protected.POST("/batch", routers.BatchHandler(r, service.Animals))
+
+	// animals over GraphQL, GraphiQL in debug mode
+	resolver := &gql.Resolver{Store: service.Animals, Policy: service.Policy}
+	schema, err := gql.NewSchema(resolver)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// mutations check their own permission
+	protected.POST("/graphql", service.require(auth.PermRead), routers.GraphQLHandler(schema, resolver, gql.Limits{
+		MaxDepth:      service.Config.GraphQLMaxDepth,
+		MaxComplexity: service.Config.GraphQLMaxComplexity,
+	}))
+	r.GET("/graphql", limit, routers.GraphiQLHandler)
+
+	// credentials of machine clients
+	admin := protected.Group("/admin", service.require(auth.PermAdmin))
+	admin.POST("/api-keys", service.CreateAPIKey)
+	admin.GET("/api-keys", service.GetAPIKeys)
+	admin.POST("/api-keys/:id/rotate", service.RotateAPIKey)
+	admin.DELETE("/api-keys/:id", service.RevokeAPIKey)
+
+	// usage and quotas stay readable once a quota is spent
+	account := r.Group("", service.authenticate()...)
+	account.GET("/me/usage", service.GetUsage)
+	operators := account.Group("/admin/quotas", service.require(auth.PermQuotas))
+	operators.GET("/:subject", service.GetQuota)
+	operators.PUT("/:subject", service.OverrideQuota)
+	operators.DELETE("/:subject", service.ResetQuota)
+
+	r.GET("/openapi.json", limit, routers.OpenAPIHandler(document))
+	r.GET("/docs", limit, routers.DocsHandler)
+}
+
+// registerAPI connects the versioned routes to the group, each with the permission it needs
+func (service *Service) registerAPI(api *gin.RouterGroup) {
+	// animal records in JSON, XML, YAML, MessagePack or Protobuf
+	animals := api.Group("/animals", routers.Negotiate())
+	animals.GET("", service.require(auth.PermRead), service.GetAnimal)
+	animals.HEAD("", service.require(auth.PermRead), service.GetAnimalCount)
+	animals.GET("/:id", service.require(auth.PermRead), service.GetAnimalById)
+	animals.POST("", service.require(auth.PermCreate), service.CreateAnimal)
+	animals.PUT("/:id", service.require(auth.PermReplace), service.ReplaceAnimal)
+	animals.DELETE("/:id", service.require(auth.PermDelete), service.DeleteAnimal)
+	animals.PATCH("/:id/description", service.require(auth.PermReplace), service.UpdateAnimalDescription) // change only description field
+
+	// users and groups allowed to change a record besides its owner
+	api.GET("/animals/:id/shares", service.require(auth.PermRead), service.GetAnimalShares)
+	api.POST("/animals/:id/shares", service.require(auth.PermReplace), service.ShareAnimal)
+	api.DELETE("/animals/:id/shares/:shareId", service.require(auth.PermReplace), service.UnshareAnimal)
+
+	api.GET("/animals/export", service.require(auth.PermRead), service.ExportAnimals)                      // stream as csv or ndjson
+	api.POST("/animals/import", service.require(auth.PermCreate, auth.PermReplace), service.ImportAnimals) // csv or ndjson, supports dry_run
+
+	api.GET("/jobs", service.require(auth.PermRead), service.GetJobs)
+	api.GET("/jobs/:id", service.require(auth.PermRead), service.GetJob)
+	api.POST("/jobs/:id/cancel", service.require(auth.PermCreate), service.CancelJob)
+}
+
+// deprecations reads the deprecation schedule of old versions from the configuration
+func (service *Service) deprecations() map[int]routers.Deprecation {
+	var v1 routers.Deprecation
+	if service.Config.V1Deprecated != "" {
+		since, err := time.Parse(time.RFC3339, service.Config.V1Deprecated)
+		if err != nil {
+			log.Printf("invalid V1_DEPRECATED: %v", err)
+		}
+		v1.Since = since
+	}
+	if service.Config.V1Sunset != "" {
+		sunset, err := time.Parse(time.RFC3339, service.Config.V1Sunset)
+		if err != nil {
+			log.Printf("invalid V1_SUNSET: %v", err)
+		}
+		v1.Sunset = sunset
+	}
+	if v1.Since.IsZero() && v1.Sunset.IsZero() {
+		return nil
+	}
+	return map[int]routers.Deprecation{1: v1}
+}
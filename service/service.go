@@ -3,20 +3,39 @@ package service
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"go-test/animals"
+	"go-test/auth"
 	dbutils "go-test/db-utils"
 	"go-test/db-utils/repository"
+	"go-test/jobs"
+	"go-test/quotas"
+	"go-test/ratelimit"
 	"go-test/routers"
+	"go-test/tunnel"
 	"go-test/utils"
 	"gorm.io/gorm"
 	"sync"
+	"time"
 )
 
 type Service struct {
-	Config         *utils.Config
-	Repository     *repository.AnimalRepository
-	PostgresClient *gorm.DB
-	RedisClient    *redis.Client
-	Mutex          *sync.Mutex
+	Config           *utils.Config
+	Repository       *repository.AnimalRepository
+	PostgresClient   *gorm.DB
+	RedisClient      *redis.Client
+	Mutex            *sync.Mutex
+	JobRepository    *repository.JobRepository
+	Jobs             *jobs.Runner
+	Animals          *animals.Store
+	Verifier         *auth.Verifier
+	APIKeyRepository *repository.APIKeyRepository
+	APIKeys          *auth.APIKeys
+	Policy           *auth.Policy
+	Tunnels          *tunnel.Proxy
+	RateLimiter      *ratelimit.Limiter
+	RateLimits       ratelimit.Rules
+	QuotaRepository  *repository.QuotaRepository
+	Quotas           *quotas.Quotas
 }
 
 func NewService(config *utils.Config) *Service {
@@ -25,15 +44,39 @@ func NewService(config *utils.Config) *Service {
 	// setup db connection
 	db := dbutils.Connect(config.DBUser, config.DBPassword, config.DBHost, config.DBName, config.DBSSLMode, config.DBPort)
 	// setup cache connection
-	rdb := dbutils.ConnectRedis(config.RedisAddress, config.RedisAddress, config.RedisDB)
+	rdb := dbutils.ConnectRedis(config.RedisAddress, config.RedisAddress, config.RedisDB, config.RedisLogCommands)
 	// setup repositories
 	animalRepository := repository.NewAnimalsRepositoryImpl(db)
+	jobRepository := repository.NewJobsRepositoryImpl(db)
+	apiKeyRepository := repository.NewAPIKeysRepositoryImpl(db)
+	quotaRepository := repository.NewQuotasRepositoryImpl(db)
+	// setup background jobs, started separately by Jobs.Run
+	runner := jobs.NewRunner(jobRepository, config.JobWorkers, time.Duration(config.JobPollInterval)*time.Second, jobs.RetryPolicy{
+		MaxAttempts: config.JobMaxAttempts,
+		Backoff:     time.Duration(config.JobRetryBackoff) * time.Second,
+	})
+	// records shared by the REST, GraphQL and gRPC APIs
+	policy := newPolicy(config)
+	store := &animals.Store{Mu: &mu, Repository: &animalRepository, Redis: rdb, Events: animals.NewBroadcaster(), Policy: policy}
+	runner.Register(routers.ImportJobKind, routers.ImportJobHandler(store))
 	return &Service{
-		Config:         config,
-		Repository:     &animalRepository,
-		RedisClient:    rdb,
-		PostgresClient: db,
-		Mutex:          &mu,
+		Config:           config,
+		Repository:       &animalRepository,
+		RedisClient:      rdb,
+		PostgresClient:   db,
+		Mutex:            &mu,
+		JobRepository:    &jobRepository,
+		Jobs:             runner,
+		Animals:          store,
+		Verifier:         newVerifier(config),
+		APIKeyRepository: &apiKeyRepository,
+		APIKeys:          newAPIKeys(config, &apiKeyRepository),
+		Policy:           policy,
+		Tunnels:          newProxy(config),
+		RateLimiter:      &ratelimit.Limiter{Redis: rdb, Prefix: "ratelimit:"},
+		RateLimits:       newRateLimits(config),
+		QuotaRepository:  &quotaRepository,
+		Quotas:           newQuotas(config, &quotaRepository),
 	}
 }
 
@@ -41,26 +84,74 @@ func (service *Service) GetAnimal(c *gin.Context) {
 	routers.GetAnimals(c, service.Mutex, service.Repository)
 }
 
+func (service *Service) ExportAnimals(c *gin.Context) {
+	routers.ExportAnimals(c, service.Repository)
+}
+
+func (service *Service) ImportAnimals(c *gin.Context) {
+	routers.ImportAnimals(c, service.Animals, service.Policy, service.Jobs, service.Config.ImportBackground, service.Config.ImportMaxBytes)
+}
+
 func (service *Service) GetAnimalCount(c *gin.Context) {
 	routers.GetAnimalCount(c, service.Mutex, service.Repository)
 }
 
 func (service *Service) GetAnimalById(c *gin.Context) {
-	routers.GetAnimalByID(c, service.Mutex, service.Repository, service.RedisClient)
+	routers.GetAnimalByID(c, service.Animals)
 }
 
 func (service *Service) CreateAnimal(c *gin.Context) {
-	routers.CreateAnimal(c, service.Mutex, service.Repository)
+	routers.CreateAnimal(c, service.Animals)
 }
 
 func (service *Service) ReplaceAnimal(c *gin.Context) {
-	routers.ReplaceAnimal(c, service.Mutex, service.Repository, service.RedisClient)
+	routers.ReplaceAnimal(c, service.Animals)
 }
 
 func (service *Service) DeleteAnimal(c *gin.Context) {
-	routers.DeleteAnimal(c, service.Mutex, service.Repository, service.RedisClient)
+	routers.DeleteAnimal(c, service.Animals)
 }
 
 func (service *Service) UpdateAnimalDescription(c *gin.Context) {
-	routers.UpdateAnimalDescription(c, service.Mutex, service.Repository, service.RedisClient)
+	routers.UpdateAnimalDescription(c, service.Animals)
+}
+
+func (service *Service) GetJobs(c *gin.Context) {
+	routers.GetJobs(c, service.JobRepository)
+}
+
+func (service *Service) GetJob(c *gin.Context) {
+	routers.GetJob(c, service.JobRepository)
+}
+
+func (service *Service) CancelJob(c *gin.Context) {
+	routers.CancelJob(c, service.Jobs)
+}
+
+func (service *Service) CreateAPIKey(c *gin.Context) {
+	routers.CreateAPIKey(c, service.APIKeyRepository, service.Policy)
+}
+
+func (service *Service) GetAPIKeys(c *gin.Context) {
+	routers.GetAPIKeys(c, service.APIKeyRepository)
+}
+
+func (service *Service) RotateAPIKey(c *gin.Context) {
+	routers.RotateAPIKey(c, service.APIKeyRepository, service.Policy)
+}
+
+func (service *Service) RevokeAPIKey(c *gin.Context) {
+	routers.RevokeAPIKey(c, service.APIKeyRepository, service.Policy)
+}
+
+func (service *Service) GetAnimalShares(c *gin.Context) {
+	routers.GetAnimalShares(c, service.Animals)
+}
+
+func (service *Service) ShareAnimal(c *gin.Context) {
+	routers.ShareAnimal(c, service.Animals)
+}
+
+func (service *Service) UnshareAnimal(c *gin.Context) {
+	routers.UnshareAnimal(c, service.Animals)
 }
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/problems"
+	"go-test/routers"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// tracePseudonym - name of the service in the Via header of TRACE replies
+const tracePseudonym = "animals-api"
+
+// Trace reflects TRACE requests of any URL when enabled, otherwise answers
+// 405 with the methods the engine routes for the URL in the Allow header.
+// Nothing else handles the request afterwards.
+func (service *Service) Trace(engine *gin.Engine) gin.HandlerFunc {
+	var once sync.Once
+	var routes gin.RoutesInfo
+	return func(c *gin.Context) {
+		defer c.Abort()
+		if !service.Config.TraceEnabled {
+			// routes are complete once the first request is served
+			once.Do(func() {
+				routes = engine.Routes()
+			})
+			allow := routers.AllowedMethods(routes, c.Request.URL.Path)
+			if len(allow) == 0 {
+				c.Error(problems.NotFound("No resource at " + c.Request.URL.Path))
+				return
+			}
+			c.Header("Allow", strings.Join(allow, ", "))
+			c.Error(problems.New(http.StatusMethodNotAllowed, problems.TypeBlank, "TRACE is disabled"))
+			return
+		}
+		if service.limit()(c); c.IsAborted() {
+			return
+		}
+		routers.TraceHandler(c, tracePseudonym)
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-test/middleware"
+	"go-test/problems"
+	"go-test/routers"
+	"go-test/tunnel"
+	"go-test/utils"
+	"log"
+	"net/http"
+	"time"
+)
+
+// newProxy builds the CONNECT proxy, nil unless tunnels are enabled. Tunnels
+// are never opened for anonymous clients.
+func newProxy(config *utils.Config) *tunnel.Proxy {
+	if !config.ConnectEnabled {
+		return nil
+	}
+	if config.AuthJWTSecret == "" && config.AuthJWKSFile == "" && config.AuthJWKSURL == "" && !config.AuthAPIKeys {
+		log.Fatal("CONNECT_ENABLED needs bearer tokens or API keys to authenticate clients")
+	}
+	proxy, err := tunnel.NewProxy(tunnel.Policy{
+		Allow:        config.ConnectAllow,
+		Deny:         config.ConnectDeny,
+		Ports:        config.ConnectPorts,
+		AllowPrivate: config.ConnectAllowPrivate,
+	}, tunnel.Limits{
+		DialTimeout:  time.Duration(config.ConnectDialTimeout) * time.Second,
+		IdleTimeout:  time.Duration(config.ConnectIdleTimeout) * time.Second,
+		MaxDuration:  time.Duration(config.ConnectMaxDuration) * time.Second,
+		MaxPerClient: config.ConnectMaxPerClient,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return proxy
+}
+
+// Connect tunnels CONNECT requests of clients with the tunnel permission,
+// nothing else handles the request afterwards
+func (service *Service) Connect(c *gin.Context) {
+	defer c.Abort()
+	if service.Tunnels == nil {
+		c.Error(problems.New(http.StatusMethodNotAllowed, problems.TypeBlank, "CONNECT tunnels are disabled"))
+		return
+	}
+	// tunnels are charged to the budget of the authenticated client, failed
+	// authentication to the one of the address
+	for _, handler := range []gin.HandlerFunc{service.limitAuthentication(middleware.ProxyAuthenticate(service.Verifier, service.APIKeys, service.Policy)), service.limit()} {
+		if handler(c); c.IsAborted() {
+			return
+		}
+	}
+	routers.ConnectHandler(c, service.Tunnels)
+}
@@ -0,0 +1,61 @@
+// Package tenants separates the records of the shelters sharing one
+// deployment. Every request runs for exactly one tenant, carried by its context.
+package tenants
+
+import (
+	"context"
+	"errors"
+	"go-test/auth"
+	"regexp"
+)
+
+// Default - tenant of anonymous requests and of credentials naming no tenant.
+const Default = "default"
+
+// Header - request header selecting the tenant, the gRPC metadata key is its lower-case form.
+const Header = "X-Tenant-ID"
+
+// ErrInvalidTenant - requested tenant id is malformed.
+var ErrInvalidTenant = errors.New("tenant id must be 1 to 63 lower-case letters, digits or dashes")
+
+// ErrForeignTenant - requested tenant differs from the one of the credential.
+var ErrForeignTenant = errors.New("tenant is not accessible with this credential")
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// key of the tenant in request contexts
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// FromContext returns the tenant of the context, empty when it has none.
+func FromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// Resolve returns the tenant of a request. Authenticated requests belong to
+// the tenant of their credential and may only repeat it in the header, the
+// header chooses freely when authentication is off.
+func Resolve(principal auth.Principal, authenticated bool, requested string) (string, error) {
+	if requested != "" && !validID.MatchString(requested) {
+		return "", ErrInvalidTenant
+	}
+	if authenticated {
+		tenant := principal.Tenant
+		if tenant == "" {
+			tenant = Default
+		}
+		if requested != "" && requested != tenant {
+			return "", ErrForeignTenant
+		}
+		return tenant, nil
+	}
+	if requested == "" {
+		return Default, nil
+	}
+	return requested, nil
+}
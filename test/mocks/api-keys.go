@@ -0,0 +1,105 @@
+package mocks
+
+import (
+	"context"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"sync"
+	"time"
+)
+
+// MockAPIKeyRepository - in-memory api key repository implementation
+type MockAPIKeyRepository struct {
+	mu   sync.Mutex
+	keys []models.APIKey
+}
+
+func (m *MockAPIKeyRepository) Create(key models.APIKey) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key.ID = uint(len(m.keys) + 1)
+	key.CreatedAt = time.Now()
+	key.UpdatedAt = key.CreatedAt
+	m.keys = append(m.keys, key)
+	return key, nil
+}
+
+func (m *MockAPIKeyRepository) FindAll() ([]models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.APIKey{}, m.keys...), nil
+}
+
+func (m *MockAPIKeyRepository) FindByID(id uint) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id == 0 || int(id) > len(m.keys) {
+		return models.APIKey{}, &repository.NotFoundError{Id: id, When: time.Now()}
+	}
+	return m.keys[id-1], nil
+}
+
+func (m *MockAPIKeyRepository) FindByPrefix(prefix string) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range m.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, repository.ErrUnknownAPIKey
+}
+
+func (m *MockAPIKeyRepository) Rotate(id uint, prefix, hash string) (models.APIKey, error) {
+	return m.update(id, func(key *models.APIKey) error {
+		if key.RevokedAt != nil {
+			return repository.ErrAPIKeyRevoked
+		}
+		key.Prefix = prefix
+		key.Hash = hash
+		return nil
+	})
+}
+
+func (m *MockAPIKeyRepository) Revoke(id uint) (models.APIKey, error) {
+	return m.update(id, func(key *models.APIKey) error {
+		if key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+		}
+		return nil
+	})
+}
+
+func (m *MockAPIKeyRepository) Touch(id uint, usedAt time.Time) error {
+	_, err := m.update(id, func(key *models.APIKey) error {
+		key.LastUsedAt = &usedAt
+		return nil
+	})
+	return err
+}
+
+func (m *MockAPIKeyRepository) update(id uint, fn func(key *models.APIKey) error) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id == 0 || int(id) > len(m.keys) {
+		return models.APIKey{}, &repository.NotFoundError{Id: id, When: time.Now()}
+	}
+	key := m.keys[id-1]
+	if err := fn(&key); err != nil {
+		return key, err
+	}
+	key.UpdatedAt = time.Now()
+	m.keys[id-1] = key
+	return key, nil
+}
+
+// ForTenant returns the mock itself, keys are not separated by tenant
+func (m *MockAPIKeyRepository) ForTenant(tenant string) repository.APIKeyRepository {
+	return m
+}
+
+// WithContext returns the mock itself, there are no statements to tag
+func (m *MockAPIKeyRepository) WithContext(ctx context.Context) repository.APIKeyRepository {
+	return m
+}
@@ -0,0 +1,141 @@
+package mocks
+
+import (
+	"context"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"sync"
+	"time"
+)
+
+// MockJobRepository - in-memory job repository implementation
+type MockJobRepository struct {
+	mu   sync.Mutex
+	jobs []models.Job
+}
+
+func (m *MockJobRepository) Create(job models.Job) (models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.ID = uint(len(m.jobs) + 1)
+	job.Status = models.JobQueued
+	job.CreatedAt = time.Now()
+	job.RunAt = job.CreatedAt
+	m.jobs = append(m.jobs, job)
+	return job, nil
+}
+
+func (m *MockJobRepository) FindByID(id uint) (models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id == 0 || int(id) > len(m.jobs) {
+		return models.Job{}, &repository.NotFoundError{Id: id, When: time.Now()}
+	}
+	return m.jobs[id-1], nil
+}
+
+func (m *MockJobRepository) FindAll(filter repository.JobFilter) ([]models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.Job{}, m.jobs...), nil
+}
+
+func (m *MockJobRepository) ClaimNext() (models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.jobs {
+		job := &m.jobs[i]
+		if job.Status == models.JobQueued && !job.RunAt.After(time.Now()) {
+			now := time.Now()
+			job.Status = models.JobRunning
+			job.Attempts++
+			job.StartedAt = &now
+			return *job, nil
+		}
+	}
+	return models.Job{}, repository.ErrNoJob
+}
+
+// leased reports whether the job still runs the attempt, as the lease of the
+// repository, must be called with the lock held
+func (m *MockJobRepository) leased(id uint, attempt int) bool {
+	job := m.jobs[id-1]
+	return job.Status == models.JobRunning && job.Attempts == attempt
+}
+
+func (m *MockJobRepository) UpdateProgress(id uint, attempt, progress int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.leased(id, attempt) {
+		return false, repository.ErrJobLost
+	}
+	m.jobs[id-1].Progress = progress
+	return m.jobs[id-1].CancelRequested, nil
+}
+
+func (m *MockJobRepository) SaveCheckpoint(id uint, attempt int, checkpoint string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.leased(id, attempt) {
+		return repository.ErrJobLost
+	}
+	m.jobs[id-1].Checkpoint = checkpoint
+	return nil
+}
+
+func (m *MockJobRepository) Finish(id uint, attempt int, status, result, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.leased(id, attempt) {
+		return repository.ErrJobLost
+	}
+	now := time.Now()
+	job := &m.jobs[id-1]
+	job.Status = status
+	job.Result = result
+	job.Error = message
+	job.FinishedAt = &now
+	return nil
+}
+
+func (m *MockJobRepository) Retry(id uint, attempt int, runAt time.Time, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.leased(id, attempt) {
+		return repository.ErrJobLost
+	}
+	job := &m.jobs[id-1]
+	job.Status = models.JobQueued
+	job.RunAt = runAt
+	job.Error = message
+	return nil
+}
+
+func (m *MockJobRepository) Cancel(id uint) (models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job := &m.jobs[id-1]
+	switch job.Status {
+	case models.JobQueued:
+		job.Status = models.JobCancelled
+	case models.JobRunning:
+		job.CancelRequested = true
+	default:
+		return *job, repository.ErrJobFinished
+	}
+	return *job, nil
+}
+
+func (m *MockJobRepository) RequeueStale(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// ForTenant returns the mock itself, jobs are not separated by tenant
+func (m *MockJobRepository) ForTenant(tenant string) repository.JobRepository {
+	return m
+}
+
+// WithContext returns the mock itself, there are no statements to tag
+func (m *MockJobRepository) WithContext(ctx context.Context) repository.JobRepository {
+	return m
+}
@@ -0,0 +1,77 @@
+package mocks
+
+import (
+	"context"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"sync"
+	"time"
+)
+
+// MockQuotaRepository - in-memory quota repository implementation
+type MockQuotaRepository struct {
+	mu     sync.Mutex
+	quotas map[string]models.Quota
+	counts map[string]int64
+	Finds  int
+}
+
+func (m *MockQuotaRepository) Find(subject string) (models.Quota, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Finds++
+	if quota, ok := m.quotas[subject]; ok {
+		return quota, nil
+	}
+	return models.Quota{Subject: subject}, nil
+}
+
+func (m *MockQuotaRepository) Save(quota models.Quota) (models.Quota, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.quotas == nil {
+		m.quotas = map[string]models.Quota{}
+	}
+	quota.UpdatedAt = time.Now()
+	m.quotas[quota.Subject] = quota
+	return quota, nil
+}
+
+func (m *MockQuotaRepository) Delete(subject string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.quotas, subject)
+	return nil
+}
+
+func (m *MockQuotaRepository) Consume(subject string, cost int64, day, month time.Time, dailyLimit, monthlyLimit int64) (int64, int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counts == nil {
+		m.counts = map[string]int64{}
+	}
+	dayKey, monthKey := usageKey(subject, models.PeriodDay, day), usageKey(subject, models.PeriodMonth, month)
+	// refused requests are not counted, as by the repository
+	if (dailyLimit > 0 && m.counts[dayKey]+cost > dailyLimit) || (monthlyLimit > 0 && m.counts[monthKey]+cost > monthlyLimit) {
+		return m.counts[dayKey], m.counts[monthKey], false, nil
+	}
+	m.counts[dayKey] += cost
+	m.counts[monthKey] += cost
+	return m.counts[dayKey], m.counts[monthKey], true, nil
+}
+
+func (m *MockQuotaRepository) Usage(subject string, day, month time.Time) (int64, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[usageKey(subject, models.PeriodDay, day)], m.counts[usageKey(subject, models.PeriodMonth, month)], nil
+}
+
+// WithContext returns the mock itself, there are no statements to tag
+func (m *MockQuotaRepository) WithContext(ctx context.Context) repository.QuotaRepository {
+	return m
+}
+
+// usageKey names the counter of a subject in a window
+func usageKey(subject, period string, start time.Time) string {
+	return subject + "|" + period + "|" + start.Format(time.RFC3339)
+}
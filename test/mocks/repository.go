@@ -1,14 +1,17 @@
 package mocks
 
 import (
+	"context"
 	"github.com/stretchr/testify/mock"
 	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
 	inputModels "go-test/models"
 )
 
 // MockRepository - mock repository implementation
 type MockRepository struct {
 	mock.Mock
+	Rollbacks int
 }
 
 // mock methods to satisfy interface
@@ -18,16 +21,43 @@ func (m *MockRepository) FindAll() ([]models.Animal, error) {
 	return args.Get(0).([]models.Animal), args.Error(1)
 }
 
+func (m *MockRepository) Rows() (repository.AnimalRows, error) {
+	args := m.Called()
+	return args.Get(0).(repository.AnimalRows), args.Error(1)
+}
+
 func (m *MockRepository) GetCount() (int64, error) {
-	return 0, nil
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func (m *MockRepository) FindByID(id uint) (models.Animal, error) {
 	return models.Animal{}, nil
 }
 
+func (m *MockRepository) FindByIDs(ids []uint) ([]models.Animal, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]models.Animal), args.Error(1)
+}
+
+func (m *MockRepository) FindAllColumns(columns []string) ([]models.Animal, error) {
+	args := m.Called(columns)
+	return args.Get(0).([]models.Animal), args.Error(1)
+}
+
+func (m *MockRepository) FindPage(columns []string, offset, limit int) ([]models.Animal, error) {
+	args := m.Called(columns, offset, limit)
+	return args.Get(0).([]models.Animal), args.Error(1)
+}
+
+func (m *MockRepository) FindByIDColumns(id uint, columns []string) (models.Animal, error) {
+	args := m.Called(id, columns)
+	return args.Get(0).(models.Animal), args.Error(1)
+}
+
 func (m *MockRepository) Create(animal inputModels.Animal) (models.Animal, error) {
-	return models.Animal{}, nil
+	args := m.Called(animal)
+	return args.Get(0).(models.Animal), args.Error(1)
 }
 
 func (m *MockRepository) Replace(id uint, animal inputModels.Animal) (models.Animal, error) {
@@ -39,5 +69,75 @@ func (m *MockRepository) Delete(id uint) (models.Animal, error) {
 }
 
 func (m *MockRepository) UpdateDescription(id uint, description string) (models.Animal, error) {
-	return models.Animal{}, nil
+	args := m.Called(id, description)
+	return args.Get(0).(models.Animal), args.Error(1)
+}
+
+func (m *MockRepository) Import(record inputModels.ImportRecord, key string) (models.Animal, bool, error) {
+	args := m.Called(record, key)
+	return args.Get(0).(models.Animal), args.Bool(1), args.Error(2)
+}
+
+// Transaction runs fn with the mock itself, rollbacks are counted
+func (m *MockRepository) Transaction(fn func(rp repository.AnimalRepository) error) error {
+	err := fn(m)
+	if err != nil {
+		m.Rollbacks++
+	}
+	return err
+}
+
+// ForTenant returns the mock itself, records are not separated by tenant
+func (m *MockRepository) ForTenant(tenant string) repository.AnimalRepository {
+	return m
+}
+
+// As returns the mock itself, ownership is not checked
+func (m *MockRepository) As(actor repository.Actor) repository.AnimalRepository {
+	return m
+}
+
+func (m *MockRepository) FindShares(id uint) ([]models.AnimalShare, error) {
+	args := m.Called(id)
+	return args.Get(0).([]models.AnimalShare), args.Error(1)
+}
+
+func (m *MockRepository) Share(id uint, granteeType, grantee string) (models.AnimalShare, error) {
+	args := m.Called(id, granteeType, grantee)
+	return args.Get(0).(models.AnimalShare), args.Error(1)
+}
+
+func (m *MockRepository) Unshare(id, shareID uint) (models.AnimalShare, error) {
+	args := m.Called(id, shareID)
+	return args.Get(0).(models.AnimalShare), args.Error(1)
+}
+
+// MockRows - in-memory cursor over a fixed list of records
+type MockRows struct {
+	Animals []models.Animal
+	pos     int
+	Closed  bool
+}
+
+func (r *MockRows) Next() bool {
+	r.pos++
+	return r.pos <= len(r.Animals)
+}
+
+func (r *MockRows) Scan() (models.Animal, error) {
+	return r.Animals[r.pos-1], nil
+}
+
+func (r *MockRows) Err() error {
+	return nil
+}
+
+func (r *MockRows) Close() error {
+	r.Closed = true
+	return nil
+}
+
+// WithContext returns the mock itself, there are no statements to tag
+func (m *MockRepository) WithContext(ctx context.Context) repository.AnimalRepository {
+	return m
 }
@@ -0,0 +1,173 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/auth"
+	dbModels "go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/models"
+	"go-test/problems"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newAPIKeyEngine serves key management and scoped routes behind API key authentication
+func newAPIKeyEngine() (*gin.Engine, *repository.APIKeyRepository) {
+	rp := repository.APIKeyRepository(&mocks.MockAPIKeyRepository{})
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	protected := r.Group("", middleware.Authenticate(nil, &auth.APIKeys{Repository: &rp}))
+	protected.GET("/animals", middleware.Require(&auth.DefaultPolicy, auth.PermRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	protected.POST("/animals", middleware.Require(&auth.DefaultPolicy, auth.PermCreate), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	admin := protected.Group("/admin", middleware.Require(&auth.DefaultPolicy, auth.PermAdmin))
+	admin.POST("/api-keys", func(c *gin.Context) { routers.CreateAPIKey(c, &rp, &auth.DefaultPolicy) })
+	admin.GET("/api-keys", func(c *gin.Context) { routers.GetAPIKeys(c, &rp) })
+	admin.POST("/api-keys/:id/rotate", func(c *gin.Context) { routers.RotateAPIKey(c, &rp, &auth.DefaultPolicy) })
+	admin.DELETE("/api-keys/:id", func(c *gin.Context) { routers.RevokeAPIKey(c, &rp, &auth.DefaultPolicy) })
+	return r, &rp
+}
+
+// seedAPIKey stores a key directly, as the first admin key is
+func seedAPIKey(t *testing.T, rp *repository.APIKeyRepository, scopes string, expiresAt *time.Time) string {
+	key, prefix, hash, err := auth.NewAPIKey()
+	assert.Equal(t, nil, err)
+	_, err = (*rp).Create(dbModels.APIKey{Name: "seed", Prefix: prefix, Hash: hash, Scopes: scopes, ExpiresAt: expiresAt})
+	assert.Equal(t, nil, err)
+	return key
+}
+
+func withAPIKey(r *gin.Engine, method, path, key string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, rp := newAPIKeyEngine()
+	// keys grant no more than their creator holds
+	adminKey := seedAPIKey(t, rp, "read admin", nil)
+
+	// created keys are shown once
+	w := withAPIKey(r, "POST", "/admin/api-keys", adminKey, models.APIKeyInput{Name: "producer", Scopes: []string{"read"}})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created models.APIKey
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, true, strings.HasPrefix(created.Key, "ak_"+created.Prefix+"_"))
+	assert.Equal(t, []string{"read"}, created.Scopes)
+
+	// scopes limit the key
+	assert.Equal(t, http.StatusOK, withAPIKey(r, "GET", "/animals", created.Key, nil).Code)
+	w = withAPIKey(r, "POST", "/animals", created.Key, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var problem problems.Problem
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, problems.TypeForbidden, problem.Type)
+	assert.Equal(t, http.StatusForbidden, withAPIKey(r, "GET", "/admin/api-keys", created.Key, nil).Code)
+
+	// lists never carry the secret, but the last use
+	w = withAPIKey(r, "GET", "/admin/api-keys", adminKey, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, false, strings.Contains(w.Body.String(), created.Key))
+	var list []models.APIKey
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 2, len(list))
+	assert.NotEqual(t, nil, list[1].LastUsedAt)
+
+	// the old secret stops working on rotation
+	w = withAPIKey(r, "POST", fmt.Sprintf("/admin/api-keys/%d/rotate", created.ID), adminKey, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var rotated models.APIKey
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.Equal(t, created.ID, rotated.ID)
+	assert.Equal(t, http.StatusUnauthorized, withAPIKey(r, "GET", "/animals", created.Key, nil).Code)
+	assert.Equal(t, http.StatusOK, withAPIKey(r, "GET", "/animals", rotated.Key, nil).Code)
+
+	// revoked keys neither work nor rotate
+	assert.Equal(t, http.StatusOK, withAPIKey(r, "DELETE", fmt.Sprintf("/admin/api-keys/%d", created.ID), adminKey, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, withAPIKey(r, "GET", "/animals", rotated.Key, nil).Code)
+	assert.Equal(t, http.StatusConflict, withAPIKey(r, "POST", fmt.Sprintf("/admin/api-keys/%d/rotate", created.ID), adminKey, nil).Code)
+}
+
+func TestAPIKeyRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, rp := newAPIKeyEngine()
+	adminKey := seedAPIKey(t, rp, "admin", nil)
+	expired := time.Now().Add(-time.Minute)
+	expiredKey := seedAPIKey(t, rp, "read", &expired)
+
+	for _, key := range []string{"", expiredKey, adminKey + "x", "ak_unknown_secret", "garbage"} {
+		assert.Equal(t, http.StatusUnauthorized, withAPIKey(r, "GET", "/animals", key, nil).Code)
+	}
+
+	// unknown scopes and past expiry are invalid input
+	past := time.Now().Add(-time.Hour)
+	for _, input := range []models.APIKeyInput{
+		{Name: "producer", Scopes: []string{"root"}},
+		{Name: "producer"},
+		{Name: "producer", Scopes: []string{"read"}, ExpiresAt: &past},
+	} {
+		assert.Equal(t, http.StatusBadRequest, withAPIKey(r, "POST", "/admin/api-keys", adminKey, input).Code)
+	}
+
+	// bodies are bound like those of every other route
+	w := withAPIKey(r, "POST", "/admin/api-keys", adminKey, models.APIKeyInput{Name: "producer"})
+	var problem problems.Problem
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, problems.TypeValidation, problem.Type)
+	assert.Equal(t, 1, len(problem.Errors))
+	assert.Equal(t, "scopes", problem.Errors[0].Field)
+	for contentType, code := range map[string]int{"application/json": http.StatusBadRequest, "text/plain": http.StatusUnsupportedMediaType} {
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/admin/api-keys", strings.NewReader("{"))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-API-Key", adminKey)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code)
+	}
+}
+
+func TestAPIKeyEscalation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, rp := newAPIKeyEngine()
+	adminKey := seedAPIKey(t, rp, "admin", nil)
+	writeKey := seedAPIKey(t, rp, "read write", nil)
+
+	// admin keys may mint admin keys, but none with more permissions
+	assert.Equal(t, http.StatusCreated, withAPIKey(r, "POST", "/admin/api-keys", adminKey, models.APIKeyInput{Name: "admin", Scopes: []string{"admin"}}).Code)
+	for _, scopes := range [][]string{{"write"}, {"tunnel"}, {"admin", "read"}} {
+		w := withAPIKey(r, "POST", "/admin/api-keys", adminKey, models.APIKeyInput{Name: "escalated", Scopes: scopes})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+	// nor take over stronger keys by rotating them
+	assert.Equal(t, http.StatusForbidden, withAPIKey(r, "POST", "/admin/api-keys/2/rotate", adminKey, nil).Code)
+	assert.Equal(t, http.StatusOK, withAPIKey(r, "GET", "/animals", writeKey, nil).Code)
+	assert.Equal(t, http.StatusNotFound, withAPIKey(r, "POST", "/admin/api-keys/9/rotate", adminKey, nil).Code)
+	// nor revoke them
+	assert.Equal(t, http.StatusForbidden, withAPIKey(r, "DELETE", "/admin/api-keys/2", adminKey, nil).Code)
+	assert.Equal(t, http.StatusOK, withAPIKey(r, "GET", "/animals", writeKey, nil).Code)
+	assert.Equal(t, http.StatusNotFound, withAPIKey(r, "DELETE", "/admin/api-keys/9", adminKey, nil).Code)
+}
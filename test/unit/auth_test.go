@@ -0,0 +1,189 @@
+package unit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go-test/auth"
+	"go-test/middleware"
+	"go-test/problems"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newAuthEngine serves the principal of the request behind authentication
+func newAuthEngine(verifier *auth.Verifier) *gin.Engine {
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	r.GET("/me", middleware.Authenticate(verifier, nil), func(c *gin.Context) {
+		principal, _ := auth.Current(c)
+		// also carried by the request context
+		if fromContext, ok := auth.FromContext(c.Request.Context()); !ok || fromContext.Subject != principal.Subject {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, principal)
+	})
+	return r
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func validClaims(subject string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   subject,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"editor"},
+		"scope": "animals:read animals:write",
+	}
+}
+
+func getMe(r *gin.Engine, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/me", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthenticateHS256(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := []byte("test-secret")
+	r := newAuthEngine(&auth.Verifier{Keys: auth.HMACSecret(secret), Issuer: "zoo"})
+
+	claims := validClaims("keeper")
+	claims["iss"] = "zoo"
+	w := getMe(r, signToken(t, jwt.SigningMethodHS256, "", secret, claims))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var principal auth.Principal
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &principal))
+	assert.Equal(t, auth.Principal{
+		Subject: "keeper",
+		Method:  auth.MethodJWT,
+		Roles:   []string{"editor"},
+		Scopes:  []string{"animals:read", "animals:write"},
+	}, principal)
+
+	// no token
+	w = getMe(r, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	var problem problems.Problem
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, problems.TypeUnauthorized, problem.Type)
+
+	expired := validClaims("keeper")
+	expired["iss"] = "zoo"
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	otherIssuer := validClaims("keeper")
+	otherIssuer["iss"] = "circus"
+	for _, token := range []string{
+		signToken(t, jwt.SigningMethodHS256, "", secret, expired),
+		signToken(t, jwt.SigningMethodHS256, "", secret, otherIssuer),
+		signToken(t, jwt.SigningMethodHS256, "", []byte("other-secret"), claims),
+		signToken(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, claims),
+	} {
+		w = getMe(r, token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+	}
+}
+
+// publicJWK encodes the public part of an RSA or P-256 key as a JWK
+func publicJWK(kid string, key interface{}) map[string]string {
+	encode := func(n *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(n.Bytes())
+	}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kid": kid, "kty": "RSA", "use": "sig", "n": encode(k.N), "e": encode(big.NewInt(int64(k.E)))}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kid": kid, "kty": "EC", "crv": "P-256", "x": encode(k.X), "y": encode(k.Y)}
+	}
+	return nil
+}
+
+func jwksDocument(keys ...map[string]string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{"keys": keys})
+	return data
+}
+
+func TestAuthenticateJWKSRotation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	// stand-in of the identity provider, which rotates from the RSA to the EC key
+	var mu sync.Mutex
+	document := jwksDocument(publicJWK("rsa-1", &rsaKey.PublicKey))
+	fetches := 0
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		w.Write(document)
+	}))
+	defer provider.Close()
+	keys := auth.NewRemoteKeys(provider.URL, time.Hour)
+	keys.MinRefresh = 0
+	r := newAuthEngine(&auth.Verifier{Keys: keys})
+
+	rsaToken := signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims("keeper"))
+	assert.Equal(t, http.StatusOK, getMe(r, rsaToken).Code)
+	assert.Equal(t, http.StatusOK, getMe(r, rsaToken).Code)
+	// cached keys are reused
+	assert.Equal(t, 1, fetches)
+
+	mu.Lock()
+	document = jwksDocument(publicJWK("ec-2", &ecKey.PublicKey))
+	mu.Unlock()
+	// an unknown key id refreshes the keys before the cache expires
+	ecToken := signToken(t, jwt.SigningMethodES256, "ec-2", ecKey, validClaims("keeper"))
+	assert.Equal(t, http.StatusOK, getMe(r, ecToken).Code)
+	assert.Equal(t, 2, fetches)
+	// the retired key is gone
+	assert.Equal(t, http.StatusUnauthorized, getMe(r, rsaToken).Code)
+}
+
+func TestAuthenticateJWKSFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.Equal(t, nil, os.WriteFile(path, jwksDocument(publicJWK("ec-1", &ecKey.PublicKey)), 0600))
+	keys, err := auth.NewFileKeys(path)
+	assert.Equal(t, nil, err)
+	r := newAuthEngine(&auth.Verifier{Keys: auth.Sources(auth.HMACSecret("test-secret"), keys)})
+
+	assert.Equal(t, http.StatusOK, getMe(r, signToken(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims("keeper"))).Code)
+	// HS256 tokens are only verified with the shared secret, never with a public key
+	x := ecKey.PublicKey.X.Bytes()
+	assert.Equal(t, http.StatusUnauthorized, getMe(r, signToken(t, jwt.SigningMethodHS256, "ec-1", x, validClaims("keeper"))).Code)
+	// tokens need a subject
+	noSubject := validClaims("")
+	assert.Equal(t, http.StatusUnauthorized, getMe(r, signToken(t, jwt.SigningMethodES256, "ec-1", ecKey, noSubject)).Code)
+}
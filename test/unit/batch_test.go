@@ -0,0 +1,105 @@
+package unit
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/animals"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	inputModels "go-test/models"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newBatchEngine serves animal creation and listing next to the batch endpoint
+func newBatchEngine(mockRepository *mocks.MockRepository) *gin.Engine {
+	var mu sync.Mutex
+	rp := repository.AnimalRepository(mockRepository)
+	store := &animals.Store{Mu: &mu, Repository: &rp}
+
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	r.GET("/animals", routers.Negotiate(), func(c *gin.Context) {
+		routers.GetAnimals(c, &mu, &rp)
+	})
+	r.POST("/animals", routers.Negotiate(), func(c *gin.Context) {
+		routers.CreateAnimal(c, store)
+	})
+	r.GET("/jobs", func(c *gin.Context) {})
+	r.POST("/batch", routers.BatchHandler(r, store))
+	return r
+}
+
+func postBatch(r *gin.Engine, query, body string) (*httptest.ResponseRecorder, inputModels.BatchResponse) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/batch"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	var response inputModels.BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func batchStatuses(response inputModels.BatchResponse) []int {
+	var statuses []int
+	for _, result := range response.Results {
+		statuses = append(statuses, result.Status)
+	}
+	return statuses
+}
+
+func TestBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Create", inputModels.Animal{Name: "Lion", Type: 1}).
+		Return(models.Animal{ID: 1, Name: "Lion", Type: 1}, nil)
+	mockRepository.On("FindAll").Return([]models.Animal{{ID: 1, Name: "Lion", Type: 1}}, nil)
+	r := newBatchEngine(mockRepository)
+
+	w, response := postBatch(r, "", `[
+		{"method": "POST", "path": "/animals", "body": {"name": "Lion", "type": 1}},
+		{"method": "GET", "path": "/animals"},
+		{"method": "POST", "path": "/animals", "body": {"type": 1}}
+	]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusBadRequest}, batchStatuses(response))
+	assert.Equal(t, false, response.RolledBack)
+
+	var created inputModels.AnimalWithID
+	assert.Equal(t, nil, json.Unmarshal(response.Results[0].Body, &created))
+	assert.Equal(t, "Lion", created.Animal.Name)
+	assert.Equal(t, 0, mockRepository.Rollbacks)
+}
+
+func TestTransactionalBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Create", inputModels.Animal{Name: "Lion", Type: 1}).
+		Return(models.Animal{ID: 1, Name: "Lion", Type: 1}, nil)
+	r := newBatchEngine(mockRepository)
+
+	// the invalid record rolls back the created one
+	w, response := postBatch(r, "?transactional=true", `[
+		{"method": "POST", "path": "/animals", "body": {"name": "Lion", "type": 1}},
+		{"method": "POST", "path": "/animals", "body": {"type": 1}},
+		{"method": "GET", "path": "/animals"}
+	]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, response.RolledBack)
+	assert.Equal(t, []int{http.StatusOK, http.StatusBadRequest, http.StatusFailedDependency}, batchStatuses(response))
+	assert.Equal(t, 1, mockRepository.Rollbacks)
+
+	// only animal records take part in transactions
+	w, _ = postBatch(r, "?transactional=true", `[{"method": "GET", "path": "/jobs"}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = postBatch(r, "", `[{"method": "POST", "path": "/batch", "body": []}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var corsPolicy = middleware.CORSPolicy{
+	AllowedOrigins:   []string{"https://app.example.com", "https://*.zoo.example"},
+	AllowedMethods:   []string{"GET", "POST", "PUT"},
+	AllowedHeaders:   []string{"Authorization", "Content-Type"},
+	ExposedHeaders:   []string{"X-Item-Length", "Location"},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
+// newCORSEngine serves a single route behind the CORS policy
+func newCORSEngine(policy middleware.CORSPolicy) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.ErrorMiddleware())
+	r.Use(middleware.CORSMiddleware(policy))
+	r.GET("/animals", func(c *gin.Context) {
+		c.Header("X-Item-Length", "3")
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func sendCORS(r *gin.Engine, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, "/animals", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSOrigins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newCORSEngine(corsPolicy)
+
+	w := sendCORS(r, "GET", "https://app.example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "X-Item-Length, Location", w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	// subdomains match the wildcard, the domain itself and lookalikes do not
+	assert.Equal(t, "https://north.zoo.example", sendCORS(r, "GET", "https://north.zoo.example", nil).Header().Get("Access-Control-Allow-Origin"))
+	for _, origin := range []string{"https://zoo.example", "https://evil.example", "http://north.zoo.example", "https://north.zoo.example.evil.com"} {
+		w = sendCORS(r, "GET", origin, nil)
+		// served, but browsers keep the response from scripts
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Credentials"))
+	}
+
+	// requests without origin are not cross-origin
+	w = sendCORS(r, "GET", "", nil)
+	assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+}
+
+func TestCORSPreflight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newCORSEngine(corsPolicy)
+
+	w := sendCORS(r, "OPTIONS", "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method":  "PUT",
+		"Access-Control-Request-Headers": "authorization, content-type",
+	})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST, PUT", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "authorization, content-type", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, w.Header().Values("Vary"))
+
+	// methods, headers and origins outside the policy are refused
+	assert.Equal(t, http.StatusForbidden, sendCORS(r, "OPTIONS", "https://app.example.com", map[string]string{"Access-Control-Request-Method": "DELETE"}).Code)
+	assert.Equal(t, http.StatusForbidden, sendCORS(r, "OPTIONS", "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method":  "GET",
+		"Access-Control-Request-Headers": "X-Debug",
+	}).Code)
+	w = sendCORS(r, "OPTIONS", "https://evil.example", map[string]string{"Access-Control-Request-Method": "GET"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSPolicyValidation(t *testing.T) {
+	assert.Equal(t, nil, corsPolicy.Validate())
+	assert.NotEqual(t, nil, middleware.CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}.Validate())
+	assert.NotEqual(t, nil, middleware.CORSPolicy{AllowedOrigins: []string{"https://*example.com"}}.Validate())
+	assert.NotEqual(t, nil, middleware.CORSPolicy{AllowedOrigins: []string{"https://*.*.example.com"}}.Validate())
+
+	// public APIs without credentials may allow every origin
+	public := middleware.CORSPolicy{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}}
+	assert.Equal(t, nil, public.Validate())
+	w := sendCORS(newCORSEngine(public), "GET", "https://anywhere.example", nil)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Credentials"))
+}
@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExportAnimals(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// one cursor per request, since a cursor can be read only once
+	newRows := func() *mocks.MockRows {
+		return &mocks.MockRows{Animals: []models.Animal{
+			{ID: 1, Name: "Lion", Type: 3, Description: "King of the jungle"},
+			{ID: 2, Name: "Eagle", Type: 3, Description: "Majestic, bird"},
+		}}
+	}
+
+	tests := []struct {
+		version     int
+		query       string
+		contentType string
+		disposition string
+		body        string
+	}{
+		{
+			query:       "",
+			contentType: "text/csv; charset=utf-8",
+			disposition: `attachment; filename="animals.csv"`,
+			body:        "id,name,type,description\n1,Lion,3,King of the jungle\n2,Eagle,3,\"Majestic, bird\"\n",
+		},
+		{
+			query:       "?format=ndjson",
+			contentType: "application/x-ndjson",
+			disposition: `attachment; filename="animals.ndjson"`,
+			body: `{"id":1,"data":{"name":"Lion","type":3,"description":"King of the jungle"}}` + "\n" +
+				`{"id":2,"data":{"name":"Eagle","type":3,"description":"Majestic, bird"}}` + "\n",
+		},
+		{
+			// records of version 2 are flat
+			version:     2,
+			query:       "?format=ndjson",
+			contentType: "application/x-ndjson",
+			disposition: `attachment; filename="animals.ndjson"`,
+			body: `{"id":1,"name":"Lion","type":3,"description":"King of the jungle"}` + "\n" +
+				`{"id":2,"name":"Eagle","type":3,"description":"Majestic, bird"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		rows := newRows()
+		mockRepository := new(mocks.MockRepository)
+		mockRepository.On("Rows").Return(rows, nil)
+
+		r := gin.Default()
+		rp := repository.AnimalRepository(mockRepository)
+		r.GET("/animals/export", routers.Version(tt.version, nil), func(c *gin.Context) {
+			routers.ExportAnimals(c, &rp)
+		})
+
+		req, _ := http.NewRequest("GET", "/animals/export"+tt.query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, tt.disposition, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, tt.body, w.Body.String())
+		// cursor must be released after streaming
+		assert.Equal(t, true, rows.Closed)
+		mockRepository.AssertExpectations(t)
+	}
+}
+
+func TestExportAnimalsUnknownFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// repository must not be touched for invalid requests
+	mockRepository := new(mocks.MockRepository)
+
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	rp := repository.AnimalRepository(mockRepository)
+	r.GET("/animals/export", func(c *gin.Context) {
+		routers.ExportAnimals(c, &rp)
+	})
+
+	req, _ := http.NewRequest("GET", "/animals/export?format=xlsx", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"type":"about:blank","title":"Bad Request","status":400,"detail":"format must be csv or ndjson","instance":"/animals/export?format=xlsx"}`, w.Body.String())
+	mockRepository.AssertExpectations(t)
+}
@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/animals"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/gql"
+	"go-test/middleware"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type graphqlResult struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newGraphQLEngine(mockRepository *mocks.MockRepository, limits gql.Limits) *gin.Engine {
+	var mu sync.Mutex
+	rp := repository.AnimalRepository(mockRepository)
+	resolver := &gql.Resolver{Store: &animals.Store{Mu: &mu, Repository: &rp}}
+	schema, err := gql.NewSchema(resolver)
+	if err != nil {
+		panic(err)
+	}
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	r.POST("/graphql", routers.GraphQLHandler(schema, resolver, limits))
+	return r
+}
+
+func postGraphQL(r *gin.Engine, query string) (*httptest.ResponseRecorder, graphqlResult) {
+	body, _ := json.Marshal(gql.Request{Query: query})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	var result graphqlResult
+	json.Unmarshal(w.Body.Bytes(), &result)
+	return w, result
+}
+
+func TestGraphQLBatchesLookups(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("FindByIDs", []uint{1, 2, 3}).Return([]models.Animal{
+		{ID: 1, Name: "Lion", Type: 1, IsActive: true},
+		{ID: 2, Name: "Eagle", Type: 2, IsActive: true},
+	}, nil).Once()
+	r := newGraphQLEngine(mockRepository, gql.Limits{})
+
+	w, result := postGraphQL(r, `{
+		lion: animal(id: 1) { name type { name } }
+		eagle: animal(id: 2) { name }
+		animals(ids: [1, 2, 3]) { id }
+	}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, len(result.Errors))
+	assert.Equal(t, "Lion", result.Data["lion"].(map[string]interface{})["name"])
+	assert.Equal(t, "mammal", result.Data["lion"].(map[string]interface{})["type"].(map[string]interface{})["name"])
+	assert.Equal(t, "Eagle", result.Data["eagle"].(map[string]interface{})["name"])
+	// unknown ids are left out of lists
+	assert.Equal(t, 2, len(result.Data["animals"].([]interface{})))
+	// one query for all fields
+	mockRepository.AssertNumberOfCalls(t, "FindByIDs", 1)
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newGraphQLEngine(new(mocks.MockRepository), gql.Limits{MaxDepth: 2, MaxComplexity: 3})
+
+	_, result := postGraphQL(r, `{ animal(id: 1) { type { name } } }`)
+	assert.Equal(t, 1, len(result.Errors))
+	assert.Equal(t, "query depth 3 exceeds the limit of 2", result.Errors[0].Message)
+
+	_, result = postGraphQL(r, `{ a: animalTypes { code } b: animalTypes { code } }`)
+	assert.Equal(t, 1, len(result.Errors))
+	assert.Equal(t, "query complexity 4 exceeds the limit of 3", result.Errors[0].Message)
+
+	// introspection is not limited
+	_, result = postGraphQL(r, `{ __schema { types { name fields { name type { name } } } } }`)
+	assert.Equal(t, 0, len(result.Errors))
+}
+
+func TestGraphQLValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newGraphQLEngine(new(mocks.MockRepository), gql.Limits{})
+
+	_, result := postGraphQL(r, `mutation { createAnimal(input: {name: "  ", type: 9}) { id } }`)
+	assert.Equal(t, 1, len(result.Errors))
+	assert.Equal(t, float64(http.StatusBadRequest), result.Errors[0].Extensions["status"])
+	assert.Equal(t, 2, len(result.Errors[0].Extensions["errors"].([]interface{})))
+}
+
+func TestGraphiQLPinAssets(t *testing.T) {
+	assertPinnedAssets(t, gql.GraphiQL)
+}
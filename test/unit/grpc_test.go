@@ -0,0 +1,216 @@
+package unit
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"go-test/animals"
+	"go-test/auth"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	inputModels "go-test/models"
+	"go-test/proto/animalpb"
+	"go-test/ratelimit"
+	"go-test/rpc"
+	"go-test/test/mocks"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newGRPCClient serves the animal service over an in-memory connection
+func newGRPCClient(t *testing.T, store *animals.Store) *grpc.ClientConn {
+	return newGRPCConn(t, rpc.NewServer(store, nil, nil, nil, rpc.Metering{}))
+}
+
+// newGRPCConn connects to the server over an in-memory connection
+func newGRPCConn(t *testing.T, server *grpc.Server) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newGRPCStore(mockRepository *mocks.MockRepository) *animals.Store {
+	var mu sync.Mutex
+	rp := repository.AnimalRepository(mockRepository)
+	return &animals.Store{Mu: &mu, Repository: &rp, Events: animals.NewBroadcaster()}
+}
+
+func TestGRPCCreateAndWatch(t *testing.T) {
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Create", inputModels.Animal{Name: "Snow Leopard", Type: 1}).
+		Return(models.Animal{ID: 5, Name: "Snow Leopard", Type: 1}, nil)
+	client := animalpb.NewAnimalServiceClient(newGRPCClient(t, newGRPCStore(mockRepository)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	watch, err := client.Watch(ctx, &animalpb.WatchAnimalsRequest{})
+	assert.Equal(t, nil, err)
+	// headers are sent once the server has subscribed
+	_, err = watch.Header()
+	assert.Equal(t, nil, err)
+
+	// input is normalized like REST bodies
+	created, err := client.Create(ctx, &animalpb.CreateAnimalRequest{Animal: &animalpb.Animal{Name: " Snow  Leopard ", Type: 1}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(5), created.GetId())
+
+	event, err := watch.Recv()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, animalpb.AnimalEvent_TYPE_CREATED, event.GetType())
+	assert.Equal(t, "Snow Leopard", event.GetAnimal().GetData().GetName())
+}
+
+func TestGRPCValidation(t *testing.T) {
+	client := animalpb.NewAnimalServiceClient(newGRPCClient(t, newGRPCStore(new(mocks.MockRepository))))
+
+	_, err := client.Create(context.Background(), &animalpb.CreateAnimalRequest{Animal: &animalpb.Animal{Type: 9}})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, 1, len(st.Details()))
+	assert.Equal(t, 2, len(st.Details()[0].(*errdetails.BadRequest).GetFieldViolations()))
+}
+
+func TestGRPCListAndHealth(t *testing.T) {
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Rows").Return(&mocks.MockRows{Animals: []models.Animal{
+		{ID: 1, Name: "Lion", Type: 1},
+		{ID: 2, Name: "Eagle", Type: 2},
+	}}, nil)
+	store := newGRPCStore(mockRepository)
+	conn := newGRPCClient(t, store)
+
+	// listing never waits for writers
+	store.Mu.Lock()
+	defer store.Mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := animalpb.NewAnimalServiceClient(conn).List(ctx, &animalpb.ListAnimalsRequest{})
+	assert.Equal(t, nil, err)
+	var names []string
+	for {
+		animal, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Equal(t, nil, err)
+		names = append(names, animal.GetData().GetName())
+	}
+	assert.Equal(t, []string{"Lion", "Eagle"}, names)
+
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: animalpb.AnimalService_ServiceDesc.ServiceName,
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.GetStatus())
+}
+
+func TestGRPCAPIKeys(t *testing.T) {
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Rows").Return(&mocks.MockRows{}, nil)
+	keys := repository.APIKeyRepository(&mocks.MockAPIKeyRepository{})
+	readKey := seedAPIKey(t, &keys, "read", nil)
+	server := rpc.NewServer(newGRPCStore(mockRepository), nil, &auth.APIKeys{Repository: &keys}, &auth.DefaultPolicy, rpc.Metering{})
+	client := animalpb.NewAnimalServiceClient(newGRPCConn(t, server))
+
+	// anonymous calls are refused once API keys are the only credentials
+	call := func(md ...string) codes.Code {
+		ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(md...))
+		if _, err := client.Create(ctx, &animalpb.CreateAnimalRequest{Animal: &animalpb.Animal{Name: "Lion", Type: 1}}); err != nil {
+			return status.Code(err)
+		}
+		stream, err := client.List(ctx, &animalpb.ListAnimalsRequest{})
+		assert.Equal(t, nil, err)
+		_, err = stream.Recv()
+		return status.Code(err)
+	}
+	assert.Equal(t, codes.Unauthenticated, call())
+	assert.Equal(t, codes.Unauthenticated, call("x-api-key", "ak_0000_forged"))
+	assert.Equal(t, codes.Unauthenticated, call("x-tenant-id", "shelter-b"))
+	// keys are limited to their scopes and their tenant
+	assert.Equal(t, codes.PermissionDenied, call("x-api-key", readKey))
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-api-key", readKey, "x-tenant-id", "shelter-b"))
+	stream, err := client.List(ctx, &animalpb.ListAnimalsRequest{})
+	assert.Equal(t, nil, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	stream, err = client.List(metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-api-key", readKey)), &animalpb.ListAnimalsRequest{})
+	assert.Equal(t, nil, err)
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestGRPCMetering(t *testing.T) {
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Create", inputModels.Animal{Name: "Lion", Type: 1}).Return(models.Animal{ID: 1, Name: "Lion", Type: 1}, nil)
+	limiter, _ := newTestLimiter(t)
+	metering := rpc.Metering{
+		RateLimiter: limiter,
+		RateLimits: ratelimit.Rules{
+			Default: ratelimit.Limit{Rate: 60, Period: time.Minute, Burst: 100},
+			Routes: map[string]ratelimit.Rule{
+				"POST /animals.v1.AnimalService/Create": {Limit: &ratelimit.Limit{Rate: 60, Period: time.Minute, Burst: 5}},
+			},
+		},
+		Quotas: newTestQuotas(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)),
+	}
+	conn := newGRPCConn(t, rpc.NewServer(newGRPCStore(mockRepository), nil, nil, nil, metering))
+	client := animalpb.NewAnimalServiceClient(conn)
+	create := func() (metadata.MD, metadata.MD, error) {
+		var header, trailer metadata.MD
+		_, err := client.Create(context.Background(), &animalpb.CreateAnimalRequest{Animal: &animalpb.Animal{Name: "Lion", Type: 1}},
+			grpc.Header(&header), grpc.Trailer(&trailer))
+		return header, trailer, err
+	}
+
+	// calls are charged like requests, with the headers of the REST API as metadata
+	header, _, err := create()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"5"}, header.Get("ratelimit-limit"))
+	assert.Equal(t, []string{"4"}, header.Get("ratelimit-remaining"))
+	for i := 0; i < 3; i++ {
+		header, _, err = create()
+		assert.Equal(t, nil, err)
+	}
+	assert.Equal(t, 1, len(header.Get("quota-warning")))
+
+	// the burst of the method is spent after 5 calls
+	_, _, err = create()
+	assert.Equal(t, nil, err)
+	_, trailer, err := create()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"1"}, trailer.Get("retry-after"))
+
+	// other methods have their own budget, but share the daily quota of 5 calls
+	stream, err := client.List(context.Background(), &animalpb.ListAnimalsRequest{})
+	assert.Equal(t, nil, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, true, strings.Contains(status.Convert(err).Message(), "daily quota of 5 requests"))
+
+	// health checks are never charged
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.GetStatus())
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type halLinks map[string]struct {
+	Href string `json:"href"`
+}
+
+func TestHALListPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	// only the second page is read, the count gives the last one
+	mockRepository.On("FindPage", mock.Anything, 2, 2).Return([]models.Animal{
+		{ID: 3, Name: "Viper", Type: 3},
+	}, nil)
+	mockRepository.On("GetCount").Return(int64(3), nil)
+	r := gin.Default()
+	r.SetTrustedProxies([]string{"127.0.0.1"})
+	r.Use(middleware.ErrorMiddleware())
+	var mu sync.Mutex
+	rp := repository.AnimalRepository(mockRepository)
+	r.GET("/v2/animals", routers.Version(2, nil), routers.Negotiate(), func(c *gin.Context) {
+		routers.GetAnimals(c, &mu, &rp)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v2/animals?page=2&per_page=2", nil)
+	req.Header.Set("Accept", routers.MIMEHAL)
+	// links point at the trusted proxy
+	req.RemoteAddr = "127.0.0.1:4711"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com, internal")
+	req.Header.Set("X-Forwarded-Prefix", "/zoo/")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, routers.MIMEHAL+"; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var body struct {
+		Links    halLinks `json:"_links"`
+		Embedded struct {
+			Animals []struct {
+				ID    int      `json:"id"`
+				Name  string   `json:"name"`
+				Links halLinks `json:"_links"`
+			} `json:"animals"`
+		} `json:"_embedded"`
+		Total int `json:"total"`
+	}
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 3, body.Total)
+	assert.Equal(t, 1, len(body.Embedded.Animals))
+	assert.Equal(t, "Viper", body.Embedded.Animals[0].Name)
+	assert.Equal(t, "https://api.example.com/zoo/v2/animals/3", body.Embedded.Animals[0].Links["self"].Href)
+	assert.Equal(t, "https://api.example.com/zoo/v2/animals", body.Embedded.Animals[0].Links["collection"].Href)
+	assert.Equal(t, "https://api.example.com/zoo/v2/animals?page=1&per_page=2", body.Links["prev"].Href)
+	assert.Equal(t, "https://api.example.com/zoo/v2/animals?page=2&per_page=2", body.Links["last"].Href)
+	_, hasNext := body.Links["next"]
+	assert.Equal(t, false, hasNext)
+	mockRepository.AssertNotCalled(t, "FindAll")
+}
+
+func TestLinkBuilderWithoutProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.SetTrustedProxies([]string{"127.0.0.1"})
+	var links []string
+	r.GET("/animals", func(c *gin.Context) {
+		links = append(links, routers.NewLinkBuilder(c).URL("/animals/1", nil))
+	})
+
+	// forwarded headers of clients are ignored, those of trusted proxies are not
+	for _, remoteAddr := range []string{"", "192.0.2.1:4711", "127.0.0.1:4711"} {
+		req, _ := http.NewRequest("GET", "http://localhost:3000/animals", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("X-Forwarded-Host", "evil.example.com")
+		req.Header.Set("X-Forwarded-Prefix", "/phish")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, []string{
+		"http://localhost:3000/animals/1",
+		"http://localhost:3000/animals/1",
+		"https://evil.example.com/phish/animals/1",
+	}, links)
+}
@@ -0,0 +1,166 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/redis/go-redis/v9"
+	"go-test/animals"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/jobs"
+	"go-test/middleware"
+	inputModels "go-test/models"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportAnimalsDryRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// dry run must not write anything
+	mockRepository := new(mocks.MockRepository)
+
+	r := gin.Default()
+	store := newGRPCStore(mockRepository)
+	r.POST("/animals/import", func(c *gin.Context) {
+		routers.ImportAnimals(c, store, nil, nil, 0, 0)
+	})
+
+	// "Nom" column is mapped to the name field
+	body := "Nom,type,description\nLion,3,King of the jungle\n,3,No name\nEagle,bird,Majestic bird\n"
+	req, _ := http.NewRequest("POST", "/animals/import?dry_run=true&map=Nom:name", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"dry_run":true,"total":3,"valid":1,"created":0,"updated":0,"failed":2,"errors":[{"line":3,"error":"name is a required field"},{"line":4,"error":"type must be a number"}]}`, w.Body.String())
+	mockRepository.AssertExpectations(t)
+}
+
+func TestImportAnimalsUpsertByExternalID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Import", inputModels.ImportRecord{
+		Line: 1, ExternalID: "a-1", Animal: inputModels.Animal{Name: "Lion", Type: 3},
+	}, repository.ImportKeyExternalID).Return(models.Animal{ID: 1}, false, nil)
+	mockRepository.On("Import", inputModels.ImportRecord{
+		Line: 2, ExternalID: "a-2", Animal: inputModels.Animal{Name: "Eagle", Type: 3},
+	}, repository.ImportKeyExternalID).Return(models.Animal{ID: 2}, true, nil)
+
+	r := gin.Default()
+	store := newGRPCStore(mockRepository)
+	r.POST("/animals/import", func(c *gin.Context) {
+		routers.ImportAnimals(c, store, nil, nil, 0, 0)
+	})
+
+	body := `{"external_id":"a-1","name":"Lion","type":3}` + "\n" + `{"external_id":"a-2","name":"Eagle","type":"3"}` + "\n"
+	req, _ := http.NewRequest("POST", "/animals/import?mode=upsert&key=external_id", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"dry_run":false,"total":2,"valid":2,"created":1,"updated":1,"failed":0,"errors":[]}`, w.Body.String())
+	mockRepository.AssertExpectations(t)
+}
+
+func TestImportAnimalsThroughStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Import", inputModels.ImportRecord{
+		Line: 2, ID: 1, Animal: inputModels.Animal{Name: "Lion", Type: 3},
+	}, repository.ImportKeyID).Return(models.Animal{ID: 1, Name: "Lion", Type: 3}, false, nil)
+	server := miniredis.RunT(t)
+	store := newGRPCStore(mockRepository)
+	store.Redis = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	// cached before the import
+	server.HSet(":1", "*", `{"id":1,"data":{"name":"Cat","type":3}}`)
+	events, unsubscribe := store.Events.Subscribe(1)
+	defer unsubscribe()
+
+	r := gin.Default()
+	r.POST("/animals/import", func(c *gin.Context) {
+		routers.ImportAnimals(c, store, nil, nil, 0, 0)
+	})
+	req, _ := http.NewRequest("POST", "/animals/import?mode=upsert&key=id", strings.NewReader("id,name,type\n1,Lion,3\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// updated records are read from the database again, and watchers learn of them
+	assert.Equal(t, false, server.Exists(":1"))
+	event := <-events
+	assert.Equal(t, animals.Replaced, event.Type)
+	assert.Equal(t, "Lion", event.Animal.Animal.Name)
+}
+
+func TestImportAnimalsTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// nothing is imported from bodies over the limit
+	mockRepository := new(mocks.MockRepository)
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	r.POST("/animals/import", func(c *gin.Context) {
+		routers.ImportAnimals(c, newGRPCStore(mockRepository), nil, nil, 0, 64)
+	})
+	for contentType, body := range map[string]string{
+		"text/csv":             "name,type\n" + strings.Repeat("Lion,3\n", 20),
+		"application/x-ndjson": strings.Repeat(`{"name":"Lion","type":3}`+"\n", 5),
+	} {
+		req, _ := http.NewRequest("POST", "/animals/import", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	}
+	mockRepository.AssertExpectations(t)
+}
+
+func TestImportJobResumes(t *testing.T) {
+	// the first row was imported by an attempt which crashed afterwards
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Import", inputModels.ImportRecord{
+		Line: 3, Animal: inputModels.Animal{Name: "Eagle", Type: 3},
+	}, repository.ImportKeyNone).Return(models.Animal{ID: 2, Name: "Eagle", Type: 3}, true, nil)
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"records": []inputModels.ImportRecord{
+			{Line: 2, Animal: inputModels.Animal{Name: "Lion", Type: 3}},
+			{Line: 3, Animal: inputModels.Animal{Name: "Eagle", Type: 3}},
+		},
+		"key":    repository.ImportKeyNone,
+		"report": inputModels.ImportReport{Total: 2, Valid: 2, Errors: []inputModels.ImportRowError{}},
+	})
+	rp := new(mocks.MockJobRepository)
+	queued, _ := rp.Create(models.Job{
+		Kind:        routers.ImportJobKind,
+		Payload:     string(payload),
+		MaxAttempts: 1,
+		Checkpoint:  `{"offset":1,"report":{"dry_run":false,"total":2,"valid":2,"created":1,"updated":0,"failed":0,"errors":[]}}`,
+	})
+
+	runner := jobs.NewRunner(rp, 1, 10*time.Millisecond, jobs.RetryPolicy{MaxAttempts: 1})
+	runner.Register(routers.ImportJobKind, routers.ImportJobHandler(newGRPCStore(mockRepository)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	job := waitForJob(t, rp, queued.ID)
+	assert.Equal(t, models.JobSucceeded, job.Status)
+	assert.Equal(t, `{"dry_run":false,"total":2,"valid":2,"created":2,"updated":0,"failed":0,"errors":[]}`, job.Result)
+	assert.Equal(t, `{"offset":2,"report":{"dry_run":false,"total":2,"valid":2,"created":2,"updated":0,"failed":0,"errors":[]}}`, job.Checkpoint)
+	mockRepository.AssertExpectations(t)
+}
@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/jobs"
+	"go-test/test/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForJob polls the repository until the job leaves the queue for good
+func waitForJob(t *testing.T, rp *mocks.MockJobRepository, id uint) models.Job {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, _ := rp.FindByID(id)
+		if job.FinishedAt != nil {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %d did not finish in time", id)
+	return models.Job{}
+}
+
+func TestJobRunnerRetries(t *testing.T) {
+	rp := new(mocks.MockJobRepository)
+	runner := jobs.NewRunner(rp, 1, 10*time.Millisecond, jobs.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	// fail the first attempt only
+	calls := 0
+	runner.Register("flaky", func(ctx context.Context, payload []byte, progress func(done, total int)) (interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("temporary failure")
+		}
+		progress(1, 1)
+		return map[string]string{"payload": string(payload)}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	queued, err := runner.Enqueue(context.Background(), "flaky", "hello")
+	assert.Equal(t, nil, err)
+
+	job := waitForJob(t, rp, queued.ID)
+	assert.Equal(t, models.JobSucceeded, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, `{"payload":"\"hello\""}`, job.Result)
+}
+
+func TestJobRunnerCheckpoint(t *testing.T) {
+	rp := new(mocks.MockJobRepository)
+	runner := jobs.NewRunner(rp, 1, 10*time.Millisecond, jobs.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+
+	// the second attempt continues where the first one failed
+	var resumed []string
+	runner.Register("resumable", func(ctx context.Context, payload []byte, progress func(done, total int)) (interface{}, error) {
+		resumed = append(resumed, string(jobs.Checkpoint(ctx)))
+		if len(resumed) == 1 {
+			assert.Equal(t, nil, jobs.SaveCheckpoint(ctx, map[string]int{"offset": 3}))
+			return nil, errors.New("temporary failure")
+		}
+		return "done", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	queued, _ := runner.Enqueue(context.Background(), "resumable", nil)
+	job := waitForJob(t, rp, queued.ID)
+	assert.Equal(t, models.JobSucceeded, job.Status)
+	assert.Equal(t, []string{"", `{"offset":3}`}, resumed)
+
+	// outside of jobs there is nothing to save
+	assert.Equal(t, nil, jobs.SaveCheckpoint(context.Background(), 1))
+	assert.Equal(t, []byte(nil), jobs.Checkpoint(context.Background()))
+}
+
+func TestJobRunnerPermanentFailure(t *testing.T) {
+	rp := new(mocks.MockJobRepository)
+	runner := jobs.NewRunner(rp, 1, 10*time.Millisecond, jobs.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+	runner.Register("broken", func(ctx context.Context, payload []byte, progress func(done, total int)) (interface{}, error) {
+		return nil, jobs.Permanent(errors.New("invalid payload"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	queued, _ := runner.Enqueue(context.Background(), "broken", nil)
+	job := waitForJob(t, rp, queued.ID)
+	assert.Equal(t, models.JobFailed, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, "invalid payload", job.Error)
+}
+
+func TestJobRunnerCancel(t *testing.T) {
+	rp := new(mocks.MockJobRepository)
+	runner := jobs.NewRunner(rp, 1, 10*time.Millisecond, jobs.RetryPolicy{MaxAttempts: 1})
+
+	// block until cancelled
+	started := make(chan struct{})
+	runner.Register("endless", func(ctx context.Context, payload []byte, progress func(done, total int)) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	queued, _ := runner.Enqueue(context.Background(), "endless", nil)
+	<-started
+	_, err := runner.Cancel(context.Background(), queued.ID)
+	assert.Equal(t, nil, err)
+
+	job := waitForJob(t, rp, queued.ID)
+	assert.Equal(t, models.JobCancelled, job.Status)
+}
+
+func TestJobRunnerUnknownKind(t *testing.T) {
+	runner := jobs.NewRunner(new(mocks.MockJobRepository), 1, time.Second, jobs.RetryPolicy{})
+	_, err := runner.Enqueue(context.Background(), "missing", nil)
+	assert.Equal(t, jobs.ErrUnknownKind, err)
+}
+
+func TestJobUpdatesHoldTheLease(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	rp := repository.NewJobsRepositoryImpl(db)
+
+	// stale workers cannot overwrite jobs claimed again, cancelled or finished
+	assert.Equal(t, repository.ErrJobLost, rp.Finish(1, 2, models.JobSucceeded, "{}", ""))
+	assert.Equal(t, repository.ErrJobLost, rp.Retry(1, 2, time.Now(), "failed"))
+	assert.Equal(t, repository.ErrJobLost, rp.SaveCheckpoint(1, 2, "{}"))
+	_, err := rp.UpdateProgress(1, 2, 50)
+	assert.Equal(t, repository.ErrJobLost, err)
+	assert.Equal(t, 4, len(recorder.statements))
+	for _, statement := range recorder.statements {
+		assert.Equal(t, true, strings.Contains(statement, `WHERE id = 1 AND status = 'running' AND attempts = 2`))
+	}
+}
+
+func TestJobRequeueStaleRespectsMaxAttempts(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	rp := repository.NewJobsRepositoryImpl(db)
+
+	rp.RequeueStale(time.Now())
+	assert.Equal(t, 3, len(recorder.statements))
+	// used up jobs fail, only the others go back to the queue
+	assert.Equal(t, true, strings.Contains(recorder.statements[1], `"status"='failed'`))
+	assert.Equal(t, true, strings.Contains(recorder.statements[1], `attempts >= max_attempts`))
+	assert.Equal(t, true, strings.Contains(recorder.statements[2], `"status"='queued'`))
+	assert.Equal(t, true, strings.Contains(recorder.statements[2], `attempts < max_attempts`))
+}
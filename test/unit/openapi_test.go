@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/openapi"
+	"go-test/service"
+	"go-test/utils"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	(&service.Service{Config: &utils.Config{}}).RegisterRoutes(r)
+
+	// fails with the list of routes missing in service.RouteDocs
+	_, err := service.OpenAPIDocument(r)
+	assert.Equal(t, nil, err)
+
+	r.GET("/undocumented", func(c *gin.Context) {})
+	_, err = service.OpenAPIDocument(r)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	(&service.Service{Config: &utils.Config{}}).RegisterRoutes(r)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var doc openapi.Document
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	// gin parameters are converted
+	item, ok := doc.Paths["/animals/{id}"]
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "id", (*item)["get"].Parameters[0].Name)
+	assert.NotEqual(t, nil, (*doc.Paths["/animals"])["head"].Responses["200"].Headers["X-Item-Length"].Schema)
+
+	// binding rules become schema constraints
+	animal := doc.Components.Schemas["Animal"]
+	assert.Equal(t, []string{"name"}, animal.Required)
+	assert.Equal(t, 100, *animal.Properties["name"].MaxLength)
+	assert.Equal(t, 6, len(animal.Properties["type"].Enum))
+	assert.NotEqual(t, nil, doc.Components.Schemas["Problem"])
+}
+
+// assertPinnedAssets fails for assets of the page loaded without exact version
+func assertPinnedAssets(t *testing.T, page []byte) {
+	assets := regexp.MustCompile(`(?:src|href)="(https?://[^"]+)"`).FindAllSubmatch(page, -1)
+	assert.NotEqual(t, 0, len(assets))
+	pinned := regexp.MustCompile(`^https://unpkg\.com/[a-z-]+@[0-9]+\.[0-9]+\.[0-9]+/`)
+	for _, asset := range assets {
+		assert.MatchRegex(t, string(asset[1]), pinned)
+	}
+}
+
+func TestDocsPinAssets(t *testing.T) {
+	assertPinnedAssets(t, openapi.UI)
+}
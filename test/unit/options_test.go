@@ -0,0 +1,72 @@
+package unit
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/middleware"
+	"go-test/routers"
+	"go-test/service"
+	"go-test/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func sendOptions(r *gin.Engine, path, accept string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("OPTIONS", path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestOptionsAllow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorMiddleware())
+	(&service.Service{Config: &utils.Config{}}).RegisterRoutes(r)
+
+	for path, allow := range map[string]string{
+		"/animals":                  "GET, HEAD, OPTIONS, POST",
+		"/animals/7":                "DELETE, GET, OPTIONS, PUT",
+		"/v2/animals/7/description": "OPTIONS, PATCH",
+		"/animals/7/shares/3":       "DELETE, OPTIONS",
+		// routed to the record as well, which then rejects the id
+		"/animals/export": "DELETE, GET, OPTIONS, PUT",
+		"/graphql":        "GET, OPTIONS, POST",
+	} {
+		w := sendOptions(r, path, "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, allow, w.Header().Get("Allow"))
+	}
+
+	for _, path := range []string{"/zebras", "/animals/7/unknown", "/v3/animals", "/animals/7/shares/"} {
+		w := sendOptions(r, path, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "", w.Header().Get("Allow"))
+	}
+}
+
+func TestOptionsDescription(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorMiddleware())
+	(&service.Service{Config: &utils.Config{}}).RegisterRoutes(r)
+
+	w := sendOptions(r, "/animals/export", "application/json")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resource routers.ResourceOptions
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &resource))
+	assert.Equal(t, "/animals/export", resource.Path)
+	assert.Equal(t, []string{"DELETE", "GET", "OPTIONS", "PUT"}, resource.Allow)
+	// the static route wins over the record route
+	assert.Equal(t, "Export animals", resource.Operations["GET"].Summary)
+	assert.Equal(t, "Replace an animal", resource.Operations["PUT"].Summary)
+
+	// browsers and other clients accepting anything get no body
+	assert.Equal(t, http.StatusNoContent, sendOptions(r, "/animals", "*/*").Code)
+	assert.Equal(t, http.StatusNoContent, sendOptions(r, "/animals", "application/json;q=0, */*").Code)
+}
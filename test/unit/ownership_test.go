@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/animals"
+	"go-test/auth"
+	dbModels "go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/models"
+	"go-test/problems"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOwnershipActor(t *testing.T) {
+	editor := auth.Principal{Subject: "keeper", Method: auth.MethodJWT, Roles: []string{"editor"}, Groups: []string{"night-shift"}}
+	admin := auth.Principal{Subject: "boss", Method: auth.MethodJWT, Roles: []string{"admin"}}
+
+	// editors are limited to their records, admins and anonymous requests are not
+	actor := animals.ActorOf(auth.WithPrincipal(context.Background(), editor), &auth.DefaultPolicy)
+	assert.Equal(t, repository.Actor{Subject: "keeper", Groups: []string{"night-shift"}}, actor)
+	assert.Equal(t, true, animals.ActorOf(auth.WithPrincipal(context.Background(), admin), &auth.DefaultPolicy).Unrestricted)
+	assert.Equal(t, true, animals.ActorOf(context.Background(), &auth.DefaultPolicy).Unrestricted)
+
+	assert.Equal(t, true, actor.Owns(dbModels.Animal{CreatedBy: "keeper"}))
+	assert.Equal(t, false, actor.Owns(dbModels.Animal{CreatedBy: "vet"}))
+	// records of times without authentication belong to nobody
+	assert.Equal(t, false, repository.Actor{}.Owns(dbModels.Animal{}))
+
+	shares := []dbModels.AnimalShare{{GranteeType: dbModels.ShareUser, Grantee: "vet"}}
+	assert.Equal(t, false, actor.SharedWith(shares))
+	shares = append(shares, dbModels.AnimalShare{GranteeType: dbModels.ShareGroup, Grantee: "night-shift"})
+	assert.Equal(t, true, actor.SharedWith(shares))
+	// a user named like the group is someone else
+	assert.Equal(t, false, actor.SharedWith([]dbModels.AnimalShare{{GranteeType: dbModels.ShareUser, Grantee: "night-shift"}}))
+}
+
+func TestOwnershipColumns(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	rp := repository.NewAnimalsRepositoryImpl(db).ForTenant("shelter-a").As(repository.Actor{Subject: "keeper"})
+
+	rp.Create(models.Animal{Name: "Lion", Type: 1})
+	assert.Equal(t, 1, len(recorder.statements))
+	assert.Equal(t, true, strings.Contains(recorder.statements[0], `"created_by","updated_by"`))
+	assert.Equal(t, true, strings.Contains(recorder.statements[0], `'keeper','keeper'`))
+}
+
+// newShareEngine serves the share routes of the store
+func newShareEngine(mockRepository *mocks.MockRepository) *gin.Engine {
+	rp := repository.AnimalRepository(mockRepository)
+	store := &animals.Store{Mu: &sync.Mutex{}, Repository: &rp, Policy: &auth.DefaultPolicy}
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	r.GET("/animals/:id/shares", func(c *gin.Context) { routers.GetAnimalShares(c, store) })
+	r.POST("/animals/:id/shares", func(c *gin.Context) { routers.ShareAnimal(c, store) })
+	r.DELETE("/animals/:id/shares/:shareId", func(c *gin.Context) { routers.UnshareAnimal(c, store) })
+	return r
+}
+
+func TestShareRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	share := dbModels.AnimalShare{ID: 3, AnimalID: 1, GranteeType: dbModels.ShareGroup, Grantee: "night-shift", CreatedBy: "keeper"}
+	mockRepository.On("Share", uint(1), "group", "night-shift").Return(share, nil)
+	mockRepository.On("Share", uint(2), "user", "vet").Return(dbModels.AnimalShare{}, repository.ErrNotPermitted)
+	mockRepository.On("FindShares", uint(1)).Return([]dbModels.AnimalShare{share}, nil)
+	mockRepository.On("Unshare", uint(1), uint(3)).Return(share, nil)
+	r := newShareEngine(mockRepository)
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/animals/1/shares", models.ShareInput{Type: "group", Name: "night-shift"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created models.Share
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, models.Share{ID: 3, Type: "group", Name: "night-shift", CreatedBy: "keeper"}, created)
+
+	w = send("GET", "/animals/1/shares", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list []models.Share
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, []models.Share{created}, list)
+
+	assert.Equal(t, http.StatusOK, send("DELETE", "/animals/1/shares/3", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/animals/1/shares", models.ShareInput{Type: "team", Name: "night-shift"}).Code)
+
+	// records of others are not shared on
+	w = send("POST", "/animals/2/shares", models.ShareInput{Type: "user", Name: "vet"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var problem problems.Problem
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, problems.TypeForbidden, problem.Type)
+}
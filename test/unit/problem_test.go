@@ -0,0 +1,34 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-playground/assert/v2"
+	"go-test/db-utils/repository"
+	"go-test/problems"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestProblemFromError(t *testing.T) {
+	tests := []struct {
+		err         error
+		status      int
+		problemType string
+	}{
+		{&repository.NotFoundError{Id: 7, When: time.Now()}, http.StatusNotFound, problems.TypeNotFound},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, problems.TypeTimeout},
+		{problems.Cache(errors.New("connection refused")), http.StatusServiceUnavailable, problems.TypeCacheUnavailable},
+		{errors.New("pq: relation does not exist"), http.StatusInternalServerError, problems.TypeInternal},
+	}
+	for _, tt := range tests {
+		problem := problems.From(tt.err)
+		assert.Equal(t, tt.status, problem.Status)
+		assert.Equal(t, tt.problemType, problem.Type)
+	}
+
+	// causes of internal errors must not reach the client
+	assert.Equal(t, "", problems.From(errors.New("pq: password authentication failed")).Detail)
+}
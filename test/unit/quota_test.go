@@ -0,0 +1,189 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/auth"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/models"
+	"go-test/problems"
+	"go-test/quotas"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestQuotas(now time.Time) *quotas.Quotas {
+	var rp repository.QuotaRepository = &mocks.MockQuotaRepository{}
+	return &quotas.Quotas{
+		Repository:  &rp,
+		Plans:       map[string]quotas.Plan{"free": {Daily: 5, Monthly: 6}, "pro": {Daily: 100, Monthly: 0}},
+		DefaultPlan: "free",
+		WarnAt:      0.8,
+		Clock:       func() time.Time { return now },
+	}
+}
+
+// newQuotaEngine serves quota routes, the X-Key header names the API key and
+// X-Role the role of a user
+func newQuotaEngine(q *quotas.Quotas) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.ErrorMiddleware())
+	identify := func(c *gin.Context) {
+		if key := c.GetHeader("X-Key"); key != "" {
+			auth.Set(c, auth.Principal{Subject: "api-key:" + key, Method: auth.MethodAPIKey, Scopes: []string{auth.ScopeRead}})
+		}
+		if role := c.GetHeader("X-Role"); role != "" {
+			auth.Set(c, auth.Principal{Subject: "keeper", Method: auth.MethodJWT, Roles: []string{role}})
+		}
+	}
+	r.GET("/animals", identify, middleware.Quota(q), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/me/usage", identify, func(c *gin.Context) { routers.GetUsage(c, q) })
+	operators := r.Group("/admin/quotas", identify, middleware.Require(&auth.DefaultPolicy, auth.PermQuotas))
+	operators.GET("/:subject", func(c *gin.Context) { routers.GetQuota(c, q) })
+	operators.PUT("/:subject", func(c *gin.Context) { routers.OverrideQuota(c, q) })
+	operators.DELETE("/:subject", func(c *gin.Context) { routers.ResetQuota(c, q) })
+	return r
+}
+
+func sendQuota(r *gin.Engine, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	r := newQuotaEngine(newTestQuotas(now))
+	key := map[string]string{"X-Key": "7"}
+
+	for i := 0; i < 3; i++ {
+		w := sendQuota(r, "GET", "/animals", "", key)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, len(w.Header().Values("Quota-Warning")))
+	}
+	// 4 of 5 daily requests reach the warning share
+	w := sendQuota(r, "GET", "/animals", "", key)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"daily; used=4; limit=5; reset=2026-10-19T00:00:00Z"}, w.Header().Values("Quota-Warning"))
+	w = sendQuota(r, "GET", "/animals", "", key)
+	assert.Equal(t, http.StatusOK, w.Code)
+	// and 5 of 6 monthly ones
+	assert.Equal(t, []string{"daily; used=5; limit=5; reset=2026-10-19T00:00:00Z", "monthly; used=5; limit=6; reset=2026-11-01T00:00:00Z"},
+		w.Header().Values("Quota-Warning"))
+
+	w = sendQuota(r, "GET", "/animals", "", key)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "43200", w.Header().Get("Retry-After"))
+	var problem problems.Problem
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, problems.TypeQuotaExceeded, problem.Type)
+
+	// other keys and tenants have quotas of their own
+	assert.Equal(t, http.StatusOK, sendQuota(r, "GET", "/animals", "", map[string]string{"X-Key": "8"}).Code)
+	assert.Equal(t, http.StatusOK, sendQuota(r, "GET", "/animals", "", nil).Code)
+
+	// usage stays readable once the quota is spent
+	w = sendQuota(r, "GET", "/me/usage", "", key)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var usage models.Usage
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, "api-key:7", usage.Subject)
+	assert.Equal(t, "free", usage.Plan)
+	// refused requests are not counted
+	assert.Equal(t, int64(5), usage.Daily.Used)
+	assert.Equal(t, int64(0), usage.Daily.Remaining)
+	assert.Equal(t, int64(6), usage.Monthly.Limit)
+	assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), usage.Monthly.ResetsAt)
+}
+
+func TestQuotaOverrides(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newQuotaEngine(newTestQuotas(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)))
+	operator := map[string]string{"X-Role": "operator"}
+	for i := 0; i < 6; i++ {
+		sendQuota(r, "GET", "/animals", "", map[string]string{"X-Key": "7"})
+	}
+
+	// tenant admins may not change quotas
+	w := sendQuota(r, "PUT", "/admin/quotas/api-key:7", `{"plan":"pro"}`, map[string]string{"X-Role": "admin"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = sendQuota(r, "PUT", "/admin/quotas/api-key:7", `{"plan":"platinum"}`, operator)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = sendQuota(r, "PUT", "/admin/quotas/user:7", `{"plan":"pro"}`, operator)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = sendQuota(r, "PUT", "/admin/quotas/api-key:7", `{"plan":"pro","monthly_limit":50}`, operator)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var usage models.Usage
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, "pro", usage.Plan)
+	assert.Equal(t, true, usage.Overridden)
+	assert.Equal(t, int64(100), usage.Daily.Limit)
+	assert.Equal(t, int64(50), usage.Monthly.Limit)
+	assert.Equal(t, http.StatusOK, sendQuota(r, "GET", "/animals", "", map[string]string{"X-Key": "7"}).Code)
+
+	// resets keep the counted requests
+	w = sendQuota(r, "DELETE", "/admin/quotas/api-key:7", "", operator)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, "free", usage.Plan)
+	assert.Equal(t, false, usage.Overridden)
+	assert.Equal(t, int64(6), usage.Daily.Used)
+	assert.Equal(t, http.StatusTooManyRequests, sendQuota(r, "GET", "/animals", "", map[string]string{"X-Key": "7"}).Code)
+
+	// routes are missing while quotas are off
+	r = newQuotaEngine(nil)
+	assert.Equal(t, http.StatusNotFound, sendQuota(r, "GET", "/admin/quotas/tenant:zoo", "", operator).Code)
+}
+
+func TestQuotaCountsAreAtomic(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	rp := repository.NewQuotasRepositoryImpl(db)
+	rp.Consume("api-key:7", 1, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), 5, 6)
+	// one statement checks the limits and adds to the counters in the database
+	assert.Equal(t, 1, len(recorder.statements))
+	assert.Equal(t, true, strings.Contains(recorder.statements[0], `FOR UPDATE`))
+	assert.Equal(t, true, strings.Contains(recorder.statements[0], `COALESCE((SELECT count FROM current WHERE period = 'day'), 0) + 1 <= 5`))
+	assert.Equal(t, true, strings.Contains(recorder.statements[0], `ON CONFLICT (subject, period, start) DO UPDATE SET count = quota_usages.count + EXCLUDED.count`))
+	assert.Equal(t, true, strings.Contains(recorder.statements[0], `RETURNING period, count`))
+}
+
+func TestQuotaCache(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	q := newTestQuotas(now)
+	q.Clock = func() time.Time { return now }
+	rp := (*q.Repository).(*mocks.MockQuotaRepository)
+	ctx := context.Background()
+
+	// the quota is read once, not on every request
+	for i := 0; i < 3; i++ {
+		_, err := q.Consume(ctx, "api-key:7")
+		assert.Equal(t, nil, err)
+	}
+	assert.Equal(t, 1, rp.Finds)
+	now = now.Add(time.Minute)
+	q.Consume(ctx, "api-key:7")
+	assert.Equal(t, 2, rp.Finds)
+
+	// overrides take effect right away on the replica
+	daily := int64(4)
+	q.Override(ctx, "api-key:7", "", &daily, nil, "operator")
+	usage, err := q.Consume(ctx, "api-key:7")
+	assert.Equal(t, quotas.ErrExceeded, err)
+	assert.Equal(t, int64(4), usage.Daily.Used)
+	assert.Equal(t, int64(4), usage.Daily.Limit)
+}
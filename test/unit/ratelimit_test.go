@@ -0,0 +1,202 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"go-test/auth"
+	"go-test/middleware"
+	"go-test/problems"
+	"go-test/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(t *testing.T) (*ratelimit.Limiter, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	server.SetTime(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	return &ratelimit.Limiter{Redis: redis.NewClient(&redis.Options{Addr: server.Addr()}), Prefix: "ratelimit:"}, server
+}
+
+func TestRateLimitGCRA(t *testing.T) {
+	ctx := context.Background()
+	limiter, server := newTestLimiter(t)
+	limit := ratelimit.Limit{Rate: 60, Period: time.Minute, Burst: 3}
+
+	// the burst is available at once
+	for remaining := 2; remaining >= 0; remaining-- {
+		result, err := limiter.Allow(ctx, "ip:10.0.0.1", limit, 1)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+	result, err := limiter.Allow(ctx, "ip:10.0.0.1", limit, 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.ResetAfter)
+
+	// other replicas draw on the same budget
+	replica := &ratelimit.Limiter{Redis: redis.NewClient(&redis.Options{Addr: server.Addr()}), Prefix: "ratelimit:"}
+	result, _ = replica.Allow(ctx, "ip:10.0.0.1", limit, 1)
+	assert.Equal(t, false, result.Allowed)
+	result, _ = replica.Allow(ctx, "ip:10.0.0.2", limit, 1)
+	assert.Equal(t, true, result.Allowed)
+
+	// one request is earned back per second
+	server.SetTime(time.Date(2026, 10, 18, 12, 0, 1, 0, time.UTC))
+	result, _ = limiter.Allow(ctx, "ip:10.0.0.1", limit, 1)
+	assert.Equal(t, true, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// costly requests wait for their whole cost
+	server.SetTime(time.Date(2026, 10, 18, 12, 0, 2, 0, time.UTC))
+	result, _ = limiter.Allow(ctx, "ip:10.0.0.1", limit, 2)
+	assert.Equal(t, false, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	server.SetTime(time.Date(2026, 10, 18, 12, 0, 3, 0, time.UTC))
+	result, _ = limiter.Allow(ctx, "ip:10.0.0.1", limit, 2)
+	assert.Equal(t, true, result.Allowed)
+	// costs beyond the burst take all of it
+	server.SetTime(time.Date(2026, 10, 18, 12, 1, 0, 0, time.UTC))
+	result, _ = limiter.Allow(ctx, "ip:10.0.0.1", limit, 50)
+	assert.Equal(t, true, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	_, err = limiter.Allow(ctx, "ip:10.0.0.1", ratelimit.Limit{}, 1)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestRateLimitRules(t *testing.T) {
+	imports := ratelimit.PerMinute(10)
+	rules := ratelimit.Rules{
+		Default: ratelimit.PerMinute(100),
+		Routes: map[string]ratelimit.Rule{
+			"POST /batch":          {Cost: 10},
+			"POST /animals/import": {Limit: &imports, Cost: 2},
+		},
+	}
+	bucket, limit, cost := rules.For("GET", "/animals/:id")
+	assert.Equal(t, "default", bucket)
+	assert.Equal(t, 100, limit.Rate)
+	assert.Equal(t, 1, cost)
+	bucket, _, cost = rules.For("POST", "/batch")
+	assert.Equal(t, "default", bucket)
+	assert.Equal(t, 10, cost)
+	// versions share the rule, and imports have their own budget
+	bucket, limit, cost = rules.For("POST", "/v2/animals/import")
+	assert.Equal(t, "POST /animals/import", bucket)
+	assert.Equal(t, 10, limit.Rate)
+	assert.Equal(t, 2, cost)
+}
+
+// newRateLimitEngine serves routes of the rules, the X-User header names the user
+func newRateLimitEngine(limiter *ratelimit.Limiter, rules ratelimit.Rules) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.ErrorMiddleware())
+	identify := func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			auth.Set(c, auth.Principal{Subject: user, Method: auth.MethodJWT})
+		}
+	}
+	handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/animals", identify, middleware.RateLimit(limiter, rules), handler)
+	r.POST("/batch", identify, middleware.RateLimit(limiter, rules), handler)
+	return r
+}
+
+func sendRateLimited(r *gin.Engine, method, path, user string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	req.RemoteAddr = "192.0.2.1:4711"
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, server := newTestLimiter(t)
+	rules := ratelimit.Rules{
+		Default: ratelimit.Limit{Rate: 60, Period: time.Minute, Burst: 5},
+		Routes:  map[string]ratelimit.Rule{"POST /batch": {Cost: 4}},
+	}
+	r := newRateLimitEngine(limiter, rules)
+
+	w := sendRateLimited(r, "GET", "/animals", "keeper")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "5", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "4", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "60;w=60;burst=5", w.Header().Get("RateLimit-Policy"))
+
+	// bulk operations spend more of the budget
+	w = sendRateLimited(r, "POST", "/batch", "keeper")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = sendRateLimited(r, "GET", "/animals", "keeper")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	var problem problems.Problem
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, problems.TypeRateLimited, problem.Type)
+
+	// users and addresses have budgets of their own
+	assert.Equal(t, http.StatusOK, sendRateLimited(r, "GET", "/animals", "vet").Code)
+	assert.Equal(t, http.StatusOK, sendRateLimited(r, "GET", "/animals", "").Code)
+	assert.Equal(t, []string{"ratelimit:default:ip:192.0.2.1", "ratelimit:default:user::keeper", "ratelimit:default:user::vet"}, server.Keys())
+
+	// requests pass while Redis is down
+	server.Close()
+	w = sendRateLimited(r, "GET", "/animals", "keeper")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, server := newTestLimiter(t)
+	rules := ratelimit.Rules{Default: ratelimit.Limit{Rate: 60, Period: time.Minute, Burst: 2}}
+	secret := []byte("rate-limit-secret")
+	verifier := &auth.Verifier{Keys: auth.HMACSecret(secret)}
+
+	r := gin.New()
+	r.Use(middleware.ErrorMiddleware())
+	r.GET("/animals", middleware.RateLimitAuthentication(limiter, rules, middleware.Authenticate(verifier, nil)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	send := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/animals", nil)
+		req.RemoteAddr = "192.0.2.1:4711"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	valid := signToken(t, jwt.SigningMethodHS256, "", secret, validClaims("keeper"))
+
+	// valid credentials leave the budget of the address alone
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, send(valid).Code)
+	}
+	assert.Equal(t, []string{}, server.Keys())
+
+	// missing and wrong credentials spend it, then the address is refused before authenticating
+	assert.Equal(t, http.StatusUnauthorized, send("").Code)
+	assert.Equal(t, http.StatusUnauthorized, send("guessed").Code)
+	w := send(valid)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, []string{"ratelimit:default:ip:192.0.2.1"}, server.Keys())
+}
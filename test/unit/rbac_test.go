@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go-test/auth"
+	"go-test/middleware"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newRBACEngine serves routes of every kind of permission behind bearer tokens
+func newRBACEngine(secret []byte, policy *auth.Policy) *gin.Engine {
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	protected := r.Group("", middleware.Authenticate(&auth.Verifier{Keys: auth.HMACSecret(secret)}, nil))
+	ok := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+	protected.GET("/animals", middleware.Require(policy, auth.PermRead), ok)
+	protected.POST("/animals", middleware.Require(policy, auth.PermCreate), ok)
+	protected.DELETE("/animals/:id", middleware.Require(policy, auth.PermDelete), ok)
+	protected.GET("/admin/api-keys", middleware.Require(policy, auth.PermAdmin), ok)
+	return r
+}
+
+func TestRBACRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := []byte("test-secret")
+	r := newRBACEngine(secret, &auth.DefaultPolicy)
+
+	requests := []struct {
+		method, path string
+	}{
+		{"GET", "/animals"},
+		{"POST", "/animals"},
+		{"DELETE", "/animals/1"},
+		{"GET", "/admin/api-keys"},
+	}
+	// expected status of every request by role
+	expected := map[string][]int{
+		"viewer":  {http.StatusOK, http.StatusForbidden, http.StatusForbidden, http.StatusForbidden},
+		"editor":  {http.StatusOK, http.StatusOK, http.StatusOK, http.StatusForbidden},
+		"admin":   {http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK},
+		"unknown": {http.StatusForbidden, http.StatusForbidden, http.StatusForbidden, http.StatusForbidden},
+	}
+	for role, statuses := range expected {
+		claims := validClaims("keeper")
+		claims["roles"] = []string{role}
+		token := signToken(t, jwt.SigningMethodHS256, "", secret, claims)
+		for i, request := range requests {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(request.method, request.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, statuses[i], w.Code)
+		}
+	}
+}
+
+func TestRBACPolicyFile(t *testing.T) {
+	// the shipped policy is the built-in one
+	policy, err := auth.LoadPolicy("../../policy.json")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, auth.DefaultPolicy, policy)
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	assert.Equal(t, nil, os.WriteFile(path, []byte(`{"roles": {"viewer": ["read", "fly"]}}`), 0600))
+	_, err = auth.LoadPolicy(path)
+	assert.NotEqual(t, nil, err)
+
+	// API keys are granted permissions by their scopes, not by roles
+	key := auth.Principal{Method: auth.MethodAPIKey, Scopes: []string{auth.ScopeWrite}, Roles: []string{"admin"}}
+	assert.Equal(t, true, auth.DefaultPolicy.Allows(key, auth.PermDelete))
+	assert.Equal(t, false, auth.DefaultPolicy.Allows(key, auth.PermAdmin))
+}
@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/proto/animalpb"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// newAnimalListEngine serves the animal list behind content negotiation
+func newAnimalListEngine() (*gin.Engine, *mocks.MockRepository) {
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("FindAll").Return([]models.Animal{
+		{ID: 1, Name: "Lion", Type: 3, Description: "King of the jungle"},
+	}, nil)
+
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	var mu sync.Mutex
+	rp := repository.AnimalRepository(mockRepository)
+	r.GET("/animals", routers.Negotiate(), func(c *gin.Context) {
+		routers.GetAnimals(c, &mu, &rp)
+	})
+	return r, mockRepository
+}
+
+func TestGetAnimalsXML(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, _ := newAnimalListEngine()
+
+	req, _ := http.NewRequest("GET", "/animals", nil)
+	req.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `<animals><animal><id>1</id><data><name>Lion</name><type>3</type><description>King of the jungle</description></data></animal></animals>`, w.Body.String())
+}
+
+func TestGetAnimalsProtobuf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, _ := newAnimalListEngine()
+
+	req, _ := http.NewRequest("GET", "/animals", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list animalpb.AnimalList
+	assert.Equal(t, nil, proto.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 1, len(list.Animals))
+	assert.Equal(t, int64(1), list.Animals[0].GetId())
+	assert.Equal(t, "Lion", list.Animals[0].GetData().GetName())
+}
+
+func TestGetAnimalsProtobufNotAcceptable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, mockRepository := newAnimalListEngine()
+	var mu sync.Mutex
+	rp := repository.AnimalRepository(mockRepository)
+	r.GET("/v2/animals", routers.Version(2, nil), routers.Negotiate(), func(c *gin.Context) {
+		routers.GetAnimals(c, &mu, &rp)
+	})
+
+	// v2 records and sparse fieldsets have no protobuf schema
+	for _, path := range []string{"/v2/animals", "/animals?fields=name", "/animals?include=type"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Accept", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	}
+	mockRepository.AssertNotCalled(t, "FindAll")
+}
+
+func TestGetAnimalsNotAcceptable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r, mockRepository := newAnimalListEngine()
+
+	req, _ := http.NewRequest("GET", "/animals", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// rejected before querying the database
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	mockRepository.AssertNotCalled(t, "FindAll")
+}
+
+func TestUpdateAnimalDescriptionProtobuf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("UpdateDescription", uint(1), "King of the jungle").
+		Return(models.Animal{ID: 1, Name: "Lion", Type: 3, Description: "King of the jungle"}, nil)
+
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	store := newGRPCStore(mockRepository)
+	r.PATCH("/animals/:id/description", routers.Negotiate(), func(c *gin.Context) {
+		routers.UpdateAnimalDescription(c, store)
+	})
+
+	// the body is normalized like those of the other formats
+	body, _ := proto.Marshal(&animalpb.AnimalDescription{Description: " King of the jungle\n"})
+	req, _ := http.NewRequest("PATCH", "/animals/1/description", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Accept", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var animal animalpb.AnimalWithID
+	assert.Equal(t, nil, proto.Unmarshal(w.Body.Bytes(), &animal))
+	assert.Equal(t, "King of the jungle", animal.GetData().GetDescription())
+	mockRepository.AssertExpectations(t)
+}
@@ -0,0 +1,168 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/redis/go-redis/v9"
+	dbutils "go-test/db-utils"
+	dbModels "go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/jobs"
+	"go-test/middleware"
+	"go-test/models"
+	"go-test/problems"
+	"go-test/requestid"
+	"go-test/test/mocks"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRequestIDEngine serves a route which fails, access logs are written to out
+func newRequestIDEngine(out *bytes.Buffer) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.RequestID(), gin.LoggerWithConfig(gin.LoggerConfig{Formatter: middleware.AccessLog, Output: out}))
+	r.Use(middleware.ErrorMiddleware())
+	r.GET("/animals/:id", func(c *gin.Context) {
+		c.Error(problems.NotFound("Record 1 not found"))
+	})
+	return r
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	r := newRequestIDEngine(&out)
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	// ids of clients are echoed, in problems too
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/animals/1", nil)
+	req.Header.Set("X-Request-ID", "ticket-4711")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "ticket-4711", w.Header().Get("X-Request-ID"))
+	var problem problems.Problem
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "ticket-4711", problem.RequestID)
+	assert.Equal(t, true, strings.Contains(out.String(), "request_id=ticket-4711"))
+
+	// missing and malformed ids are replaced
+	for _, id := range []string{"", "*/ DROP TABLE animals; /*", strings.Repeat("a", 129)} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/animals/1", nil)
+		req.Header.Set("X-Request-ID", id)
+		r.ServeHTTP(w, req)
+		generated := w.Header().Get("X-Request-ID")
+		assert.MatchRegex(t, generated, uuid)
+		assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, generated, problem.RequestID)
+	}
+	assert.NotEqual(t, requestid.New(), requestid.New())
+}
+
+func TestRequestIDLogs(t *testing.T) {
+	var out bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&out)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	}()
+
+	ctx := requestid.WithID(context.Background(), "ticket-4711")
+	requestid.Printf(ctx, "quota: %v", "connection refused")
+	requestid.Printf(context.Background(), "quota: %v", "connection refused")
+	assert.Equal(t, "request_id=ticket-4711 quota: connection refused\nquota: connection refused\n", out.String())
+
+	// failed Redis calls name the request, missing keys are no failure
+	out.Reset()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	client.AddHook(dbutils.RequestLogger{})
+	client.Set(ctx, "animals:1", "lion", 0)
+	client.Get(ctx, "animals:2")
+	client.HGet(ctx, "animals:1", "full")
+	client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, "animals:1", "full", "lion")
+		return nil
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, true, strings.HasPrefix(lines[0], "request_id=ticket-4711 redis: hget: WRONGTYPE"))
+	assert.Equal(t, true, strings.HasPrefix(lines[1], "request_id=ticket-4711 redis: hset: WRONGTYPE"))
+
+	// in debug mode every command is logged, with its key but not its value
+	out.Reset()
+	debug := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	debug.AddHook(dbutils.RequestLogger{Commands: true})
+	debug.Set(ctx, "animals:3", "eagle", 0)
+	debug.Get(ctx, "animals:3")
+	assert.Equal(t, "request_id=ticket-4711 redis: set [animals:3]\nrequest_id=ticket-4711 redis: get [animals:3]\n", out.String())
+}
+
+func TestRequestIDJobs(t *testing.T) {
+	var out bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&out)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	}()
+
+	rp := new(mocks.MockJobRepository)
+	runner := jobs.NewRunner(rp, 1, 10*time.Millisecond, jobs.RetryPolicy{MaxAttempts: 1})
+	seen := make(chan string, 1)
+	runner.Register("broken", func(ctx context.Context, payload []byte, progress func(done, total int)) (interface{}, error) {
+		seen <- requestid.FromContext(ctx)
+		return nil, jobs.Permanent(errors.New("invalid payload"))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	// the job runs with the id of the request which enqueued it
+	queued, _ := runner.Enqueue(requestid.WithID(context.Background(), "ticket-4711"), "broken", nil)
+	assert.Equal(t, "ticket-4711", queued.RequestID)
+	job := waitForJob(t, rp, queued.ID)
+	assert.Equal(t, dbModels.JobFailed, job.Status)
+	assert.Equal(t, "ticket-4711", <-seen)
+	assert.Equal(t, true, strings.Contains(out.String(), "request_id=ticket-4711 Job 1 (broken) failed: invalid payload"))
+}
+
+func TestRequestIDQueryComments(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	assert.Equal(t, nil, db.Use(dbutils.RequestComments{}))
+	ctx := requestid.WithID(context.Background(), "ticket-4711")
+
+	rp := repository.NewAnimalsRepositoryImpl(db).ForTenant("shelter-a")
+	rp.WithContext(ctx).FindAll()
+	rp.WithContext(ctx).Create(models.Animal{Name: "Lion", Type: 1})
+	rp.WithContext(ctx).UpdateDescription(1, "King")
+	rp.WithContext(ctx).Rows()
+	db.WithContext(ctx).Exec("SELECT 1")
+	repository.NewQuotasRepositoryImpl(db).WithContext(ctx).Delete("tenant:shelter-a")
+	for _, statement := range recorder.statements {
+		assert.Equal(t, true, strings.HasPrefix(statement, "/* request_id=ticket-4711 */ "))
+	}
+	assert.Equal(t, true, len(recorder.statements) >= 6)
+
+	// statements outside of requests stay as they are
+	recorder.statements = nil
+	rp.FindAll()
+	db.Exec("SELECT 1")
+	for _, statement := range recorder.statements {
+		assert.Equal(t, false, strings.Contains(statement, "request_id"))
+	}
+}
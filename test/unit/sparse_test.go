@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/redis/go-redis/v9"
+	"go-test/animals"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/routers"
+	"go-test/tenants"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// newSparseEngine serves the animal list and records without version routing
+func newSparseEngine(store *animals.Store) *gin.Engine {
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	r.GET("/v2/animals", routers.Version(2, nil), routers.Negotiate(), func(c *gin.Context) {
+		routers.GetAnimals(c, store.Mu, store.Repository)
+	})
+	r.GET("/animals/:id", routers.Negotiate(), func(c *gin.Context) {
+		routers.GetAnimalByID(c, store)
+	})
+	return r
+}
+
+func newSparseStore(mockRepository *mocks.MockRepository) *animals.Store {
+	rp := repository.AnimalRepository(mockRepository)
+	return &animals.Store{Mu: &sync.Mutex{}, Repository: &rp}
+}
+
+func TestSparseFieldsList(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("FindAllColumns", []string{"id", "name"}).Return([]models.Animal{
+		{ID: 1, Name: "Lion"},
+	}, nil)
+	r := newSparseEngine(newSparseStore(mockRepository))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v2/animals?fields=name", nil)
+	r.ServeHTTP(w, req)
+
+	// only the selected columns are read and rendered
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `[{"id":1,"name":"Lion"}]`, w.Body.String())
+	mockRepository.AssertNotCalled(t, "FindAll")
+}
+
+func TestSparseIncludeType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	// the type column is read for the embedded resource
+	mockRepository.On("FindByIDColumns", uint(1), []string{"id", "name", "type"}).Return(models.Animal{
+		ID: 1, Name: "Lion", Type: 1,
+	}, nil)
+	r := newSparseEngine(newSparseStore(mockRepository))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/animals/1?fields=name&include=type", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		ID       int               `json:"id"`
+		Data     map[string]string `json:"data"`
+		Embedded struct {
+			Type struct {
+				Code int    `json:"code"`
+				Name string `json:"name"`
+			} `json:"type"`
+		} `json:"_embedded"`
+	}
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, map[string]string{"name": "Lion"}, body.Data)
+	assert.Equal(t, 1, body.Embedded.Type.Code)
+	assert.NotEqual(t, "", body.Embedded.Type.Name)
+}
+
+func TestSparseXML(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("FindByIDColumns", uint(1), []string{"id", "name"}).Return(models.Animal{
+		ID: 1, Name: "Lion",
+	}, nil)
+	r := newSparseEngine(newSparseStore(mockRepository))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/animals/1?fields=name", nil)
+	req.Header.Set("Accept", "application/xml")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `<animal><id>1</id><data><name>Lion</name></data></animal>`, w.Body.String())
+}
+
+func TestSparseInvalidParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepository := new(mocks.MockRepository)
+	r := newSparseEngine(newSparseStore(mockRepository))
+
+	for _, query := range []string{"fields=name,age", "include=tags"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/animals/1?"+query, nil)
+		r.ServeHTTP(w, req)
+
+		// rejected before querying the database
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+	mockRepository.AssertNotCalled(t, "FindByID")
+}
+
+func TestSparseCacheProjections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := miniredis.RunT(t)
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("FindByIDColumns", uint(1), []string{"id", "name"}).Return(models.Animal{ID: 1, Name: "Lion"}, nil).Once()
+	mockRepository.On("FindByIDColumns", uint(1), []string{"id", "description"}).Return(models.Animal{ID: 1, Description: "King"}, nil).Once()
+	mockRepository.On("Delete", uint(1)).Return(models.Animal{ID: 1, Name: "Lion"}, nil)
+	store := newSparseStore(mockRepository)
+	store.Redis = redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	ctx := tenants.WithTenant(context.Background(), tenants.Default)
+	for i := 0; i < 2; i++ {
+		// second reads are served from the cache
+		name, err := store.GetColumns(ctx, 1, []string{"id", "name"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Lion", name.Animal.Name)
+		assert.Equal(t, "", name.Animal.Description)
+		description, err := store.GetColumns(ctx, 1, []string{"id", "description"})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "", description.Animal.Name)
+		assert.Equal(t, "King", description.Animal.Description)
+	}
+	mockRepository.AssertNumberOfCalls(t, "FindByIDColumns", 2)
+	projections, err := server.HKeys("default:1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"id,description", "id,name"}, projections)
+
+	// changes invalidate every projection
+	_, err = store.Delete(ctx, 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, server.Exists("default:1"))
+}
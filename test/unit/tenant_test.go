@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"go-test/animals"
+	"go-test/auth"
+	dbModels "go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	"go-test/models"
+	"go-test/tenants"
+	"go-test/test/mocks"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statementRecorder - gorm logger collecting the SQL of every statement
+type statementRecorder struct {
+	statements []string
+}
+
+func (r *statementRecorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *statementRecorder) Info(context.Context, string, ...interface{})  {}
+func (r *statementRecorder) Warn(context.Context, string, ...interface{})  {}
+func (r *statementRecorder) Error(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// newDryRunDB builds statements without ever connecting to Postgres
+func newDryRunDB(t *testing.T) (*gorm.DB, *statementRecorder) {
+	recorder := &statementRecorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, recorder
+}
+
+func TestTenantScopedQueries(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	rp := repository.NewAnimalsRepositoryImpl(db).ForTenant("shelter-a")
+
+	rp.FindAll()
+	rp.GetCount()
+	rp.FindByID(1)
+	rp.FindByIDs([]uint{1, 2})
+	rp.FindAllColumns([]string{"id", "name"})
+	rp.FindPage(nil, 20, 10)
+	rp.FindByIDColumns(1, []string{"id", "name"})
+	rp.Rows()
+	rp.Replace(1, models.Animal{Name: "Lion", Type: 1})
+	rp.Delete(1)
+	rp.UpdateDescription(1, "King")
+	rp.Import(models.ImportRecord{ExternalID: "lion-1"}, repository.ImportKeyExternalID)
+	rp.Create(models.Animal{Name: "Lion", Type: 1})
+	// updates of loaded records, which dry runs never find
+	db.Scopes(repository.TenantScope("shelter-a")).Save(&dbModels.Animal{ID: 1, TenantID: "shelter-a"})
+
+	assert.Equal(t, 15, len(recorder.statements))
+	for _, statement := range recorder.statements {
+		switch {
+		case strings.HasPrefix(statement, "INSERT"):
+			assert.Equal(t, true, strings.Contains(statement, `("tenant_id",`))
+			assert.Equal(t, true, strings.Contains(statement, `VALUES ('shelter-a',`))
+		default:
+			// reads and updates of other tenants' rows cannot match
+			assert.Equal(t, true, strings.Contains(statement, `"animals"."tenant_id" = 'shelter-a'`))
+		}
+	}
+}
+
+func TestAnimalPageQuery(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	rp := repository.NewAnimalsRepositoryImpl(db).ForTenant("shelter-a")
+
+	// pages are cut by the database, not in memory
+	rp.FindPage([]string{"id", "name"}, 20, 10)
+	assert.Equal(t, 1, len(recorder.statements))
+	assert.Equal(t, true, strings.HasPrefix(recorder.statements[0], `SELECT "id","name" FROM "animals"`))
+	assert.Equal(t, true, strings.HasSuffix(recorder.statements[0], `ORDER BY id LIMIT 10 OFFSET 20`))
+}
+
+func TestTenantUnboundRepository(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	rp := repository.NewAnimalsRepositoryImpl(db)
+
+	// nothing reaches the database without tenant
+	_, err := rp.FindAll()
+	assert.Equal(t, repository.ErrNoTenant, err)
+	_, err = rp.FindByID(1)
+	assert.Equal(t, repository.ErrNoTenant, err)
+	_, err = rp.Create(models.Animal{Name: "Lion", Type: 1})
+	assert.Equal(t, repository.ErrNoTenant, err)
+	_, err = rp.ForTenant("").GetCount()
+	assert.Equal(t, repository.ErrNoTenant, err)
+	assert.Equal(t, 0, len(recorder.statements))
+
+	// jobs and keys are listed per tenant, the workers see all of them
+	jobs := repository.NewJobsRepositoryImpl(db)
+	jobs.ForTenant("shelter-a").FindAll(repository.JobFilter{})
+	jobs.ForTenant("shelter-a").FindByID(1)
+	repository.NewAPIKeysRepositoryImpl(db).ForTenant("shelter-a").FindAll()
+	assert.Equal(t, 3, len(recorder.statements))
+	for _, statement := range recorder.statements {
+		assert.Equal(t, true, strings.Contains(statement, `"tenant_id" = 'shelter-a'`))
+	}
+	recorder.statements = nil
+	jobs.FindAll(repository.JobFilter{})
+	assert.Equal(t, false, strings.Contains(recorder.statements[0], "tenant_id"))
+}
+
+func TestTenantCacheNamespaces(t *testing.T) {
+	server := miniredis.RunT(t)
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("FindByIDColumns", uint(1), []string{"id", "name"}).Return(dbModels.Animal{ID: 1, Name: "Lion"}, nil).Once()
+	mockRepository.On("FindByIDColumns", uint(1), []string{"id", "name"}).Return(dbModels.Animal{ID: 1, Name: "Tiger"}, nil).Once()
+	rp := repository.AnimalRepository(mockRepository)
+	store := &animals.Store{Mu: &sync.Mutex{}, Repository: &rp, Redis: redis.NewClient(&redis.Options{Addr: server.Addr()})}
+
+	a := tenants.WithTenant(context.Background(), "shelter-a")
+	b := tenants.WithTenant(context.Background(), "shelter-b")
+	lion, err := store.GetColumns(a, 1, []string{"id", "name"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Lion", lion.Animal.Name)
+
+	// record 1 of the other tenant is not served from the cache
+	tiger, err := store.GetColumns(b, 1, []string{"id", "name"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Tiger", tiger.Animal.Name)
+	lion, err = store.GetColumns(a, 1, []string{"id", "name"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Lion", lion.Animal.Name)
+	mockRepository.AssertNumberOfCalls(t, "FindByIDColumns", 2)
+	assert.Equal(t, []string{"shelter-a:1", "shelter-b:1"}, server.Keys())
+}
+
+// newTenantEngine serves the tenant of the request
+func newTenantEngine(handlers ...gin.HandlerFunc) *gin.Engine {
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	handlers = append(handlers, middleware.Tenant(), func(c *gin.Context) {
+		c.String(http.StatusOK, tenants.FromContext(c.Request.Context()))
+	})
+	r.GET("/tenant", handlers...)
+	return r
+}
+
+func getTenant(r *gin.Engine, token, tenant string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tenant", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if tenant != "" {
+		req.Header.Set(tenants.Header, tenant)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestTenantResolution(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := []byte("test-secret")
+	r := newTenantEngine(middleware.Authenticate(&auth.Verifier{Keys: auth.HMACSecret(secret)}, nil))
+	claims := validClaims("keeper")
+	claims["tenant"] = "shelter-a"
+	token := signToken(t, jwt.SigningMethodHS256, "", secret, claims)
+
+	// the token decides, the header may only repeat it
+	w := getTenant(r, token, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "shelter-a", w.Body.String())
+	assert.Equal(t, http.StatusOK, getTenant(r, token, "shelter-a").Code)
+	assert.Equal(t, http.StatusForbidden, getTenant(r, token, "shelter-b").Code)
+
+	// tokens without tenant belong to the default one
+	untenanted := signToken(t, jwt.SigningMethodHS256, "", secret, validClaims("keeper"))
+	assert.Equal(t, tenants.Default, getTenant(r, untenanted, "").Body.String())
+	assert.Equal(t, http.StatusForbidden, getTenant(r, untenanted, "shelter-b").Code)
+
+	// without authentication the header chooses
+	anonymous := newTenantEngine()
+	assert.Equal(t, "shelter-b", getTenant(anonymous, "", "shelter-b").Body.String())
+	assert.Equal(t, tenants.Default, getTenant(anonymous, "", "").Body.String())
+	assert.Equal(t, http.StatusBadRequest, getTenant(anonymous, "", "Shelter B").Code)
+}
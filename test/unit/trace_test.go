@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/middleware"
+	"go-test/service"
+	"go-test/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTraceEngine dispatches TRACE like main does
+func newTraceEngine(enabled bool) *gin.Engine {
+	s := &service.Service{Config: &utils.Config{TraceEnabled: enabled}}
+	r := gin.New()
+	r.Use(middleware.ErrorMiddleware())
+	trace := s.Trace(r)
+	r.Use(func(c *gin.Context) {
+		if c.Request.Method == http.MethodTrace {
+			trace(c)
+		}
+	})
+	r.NoRoute(middleware.NotFoundHandler)
+	r.GET("/animals", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/animals", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	return r
+}
+
+func sendTrace(r *gin.Engine, target string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodTrace, target, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestTraceReflectsRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newTraceEngine(true)
+
+	w := sendTrace(r, "/zebras/1?fields=name", map[string]string{
+		"Accept":              "*/*",
+		"Authorization":       "Bearer secret",
+		"Proxy-Authorization": "Basic c2VjcmV0",
+		"Cookie":              "session=secret",
+		"X-API-Key":           "ak_0_secret",
+		"Via":                 "1.0 fred",
+		"Max-Forwards":        "0",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "message/http", w.Header().Get("Content-Type"))
+	assert.Equal(t, "TRACE /zebras/1?fields=name HTTP/1.1\r\n"+
+		"Host: example.com\r\n"+
+		"Accept: */*\r\n"+
+		"Max-Forwards: 0\r\n"+
+		"Via: 1.0 fred, 1.1 animals-api\r\n"+
+		"\r\n", w.Body.String())
+	assert.Equal(t, false, strings.Contains(w.Body.String(), "secret"))
+}
+
+func TestTraceRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// off unless configured
+	w := sendTrace(newTraceEngine(false), "/animals", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	// with the methods the URL is served by
+	assert.Equal(t, "GET, OPTIONS, POST", w.Header().Get("Allow"))
+	assert.Equal(t, http.StatusNotFound, sendTrace(newTraceEngine(false), "/zebras", nil).Code)
+
+	r := newTraceEngine(true)
+	assert.Equal(t, http.StatusBadRequest, sendTrace(r, "/animals", map[string]string{"Max-Forwards": "-1"}).Code)
+	assert.Equal(t, http.StatusBadRequest, sendTrace(r, "/animals", map[string]string{"Max-Forwards": "many"}).Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodTrace, "/animals", strings.NewReader(`{"name":"Lion"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,295 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go-test/auth"
+	"go-test/middleware"
+	"go-test/service"
+	"go-test/tunnel"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+)
+
+// staticResolver - host names of the tests
+type staticResolver map[string][]netip.Addr
+
+func (r staticResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+var tunnelHosts = staticResolver{
+	"example.com":          {netip.MustParseAddr("93.184.215.16")},
+	"api.example.com":      {netip.MustParseAddr("93.184.215.14")},
+	"cdn.example.com":      {netip.MustParseAddr("93.184.215.15")},
+	"internal.example.com": {netip.MustParseAddr("10.1.2.3")},
+	// public and private at once, as rebinding attacks answer
+	"mixed.example.net": {netip.MustParseAddr("198.51.100.7"), netip.MustParseAddr("127.0.0.1")},
+	"other.example.net": {netip.MustParseAddr("198.51.100.8")},
+}
+
+func newTestProxy(t *testing.T, policy tunnel.Policy, limits tunnel.Limits) *tunnel.Proxy {
+	proxy, err := tunnel.NewProxy(policy, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy.Resolver = tunnelHosts
+	return proxy
+}
+
+func TestTunnelDestinations(t *testing.T) {
+	ctx := context.Background()
+	open := newTestProxy(t, tunnel.Policy{Deny: []string{"cdn.example.com", "198.51.100.0/24"}, Ports: []int{443}}, tunnel.Limits{})
+
+	destination, err := open.Resolve(ctx, "api.example.com:443")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "93.184.215.14:443", destination.String())
+	for target, expected := range map[string]error{
+		"api.example.com:22":       tunnel.ErrForbiddenDestination,
+		"cdn.example.com:443":      tunnel.ErrForbiddenDestination,
+		"other.example.net:443":    tunnel.ErrForbiddenDestination,
+		"internal.example.com:443": tunnel.ErrForbiddenDestination,
+		"127.0.0.1:443":            tunnel.ErrForbiddenDestination,
+		"[::1]:443":                tunnel.ErrForbiddenDestination,
+		"[::ffff:10.0.0.1]:443":    tunnel.ErrForbiddenDestination,
+		"169.254.169.254:443":      tunnel.ErrForbiddenDestination,
+		"api.example.com":          tunnel.ErrInvalidTarget,
+		"api.example.com:0":        tunnel.ErrInvalidTarget,
+	} {
+		_, err := open.Resolve(ctx, target)
+		assert.Equal(t, expected, err)
+	}
+
+	// allow lists restrict the destinations, networks may name private ones
+	restricted := newTestProxy(t, tunnel.Policy{Allow: []string{"*.example.com", "10.0.0.0/8"}, Deny: []string{"cdn.example.com"}}, tunnel.Limits{})
+	_, err = restricted.Resolve(ctx, "api.example.com:8443")
+	assert.Equal(t, nil, err)
+	_, err = restricted.Resolve(ctx, "internal.example.com:443")
+	assert.Equal(t, nil, err)
+	for _, target := range []string{"cdn.example.com:443", "other.example.net:443", "example.com:443", "93.184.215.14:443", "127.0.0.1:443"} {
+		_, err := restricted.Resolve(ctx, target)
+		assert.Equal(t, tunnel.ErrForbiddenDestination, err)
+	}
+
+	// names allowed by the list still never reach private addresses through DNS
+	named := newTestProxy(t, tunnel.Policy{Allow: []string{"mixed.example.net", "internal.example.com"}}, tunnel.Limits{})
+	_, err = named.Resolve(ctx, "mixed.example.net:443")
+	assert.Equal(t, tunnel.ErrForbiddenDestination, err)
+	_, err = named.Resolve(ctx, "internal.example.com:443")
+	assert.Equal(t, tunnel.ErrForbiddenDestination, err)
+	_, err = named.Resolve(ctx, "unknown.example.org:443")
+	assert.Equal(t, tunnel.ErrForbiddenDestination, err)
+
+	_, err = tunnel.NewProxy(tunnel.Policy{Allow: []string{"*.*.example.com"}}, tunnel.Limits{})
+	assert.NotEqual(t, nil, err)
+	_, err = tunnel.NewProxy(tunnel.Policy{Ports: []int{70000}}, tunnel.Limits{})
+	assert.NotEqual(t, nil, err)
+}
+
+func TestTunnelLimits(t *testing.T) {
+	proxy := newTestProxy(t, tunnel.Policy{}, tunnel.Limits{MaxPerClient: 2})
+	first, ok := proxy.Acquire("keeper")
+	assert.Equal(t, true, ok)
+	_, ok = proxy.Acquire("keeper")
+	assert.Equal(t, true, ok)
+	_, ok = proxy.Acquire("keeper")
+	assert.Equal(t, false, ok)
+	// clients are counted separately
+	_, ok = proxy.Acquire("vet")
+	assert.Equal(t, true, ok)
+
+	// releasing twice frees a single tunnel
+	first()
+	first()
+	_, ok = proxy.Acquire("keeper")
+	assert.Equal(t, true, ok)
+	_, ok = proxy.Acquire("keeper")
+	assert.Equal(t, false, ok)
+}
+
+// startEchoServer answers every connection with what it receives
+func startEchoServer(t *testing.T) net.Listener {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return lis
+}
+
+// recordCollector - reports of closed tunnels
+type recordCollector struct {
+	mu      sync.Mutex
+	records []tunnel.Record
+	closed  chan struct{}
+}
+
+func (r *recordCollector) report(record tunnel.Record) {
+	r.mu.Lock()
+	r.records = append(r.records, record)
+	r.mu.Unlock()
+	r.closed <- struct{}{}
+}
+
+// newTunnelServer serves CONNECT like main does, with tunnels to loopback allowed
+func newTunnelServer(t *testing.T, secret []byte, limits tunnel.Limits) (*httptest.Server, *recordCollector) {
+	gin.SetMode(gin.TestMode)
+	proxy := newTestProxy(t, tunnel.Policy{Allow: []string{"127.0.0.1/32"}}, limits)
+	collector := &recordCollector{closed: make(chan struct{}, 10)}
+	proxy.Report = collector.report
+	s := &service.Service{Verifier: &auth.Verifier{Keys: auth.HMACSecret(secret)}, Policy: &auth.DefaultPolicy, Tunnels: proxy}
+
+	r := gin.New()
+	r.Use(middleware.ErrorMiddleware())
+	r.Use(func(c *gin.Context) {
+		if c.Request.Method == http.MethodConnect {
+			s.Connect(c)
+		}
+	})
+	r.NoRoute(middleware.NotFoundHandler)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server, collector
+}
+
+// openTunnel sends a CONNECT request and returns the connection with its response
+func openTunnel(t *testing.T, server *httptest.Server, target, authorization string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	req, _ := http.NewRequest(http.MethodConnect, "", nil)
+	req.Host = target
+	if authorization != "" {
+		req.Header.Set("Proxy-Authorization", authorization)
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, reader, resp
+}
+
+func TestTunnelRelay(t *testing.T) {
+	secret := []byte("test-secret")
+	echo := startEchoServer(t)
+	server, collector := newTunnelServer(t, secret, tunnel.Limits{DialTimeout: time.Second, MaxPerClient: 1})
+	admin := validClaims("boss")
+	admin["roles"] = []string{"admin"}
+	token := "Bearer " + signToken(t, jwt.SigningMethodHS256, "", secret, admin)
+
+	// proxy credentials and the tunnel permission are required
+	_, _, resp := openTunnel(t, server, echo.Addr().String(), "")
+	assert.Equal(t, http.StatusProxyAuthRequired, resp.StatusCode)
+	assert.Equal(t, "Bearer", resp.Header.Get("Proxy-Authenticate"))
+	editor := "Bearer " + signToken(t, jwt.SigningMethodHS256, "", secret, validClaims("keeper"))
+	_, _, resp = openTunnel(t, server, echo.Addr().String(), editor)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	// API keys are not accepted without key store
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("proxy:ak_0_secret"))
+	_, _, resp = openTunnel(t, server, echo.Addr().String(), basic)
+	assert.Equal(t, http.StatusProxyAuthRequired, resp.StatusCode)
+
+	// destinations outside the policy
+	_, _, resp = openTunnel(t, server, "127.0.0.2:443", token)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	conn, reader, resp := openTunnel(t, server, echo.Addr().String(), token)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err := conn.Write([]byte("ping"))
+	assert.Equal(t, nil, err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(reader, reply)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "ping", string(reply))
+
+	// one tunnel per client
+	_, _, resp = openTunnel(t, server, echo.Addr().String(), token)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	conn.Close()
+	waitForTunnel(t, collector)
+	record := collector.records[0]
+	assert.Equal(t, "boss", record.Client)
+	assert.Equal(t, echo.Addr().String(), record.Target)
+	assert.Equal(t, int64(4), record.BytesUp)
+	assert.Equal(t, int64(4), record.BytesDown)
+
+	// the tunnel of the client is released
+	_, _, resp = openTunnel(t, server, echo.Addr().String(), token)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTunnelTimeouts(t *testing.T) {
+	secret := []byte("test-secret")
+	echo := startEchoServer(t)
+	admin := validClaims("boss")
+	admin["roles"] = []string{"admin"}
+	token := "Bearer " + signToken(t, jwt.SigningMethodHS256, "", secret, admin)
+
+	// quiet tunnels are closed
+	server, collector := newTunnelServer(t, secret, tunnel.Limits{IdleTimeout: 100 * time.Millisecond})
+	conn, reader, resp := openTunnel(t, server, echo.Addr().String(), token)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	waitForTunnel(t, collector)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err := reader.ReadByte()
+	assert.Equal(t, io.EOF, err)
+
+	// busy ones too, once they reach the maximum duration
+	server, collector = newTunnelServer(t, secret, tunnel.Limits{IdleTimeout: time.Second, MaxDuration: 200 * time.Millisecond})
+	conn, _, resp = openTunnel(t, server, echo.Addr().String(), token)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	go func() {
+		for {
+			if _, err := conn.Write([]byte("ping")); err != nil {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+	waitForTunnel(t, collector)
+	record := collector.records[0]
+	assert.Equal(t, true, record.Duration < time.Second)
+	assert.Equal(t, true, record.BytesUp > 0)
+}
+
+func waitForTunnel(t *testing.T, collector *recordCollector) {
+	select {
+	case <-collector.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal(errors.New("tunnel was not closed"))
+	}
+}
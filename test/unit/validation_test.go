@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/animals"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	inputModels "go-test/models"
+	"go-test/problems"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newCreateAnimalEngine serves animal creation with error reporting
+func newCreateAnimalEngine(mockRepository *mocks.MockRepository) *gin.Engine {
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	var mu sync.Mutex
+	rp := repository.AnimalRepository(mockRepository)
+	store := &animals.Store{Mu: &mu, Repository: &rp}
+	r.POST("/animals", routers.Negotiate(), func(c *gin.Context) {
+		routers.CreateAnimal(c, store)
+	})
+	return r
+}
+
+func TestCreateAnimalNormalizesInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// stored with trimmed and collapsed whitespace
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("Create", inputModels.Animal{Name: "Snow Leopard", Type: 1, Description: "Lives in\nmountains"}).
+		Return(models.Animal{ID: 5, Name: "Snow Leopard", Type: 1, Description: "Lives in\nmountains"}, nil)
+	r := newCreateAnimalEngine(mockRepository)
+
+	body := `{"name":"  Snow \t Leopard ","type":1,"description":" Lives in\r\nmountains\n"}`
+	req, _ := http.NewRequest("POST", "/animals", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepository.AssertExpectations(t)
+}
+
+func TestCreateAnimalValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// invalid input never reaches the database
+	mockRepository := new(mocks.MockRepository)
+	r := newCreateAnimalEngine(mockRepository)
+
+	body := `{"name":"   ","type":-1,"description":"` + strings.Repeat("a", 2001) + `"}`
+	req, _ := http.NewRequest("POST", "/animals", strings.NewReader(body))
+	req.Header.Set("Accept-Language", "de-DE, es;q=0.8, en;q=0.5")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "es", w.Header().Get("Content-Language"))
+	assert.Equal(t, `{"type":"/problems/validation-error","title":"Validation failed","status":400,"detail":"Request has invalid fields","instance":"/animals","errors":[`+
+		`{"field":"name","message":"name es un campo requerido"},`+
+		`{"field":"type","message":"type debe ser uno de los tipos de animal conocidos"},`+
+		`{"field":"description","message":"description debe tener un máximo de 2.000 caracteres de longitud"}]}`, w.Body.String())
+	mockRepository.AssertNotCalled(t, "Create")
+}
+
+func TestCreateAnimalMalformedBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// bodies which cannot be decoded are the client's fault in every format
+	mockRepository := new(mocks.MockRepository)
+	r := newCreateAnimalEngine(mockRepository)
+	for contentType, body := range map[string]string{
+		"application/json":       `{"name":`,
+		"application/xml":        `<animal><name>Lion</name>`,
+		"application/x-yaml":     "name: [Lion",
+		"application/x-msgpack":  "\xc1",
+		"application/x-protobuf": "\xff",
+		routers.MIMEAnimalsV1:    `{"type":"three"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/animals", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var problem problems.Problem
+		assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, problems.TypeMalformedBody, problem.Type)
+	}
+	mockRepository.AssertNotCalled(t, "Create")
+}
@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"go-test/db-utils/models"
+	"go-test/db-utils/repository"
+	"go-test/middleware"
+	outputModels "go-test/models"
+	"go-test/routers"
+	"go-test/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newVersionedEngine serves the animal list as v1, v2 and unversioned with deprecated v1
+func newVersionedEngine() *gin.Engine {
+	mockRepository := new(mocks.MockRepository)
+	mockRepository.On("FindAll").Return([]models.Animal{
+		{ID: 1, Name: "Lion", Type: 1, Description: "King of the jungle"},
+	}, nil)
+
+	r := gin.Default()
+	r.Use(middleware.ErrorMiddleware())
+	var mu sync.Mutex
+	rp := repository.AnimalRepository(mockRepository)
+	deprecations := map[int]routers.Deprecation{1: {
+		Since:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	for prefix, version := range map[string]int{"/v1": 1, "/v2": 2, "": 0} {
+		r.GET(prefix+"/animals", routers.Version(version, deprecations), routers.Negotiate(), func(c *gin.Context) {
+			routers.GetAnimals(c, &mu, &rp)
+		})
+	}
+	return r
+}
+
+func TestVersionedPaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newVersionedEngine()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/animals", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var v1 []outputModels.AnimalWithID
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &v1))
+	assert.Equal(t, "Lion", v1[0].Animal.Name)
+	assert.Equal(t, "@1767225600", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Wed, 01 Jul 2026 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v2/animals>; rel="successor-version"`, w.Header().Get("Link"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v2/animals", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var v2 []outputModels.AnimalV2
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &v2))
+	assert.Equal(t, "Lion", v2[0].Name)
+	assert.Equal(t, "", w.Header().Get("Deprecation"))
+}
+
+func TestVersionByAcceptHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := newVersionedEngine()
+
+	// unversioned paths stay on v1 by default
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/animals", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, "", w.Header().Get("Deprecation"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/animals", nil)
+	req.Header.Set("Accept", routers.MIMEAnimalsV2)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, routers.MIMEAnimalsV2+"; charset=utf-8", w.Header().Get("Content-Type"))
+	var v2 []outputModels.AnimalV2
+	assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &v2))
+	assert.Equal(t, 1, v2[0].ID)
+	assert.Equal(t, "Lion", v2[0].Name)
+
+	// version of the path and of the header disagree
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v1/animals", nil)
+	req.Header.Set("Accept", routers.MIMEAnimalsV2)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/animals", nil)
+	req.Header.Set("Accept", "application/vnd.animals.v3+json")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
@@ -0,0 +1,169 @@
+// Package tunnel relays the CONNECT tunnels of the API to the destinations
+// its policy permits.
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidTarget - CONNECT target is not a host:port pair.
+	ErrInvalidTarget = errors.New("tunnel target must be host:port")
+	// ErrForbiddenDestination - policy does not permit the destination.
+	ErrForbiddenDestination = errors.New("tunnel destination is not allowed")
+)
+
+// Policy - destinations tunnels may reach. Denied entries win over allowed
+// ones. Loopback, private and link-local addresses are only reached when an
+// allowed network names them or AllowPrivate is set.
+type Policy struct {
+	// host names, patterns like *.example.com, addresses or networks like
+	// 203.0.113.0/24, every public destination when empty
+	Allow []string
+	Deny  []string
+	// destination ports, every port when empty
+	Ports        []int
+	AllowPrivate bool
+}
+
+// rules - parsed entries of an allow or deny list
+type rules struct {
+	hosts    []string
+	suffixes []string
+	networks []netip.Prefix
+}
+
+// parseRules sorts the entries into host names, wildcard suffixes and networks
+func parseRules(entries []string) (rules, error) {
+	var r rules
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if network, err := netip.ParsePrefix(entry); err == nil {
+			r.networks = append(r.networks, network.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			r.networks = append(r.networks, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		if suffix, ok := strings.CutPrefix(entry, "*."); ok && suffix != "" && !strings.Contains(suffix, "*") {
+			r.suffixes = append(r.suffixes, "."+suffix)
+			continue
+		}
+		if entry == "" || strings.ContainsAny(entry, "*/: ") {
+			return r, fmt.Errorf("tunnel: invalid destination %q", entry)
+		}
+		r.hosts = append(r.hosts, entry)
+	}
+	return r, nil
+}
+
+// matchesHost reports whether a host name or pattern names the host
+func (r rules) matchesHost(host string) bool {
+	return slices.Contains(r.hosts, host) || slices.ContainsFunc(r.suffixes, func(suffix string) bool {
+		return strings.HasSuffix(host, suffix)
+	})
+}
+
+// matchesAddr reports whether a network contains the address
+func (r rules) matchesAddr(addr netip.Addr) bool {
+	return slices.ContainsFunc(r.networks, func(network netip.Prefix) bool {
+		return network.Contains(addr)
+	})
+}
+
+// sharedAddressSpace - carrier-grade NAT range of RFC 6598
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPrivate reports addresses of the host itself or of internal networks
+func isPrivate(addr netip.Addr) bool {
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() || sharedAddressSpace.Contains(addr)
+}
+
+// Resolver - host name lookup, net.DefaultResolver satisfies it.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// destinations - compiled policy
+type destinations struct {
+	allow, deny  rules
+	ports        []int
+	allowPrivate bool
+	restricted   bool
+}
+
+func compile(policy Policy) (destinations, error) {
+	allow, err := parseRules(policy.Allow)
+	if err != nil {
+		return destinations{}, err
+	}
+	deny, err := parseRules(policy.Deny)
+	if err != nil {
+		return destinations{}, err
+	}
+	for _, port := range policy.Ports {
+		if port < 1 || port > 65535 {
+			return destinations{}, fmt.Errorf("tunnel: invalid port %d", port)
+		}
+	}
+	return destinations{allow: allow, deny: deny, ports: policy.Ports, allowPrivate: policy.AllowPrivate, restricted: len(policy.Allow) > 0}, nil
+}
+
+// resolve checks the target against the policy and returns the address to dial.
+// Every address of a host name must be permitted, so that names cannot be made
+// to point at internal services after they are checked.
+func (d destinations) resolve(ctx context.Context, resolver Resolver, target string) (netip.AddrPort, error) {
+	host, portText, err := net.SplitHostPort(target)
+	if err != nil || host == "" {
+		return netip.AddrPort{}, ErrInvalidTarget
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil || port < 1 || port > 65535 {
+		return netip.AddrPort{}, ErrInvalidTarget
+	}
+	if len(d.ports) > 0 && !slices.Contains(d.ports, port) {
+		return netip.AddrPort{}, ErrForbiddenDestination
+	}
+
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	var addrs []netip.Addr
+	hostAllowed := false
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{addr}
+	} else {
+		if d.deny.matchesHost(host) {
+			return netip.AddrPort{}, ErrForbiddenDestination
+		}
+		hostAllowed = d.allow.matchesHost(host)
+		if d.restricted && !hostAllowed && len(d.allow.networks) == 0 {
+			// nothing to resolve for
+			return netip.AddrPort{}, ErrForbiddenDestination
+		}
+		addrs, err = resolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return netip.AddrPort{}, fmt.Errorf("tunnel: resolving %s: %w", host, err)
+		}
+		if len(addrs) == 0 {
+			return netip.AddrPort{}, fmt.Errorf("tunnel: %s has no address", host)
+		}
+	}
+
+	for i, addr := range addrs {
+		addr = addr.Unmap()
+		addrs[i] = addr
+		named := d.allow.matchesAddr(addr)
+		if d.deny.matchesAddr(addr) || (d.restricted && !hostAllowed && !named) || (isPrivate(addr) && !d.allowPrivate && !named) {
+			return netip.AddrPort{}, ErrForbiddenDestination
+		}
+	}
+	return netip.AddrPortFrom(addrs[0], uint16(port)), nil
+}
@@ -0,0 +1,187 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limits - bounds of the tunnels of a proxy, zero values are unbounded.
+type Limits struct {
+	DialTimeout time.Duration
+	// tunnels without traffic in either direction are closed
+	IdleTimeout time.Duration
+	MaxDuration time.Duration
+	// open tunnels of one client
+	MaxPerClient int
+}
+
+// Record - accounting of one tunnel, reported once it is closed.
+type Record struct {
+	ID     uint64
+	Client string
+	// requested host:port and the address dialed for it
+	Target      string
+	Destination netip.AddrPort
+	// client to destination and back
+	BytesUp   int64
+	BytesDown int64
+	Opened    time.Time
+	Duration  time.Duration
+}
+
+// Proxy - relays tunnels to the destinations of its policy.
+type Proxy struct {
+	Resolver Resolver
+	Limits   Limits
+	// receives the record of every closed tunnel, logged when nil
+	Report func(Record)
+
+	destinations destinations
+	mu           sync.Mutex
+	open         map[string]int
+	lastID       atomic.Uint64
+}
+
+// NewProxy returns a proxy resolving host names with net.DefaultResolver.
+func NewProxy(policy Policy, limits Limits) (*Proxy, error) {
+	destinations, err := compile(policy)
+	if err != nil {
+		return nil, err
+	}
+	return &Proxy{Resolver: net.DefaultResolver, Limits: limits, destinations: destinations, open: map[string]int{}}, nil
+}
+
+// Resolve returns the address to dial for a host:port target, ErrInvalidTarget
+// or ErrForbiddenDestination when the policy refuses it.
+func (p *Proxy) Resolve(ctx context.Context, target string) (netip.AddrPort, error) {
+	return p.destinations.resolve(ctx, p.Resolver, target)
+}
+
+// Acquire counts a tunnel of the client, false when it has as many open as allowed.
+// The returned function releases it.
+func (p *Proxy) Acquire(client string) (func(), bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Limits.MaxPerClient > 0 && p.open[client] >= p.Limits.MaxPerClient {
+		return nil, false
+	}
+	p.open[client]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.open[client]--; p.open[client] <= 0 {
+				delete(p.open, client)
+			}
+		})
+	}, true
+}
+
+// Dial connects to a resolved destination.
+func (p *Proxy) Dial(ctx context.Context, destination netip.AddrPort) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: p.Limits.DialTimeout}
+	return dialer.DialContext(ctx, "tcp", destination.String())
+}
+
+// Relay copies traffic between the hijacked client connection and the destination
+// until either side closes or a timeout expires, then closes both and reports
+// the tunnel. Bytes the client sent after its request are read from buffered.
+func (p *Proxy) Relay(client net.Conn, buffered *bufio.Reader, destination net.Conn, record Record) Record {
+	record.ID = p.lastID.Add(1)
+	record.Opened = time.Now()
+	var deadline time.Time
+	if p.Limits.MaxDuration > 0 {
+		deadline = record.Opened.Add(p.Limits.MaxDuration)
+	}
+	activity := &activity{idle: p.Limits.IdleTimeout, deadline: deadline, conns: []net.Conn{client, destination}}
+	activity.touch()
+
+	var source io.Reader = client
+	if buffered != nil && buffered.Buffered() > 0 {
+		source = io.MultiReader(io.LimitReader(buffered, int64(buffered.Buffered())), client)
+	}
+	var up, down int64
+	var wg sync.WaitGroup
+	wg.Add(2)
+	// the first direction to end closes the tunnel
+	closeBoth := sync.OnceFunc(func() {
+		client.Close()
+		destination.Close()
+	})
+	go func() {
+		defer wg.Done()
+		defer closeBoth()
+		up = copyActive(destination, source, activity)
+	}()
+	go func() {
+		defer wg.Done()
+		defer closeBoth()
+		down = copyActive(client, destination, activity)
+	}()
+	wg.Wait()
+
+	record.BytesUp, record.BytesDown = up, down
+	record.Duration = time.Since(record.Opened)
+	if p.Report != nil {
+		p.Report(record)
+	} else {
+		log.Printf("tunnel %d of %s to %s (%s) closed after %s, %d bytes up, %d bytes down",
+			record.ID, record.Client, record.Target, record.Destination, record.Duration.Round(time.Millisecond), record.BytesUp, record.BytesDown)
+	}
+	return record
+}
+
+// activity moves the deadlines of the tunnel connections on with its traffic
+type activity struct {
+	idle     time.Duration
+	deadline time.Time
+	conns    []net.Conn
+	mu       sync.Mutex
+}
+
+// touch sets the deadline of both connections to the end of the idle time,
+// never past the maximum duration
+func (a *activity) touch() {
+	next := a.deadline
+	if a.idle > 0 {
+		if idle := time.Now().Add(a.idle); next.IsZero() || idle.Before(next) {
+			next = idle
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, conn := range a.conns {
+		conn.SetDeadline(next)
+	}
+}
+
+// copyActive copies until src ends or a deadline expires and returns the bytes written
+func copyActive(dst io.Writer, src io.Reader, activity *activity) int64 {
+	buf := make([]byte, 32*1024)
+	var written int64
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			activity.touch()
+			m, werr := dst.Write(buf[:n])
+			written += int64(m)
+			if werr != nil {
+				return written
+			}
+		}
+		if err != nil {
+			return written
+		}
+	}
+}
@@ -7,17 +7,72 @@ import (
 )
 
 type Config struct {
-	RequestsPerMinute int    `json:"REQUESTS_PER_MINUTE""`
-	DBHeathInterval   int64  `json:"DATABASE_HEALTH_LOOP_INTERVAL""`
-	DBUser            string `json:"DB_USER"`
-	DBPassword        string `json:"DB_PASSWORD"`
-	DBName            string `json:"DB_NAME"`
-	DBHost            string `json:"DB_HOST"`
-	DBPort            string `json:"DB_PORT"`
-	DBSSLMode         string `json:"DB_SSLMODE"`
-	RedisAddress      string `json:"REDIS_ADDRESS"`
-	RedisPassword     string `json:"REDIS_PASSWORD"`
-	RedisDB           int    `json:"REDIS_DB"`
+	GRPCAddress          string                `json:"GRPC_ADDRESS"`
+	RequestsPerMinute    int                   `json:"REQUESTS_PER_MINUTE"` // per client, across all replicas
+	RateLimitBurst       int                   `json:"RATE_LIMIT_BURST"`    // requests at once, REQUESTS_PER_MINUTE when zero
+	RateLimitRoutes      map[string]RouteLimit `json:"RATE_LIMIT_ROUTES"`   // by "METHOD /path" of unversioned routes
+	DBHeathInterval      int64                 `json:"DATABASE_HEALTH_LOOP_INTERVAL"`
+	DBUser               string                `json:"DB_USER"`
+	DBPassword           string                `json:"DB_PASSWORD"`
+	DBName               string                `json:"DB_NAME"`
+	DBHost               string                `json:"DB_HOST"`
+	DBPort               string                `json:"DB_PORT"`
+	DBSSLMode            string                `json:"DB_SSLMODE"`
+	RedisAddress         string                `json:"REDIS_ADDRESS"`
+	RedisPassword        string                `json:"REDIS_PASSWORD"`
+	RedisDB              int                   `json:"REDIS_DB"`
+	RedisLogCommands     bool                  `json:"REDIS_LOG_COMMANDS"` // debug, log every command with its request id
+	ImportBackground     int                   `json:"IMPORT_BACKGROUND_ROWS"`
+	ImportMaxBytes       int64                 `json:"IMPORT_MAX_BYTES"` // 0 is unlimited
+	JobWorkers           int                   `json:"JOB_WORKERS"`
+	JobPollInterval      int64                 `json:"JOB_POLL_INTERVAL"`
+	JobMaxAttempts       int                   `json:"JOB_MAX_ATTEMPTS"`
+	JobRetryBackoff      int64                 `json:"JOB_RETRY_BACKOFF"`
+	V1Deprecated         string                `json:"V1_DEPRECATED"` // RFC 3339, no Deprecation header when empty
+	V1Sunset             string                `json:"V1_SUNSET"`     // RFC 3339, no Sunset header when empty
+	GraphQLMaxDepth      int                   `json:"GRAPHQL_MAX_DEPTH"`
+	GraphQLMaxComplexity int                   `json:"GRAPHQL_MAX_COMPLEXITY"`
+	AuthJWTSecret        string                `json:"AUTH_JWT_SECRET"` // HS256, authentication is off without any key source
+	AuthJWKSFile         string                `json:"AUTH_JWKS_FILE"`
+	AuthJWKSURL          string                `json:"AUTH_JWKS_URL"`
+	AuthJWKSTTL          int64                 `json:"AUTH_JWKS_TTL"` // seconds
+	AuthIssuer           string                `json:"AUTH_ISSUER"`
+	AuthAudience         string                `json:"AUTH_AUDIENCE"`
+	AuthAPIKeys          bool                  `json:"AUTH_API_KEYS"`        // accept X-API-Key, authentication is on then
+	RBACPolicyFile       string                `json:"RBAC_POLICY_FILE"`     // permissions of roles and scopes, built-in policy when empty
+	CORSAllowedOrigins   []string              `json:"CORS_ALLOWED_ORIGINS"` // exact, https://*.example.com or *, no cross-origin access when empty
+	CORSAllowedMethods   []string              `json:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders   []string              `json:"CORS_ALLOWED_HEADERS"`
+	CORSExposedHeaders   []string              `json:"CORS_EXPOSED_HEADERS"`
+	CORSAllowCredentials bool                  `json:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge           int64                 `json:"CORS_MAX_AGE"`    // seconds
+	ConnectEnabled       bool                  `json:"CONNECT_ENABLED"` // CONNECT tunnels, needs authentication
+	ConnectAllow         []string              `json:"CONNECT_ALLOW"`   // hosts, *.example.com or networks, every public destination when empty
+	ConnectDeny          []string              `json:"CONNECT_DENY"`
+	ConnectPorts         []int                 `json:"CONNECT_PORTS"` // every port when empty
+	ConnectAllowPrivate  bool                  `json:"CONNECT_ALLOW_PRIVATE"`
+	ConnectDialTimeout   int64                 `json:"CONNECT_DIAL_TIMEOUT"` // seconds
+	ConnectIdleTimeout   int64                 `json:"CONNECT_IDLE_TIMEOUT"` // seconds
+	ConnectMaxDuration   int64                 `json:"CONNECT_MAX_DURATION"` // seconds
+	ConnectMaxPerClient  int                   `json:"CONNECT_MAX_PER_CLIENT"`
+	TraceEnabled         bool                  `json:"TRACE_ENABLED"`      // reflect TRACE requests, credentials are left out
+	QuotaPlans           map[string]QuotaPlan  `json:"QUOTA_PLANS"`        // no quotas when empty
+	QuotaDefaultPlan     string                `json:"QUOTA_DEFAULT_PLAN"` // plan of API keys and tenants without one
+	QuotaWarnPercent     int                   `json:"QUOTA_WARN_PERCENT"` // Quota-Warning from this share of a quota
+}
+
+// RouteLimit - cost of the requests of a route, and its own budget when
+// RequestsPerMinute is set.
+type RouteLimit struct {
+	RequestsPerMinute int `json:"REQUESTS_PER_MINUTE"`
+	Burst             int `json:"BURST"`
+	Cost              int `json:"COST"`
+}
+
+// QuotaPlan - requests per day and month of a plan, 0 is unlimited.
+type QuotaPlan struct {
+	Daily   int64 `json:"DAILY"`
+	Monthly int64 `json:"MONTHLY"`
 }
 
 func LoadConfiguration(file string) Config {
@@ -0,0 +1,174 @@
+// Package validation registers the input rules of the API with the gin validator.
+// Rules are installed on import, so that every binding of the routers sees them.
+package validation
+
+import (
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	esTranslations "github.com/go-playground/validator/v10/translations/es"
+	frTranslations "github.com/go-playground/validator/v10/translations/fr"
+	ruTranslations "github.com/go-playground/validator/v10/translations/ru"
+	"go-test/models"
+	"go-test/problems"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Normalizer - input model which cleans up its fields before validation.
+type Normalizer interface {
+	Normalize()
+}
+
+// normalizingValidator normalizes input models before validating them
+type normalizingValidator struct {
+	binding.StructValidator
+}
+
+func (v normalizingValidator) ValidateStruct(obj any) error {
+	if n, ok := obj.(Normalizer); ok {
+		n.Normalize()
+	}
+	return v.StructValidator.ValidateStruct(obj)
+}
+
+// messages of custom rules by locale
+var customMessages = map[string]map[string]string{
+	"en": {
+		"nocontrol":  "{0} must not contain control characters",
+		"animaltype": "{0} must be one of the known animal types",
+	},
+	"es": {
+		"nocontrol":  "{0} no debe contener caracteres de control",
+		"animaltype": "{0} debe ser uno de los tipos de animal conocidos",
+	},
+	"fr": {
+		"nocontrol":  "{0} ne doit pas contenir de caractères de contrôle",
+		"animaltype": "{0} doit être un type d'animal connu",
+	},
+	"ru": {
+		"nocontrol":  "{0} не должно содержать управляющих символов",
+		"animaltype": "{0} должно быть одним из известных типов животных",
+	},
+}
+
+var universal *ut.UniversalTranslator
+
+func init() {
+	v := binding.Validator.Engine().(*validator.Validate)
+	// report fields by their json names
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+	mustRegister(v.RegisterValidation("nocontrol", noControl))
+	mustRegister(v.RegisterValidation("animaltype", animalType))
+
+	// english is the fallback locale
+	universal = ut.New(en.New(), en.New(), es.New(), fr.New(), ru.New())
+	defaults := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": enTranslations.RegisterDefaultTranslations,
+		"es": esTranslations.RegisterDefaultTranslations,
+		"fr": frTranslations.RegisterDefaultTranslations,
+		"ru": ruTranslations.RegisterDefaultTranslations,
+	}
+	for locale, register := range defaults {
+		trans, _ := universal.GetTranslator(locale)
+		mustRegister(register(v, trans))
+		for tag, message := range customMessages[locale] {
+			mustRegister(v.RegisterTranslation(tag, trans, addTranslation(tag, message), translate))
+		}
+	}
+
+	binding.Validator = normalizingValidator{binding.Validator}
+}
+
+func mustRegister(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func addTranslation(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, false)
+	}
+}
+
+func translate(trans ut.Translator, fe validator.FieldError) string {
+	message, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return message
+}
+
+// noControl rejects strings with control characters
+func noControl(fl validator.FieldLevel) bool {
+	return strings.IndexFunc(fl.Field().String(), unicode.IsControl) < 0
+}
+
+// animalType accepts codes of models.AnimalTypes
+func animalType(fl validator.FieldLevel) bool {
+	_, ok := models.AnimalTypes[int(fl.Field().Int())]
+	return ok
+}
+
+// FieldErrors translates validation errors into the first supported language
+// of the Accept-Language header and reports the locale used.
+func FieldErrors(errs validator.ValidationErrors, acceptLanguage string) ([]problems.FieldError, string) {
+	trans, _ := universal.FindTranslator(preferredLanguages(acceptLanguage)...)
+	var fields []problems.FieldError
+	for _, fe := range errs {
+		fields = append(fields, problems.FieldError{
+			Field:   fe.Field(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return fields, trans.Locale()
+}
+
+// preferredLanguages lists base languages of the Accept-Language header by descending quality
+func preferredLanguages(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		// translations exist per base language only
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		languages = append(languages, language{tag: base, quality: quality})
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+	var tags []string
+	for _, l := range languages {
+		if l.quality > 0 {
+			tags = append(tags, l.tag)
+		}
+	}
+	return tags
+}